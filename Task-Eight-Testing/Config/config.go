@@ -0,0 +1,283 @@
+package Config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
+	"time"
+)
+
+// Config holds every setting the server reads at startup.
+type Config struct {
+	Server    ServerConfig
+	Storage   StorageConfig
+	Auth      AuthConfig
+	Passwords PasswordConfig
+	Mail      MailConfig
+	Tasks     TaskConfig
+	Log       LogConfig
+	Metrics   MetricsConfig
+}
+
+type ServerConfig struct {
+	Port int
+	// TrustedProxies may set the client address through X-Forwarded-For.
+	TrustedProxies      []string
+	ShutdownGracePeriod time.Duration
+}
+
+type StorageConfig struct {
+	// Backend is "mongo", "sqlite" or "memory".
+	Backend      string
+	SQLitePath   string
+	MongoURI     string
+	Database     string
+	Collections  Repositories.Collections
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	MaxConnIdleTime        time.Duration
+	ServerSelectionTimeout time.Duration
+	ConnectAttempts        int
+	ConnectBackoff         time.Duration
+}
+
+// MinSecretKeyLength is the shortest auth.secret_key that draws no warning:
+// HS256 needs a key at least as long as its 32-byte hash. Shorter keys are
+// still accepted, so existing deployments keep starting, but a later
+// release will refuse them.
+const MinSecretKeyLength = 32
+
+type AuthConfig struct {
+	// SecretKey signs HS256 tokens and email verification links. It may be
+	// left out when KeysFile provides the signing keys.
+	SecretKey       string
+	KeysFile        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// UnverifiedAccess is what users who have not verified their email may
+	// do: "read_only", "block" or "allow".
+	UnverifiedAccess string
+	VerificationTTL  time.Duration
+	VerificationURL  string
+}
+
+type PasswordConfig struct {
+	PolicyFile string
+	Hashing    Infrastructure.PasswordHashing
+	ResetTTL   time.Duration
+	ResetURL   string
+}
+
+type MailConfig struct {
+	// OutboxDir receives outgoing mail as .eml files.
+	OutboxDir string
+}
+
+type TaskConfig struct {
+	WorkflowFile       string
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+}
+
+type LogConfig struct {
+	// Level is the least severe level written out.
+	Level slog.Level
+}
+
+type MetricsConfig struct {
+	// RequireAuth makes /metrics ask for AuthToken as a bearer token.
+	RequireAuth bool
+	AuthToken   string
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() Config {
+	mongo := Repositories.DefaultMongoOptions()
+	return Config{
+		Server: ServerConfig{
+			Port:                8080,
+			ShutdownGracePeriod: 15 * time.Second,
+		},
+		Storage: StorageConfig{
+			Backend:                "mongo",
+			SQLitePath:             "task_manager.db",
+			Database:               mongo.Database,
+			Collections:            mongo.Collections,
+			ReadTimeout:            mongo.Timeouts.Read,
+			WriteTimeout:           mongo.Timeouts.Write,
+			MaxPoolSize:            mongo.MaxPoolSize,
+			MinPoolSize:            mongo.MinPoolSize,
+			MaxConnIdleTime:        mongo.MaxConnIdleTime,
+			ServerSelectionTimeout: mongo.ServerSelectionTimeout,
+			ConnectAttempts:        mongo.ConnectAttempts,
+			ConnectBackoff:         mongo.ConnectBackoff,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:   Infrastructure.DefaultAccessTokenTTL,
+			RefreshTokenTTL:  Infrastructure.DefaultRefreshTokenTTL,
+			UnverifiedAccess: string(Domain.UnverifiedReadOnly),
+			VerificationTTL:  Infrastructure.DefaultVerificationTokenTTL,
+		},
+		Passwords: PasswordConfig{
+			Hashing:  Infrastructure.DefaultPasswordHashing(),
+			ResetTTL: Domain.DefaultResetTokenTTL,
+		},
+		Mail: MailConfig{OutboxDir: "outbox"},
+		Tasks: TaskConfig{
+			TrashRetention:     Domain.DefaultTrashRetention,
+			TrashPurgeInterval: time.Hour,
+		},
+		Log: LogConfig{Level: slog.LevelInfo},
+	}
+}
+
+// Validate returns every problem with the settings at once, joined into a
+// single error.
+func (c Config) Validate() error {
+	var problems []error
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problem("server.port must be between 1 and 65535")
+	}
+
+	switch c.Storage.Backend {
+	case "mongo":
+		if c.Storage.MongoURI == "" {
+			problem("storage.mongo_uri (MONGODB_URI) is required for the mongo backend")
+		}
+		if c.Storage.Database == "" {
+			problem("storage.database must not be empty")
+		}
+		for _, collection := range []struct{ name, value string }{
+			{"tasks", c.Storage.Collections.Tasks},
+			{"users", c.Storage.Collections.Users},
+			{"roles", c.Storage.Collections.Roles},
+			{"audit", c.Storage.Collections.Audit},
+			{"revoked_tokens", c.Storage.Collections.RevokedTokens},
+			{"one_time_tokens", c.Storage.Collections.OneTimeTokens},
+			{"login_attempts", c.Storage.Collections.LoginAttempts},
+		} {
+			if collection.value == "" {
+				problem("storage.collections.%s must not be empty", collection.name)
+			}
+		}
+		if c.Storage.MaxPoolSize > 0 && c.Storage.MinPoolSize > c.Storage.MaxPoolSize {
+			problem("storage.mongo_min_pool_size must not exceed storage.mongo_max_pool_size")
+		}
+		if c.Storage.ConnectAttempts < 1 {
+			problem("storage.mongo_connect_attempts must be at least 1")
+		}
+		if c.Storage.ServerSelectionTimeout <= 0 || c.Storage.ConnectBackoff <= 0 {
+			problem("storage.mongo_server_selection_timeout and storage.mongo_connect_backoff must be positive")
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			problem("storage.sqlite_path must not be empty")
+		}
+	case "memory":
+	default:
+		problem("storage.backend must be mongo, sqlite or memory, not %q", c.Storage.Backend)
+	}
+
+	if c.Auth.SecretKey == "" && c.Auth.KeysFile == "" {
+		problem("auth.secret_key (SECRET_KEY) is required unless auth.keys_file (JWT_KEYS_FILE) is set")
+	}
+	if _, err := Domain.ParseUnverifiedAccess(c.Auth.UnverifiedAccess); err != nil {
+		problem("auth.unverified_access: %v", err)
+	}
+	if c.Metrics.RequireAuth && c.Metrics.AuthToken == "" {
+		problem("metrics.auth_token (METRICS_AUTH_TOKEN) is required when metrics.require_auth is set")
+	}
+	if err := c.Passwords.Hashing.Validate(); err != nil {
+		problem("passwords: %v", err)
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.shutdown_grace_period", c.Server.ShutdownGracePeriod},
+		{"storage.read_timeout", c.Storage.ReadTimeout},
+		{"storage.write_timeout", c.Storage.WriteTimeout},
+		{"auth.access_token_ttl", c.Auth.AccessTokenTTL},
+		{"auth.refresh_token_ttl", c.Auth.RefreshTokenTTL},
+		{"auth.verification_ttl", c.Auth.VerificationTTL},
+		{"passwords.reset_ttl", c.Passwords.ResetTTL},
+		{"tasks.trash_retention", c.Tasks.TrashRetention},
+		{"tasks.trash_purge_interval", c.Tasks.TrashPurgeInterval},
+	} {
+		if d.value <= 0 {
+			problem("%s must be positive", d.name)
+		}
+	}
+
+	// The files themselves are parsed while wiring up the server; catch
+	// missing ones here along with everything else.
+	for _, file := range []struct{ name, path string }{
+		{"auth.keys_file", c.Auth.KeysFile},
+		{"passwords.policy_file", c.Passwords.PolicyFile},
+		{"tasks.workflow_file", c.Tasks.WorkflowFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			problem("%s: %v", file.name, err)
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// Warnings returns settings that still work but should be changed before a
+// later release refuses them.
+func (c Config) Warnings() []string {
+	var warnings []string
+	if c.Auth.SecretKey != "" && len(c.Auth.SecretKey) < MinSecretKeyLength {
+		warnings = append(warnings, fmt.Sprintf("auth.secret_key (SECRET_KEY) is shorter than %d bytes and will be refused by a later release; "+
+			"replace it with a random one, such as the output of `openssl rand -hex 32`. Changing it signs every user out and "+
+			"invalidates verification links already mailed", MinSecretKeyLength))
+	}
+	return warnings
+}
+
+// Timeouts returns the limits on single database operations.
+func (c StorageConfig) Timeouts() Repositories.Timeouts {
+	return Repositories.Timeouts{Read: c.ReadTimeout, Write: c.WriteTimeout}
+}
+
+// startupWrites is how many writes a single startup step, such as creating
+// a collection's indexes, is given time for.
+const startupWrites = 10
+
+// StartupTimeout bounds each step of preparing the database at startup once
+// it is reachable: waiting for a server, then a handful of writes.
+func (c StorageConfig) StartupTimeout() time.Duration {
+	return c.ServerSelectionTimeout + startupWrites*c.WriteTimeout
+}
+
+// MongoOptions returns the settings for Repositories.ConnectMongo.
+func (c StorageConfig) MongoOptions() Repositories.MongoOptions {
+	return Repositories.MongoOptions{
+		URI:                    c.MongoURI,
+		Database:               c.Database,
+		Collections:            c.Collections,
+		Timeouts:               c.Timeouts(),
+		MaxPoolSize:            c.MaxPoolSize,
+		MinPoolSize:            c.MinPoolSize,
+		MaxConnIdleTime:        c.MaxConnIdleTime,
+		ServerSelectionTimeout: c.ServerSelectionTimeout,
+		ConnectAttempts:        c.ConnectAttempts,
+		ConnectBackoff:         c.ConnectBackoff,
+	}
+}
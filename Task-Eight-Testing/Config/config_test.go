@@ -0,0 +1,182 @@
+package Config_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task-manager/Config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+// testSecret is just long enough to be accepted as auth.secret_key.
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Config.Load(nil, envFrom(map[string]string{
+		"SECRET_KEY":  testSecret,
+		"MONGODB_URI": "mongodb://localhost:27017",
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, "mongo", cfg.Storage.Backend)
+	assert.Equal(t, "task_db", cfg.Storage.Database)
+	assert.Equal(t, "user", cfg.Storage.Collections.Users)
+	assert.Equal(t, 15*time.Minute, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, "argon2id", cfg.Passwords.Hashing.Algorithm)
+	assert.Greater(t, cfg.Storage.StartupTimeout(), cfg.Storage.ServerSelectionTimeout)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 7000
+  trusted_proxies: [10.0.0.1, 10.0.0.2]
+storage:
+  backend: sqlite
+  database: from_file
+auth:
+  secret_key: a-secret-key-read-from-the-config-file
+  access_token_ttl: 5m
+`)
+
+	cfg, err := Config.Load(
+		[]string{"-config", path, "-server.port=9000"},
+		envFrom(map[string]string{"PORT": "8000", "MONGODB_DATABASE": "from_env", "SQLITE_PATH": ""}),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, cfg.Server.Port, "flags beat env and the file")
+	assert.Equal(t, "from_env", cfg.Storage.Database, "env beats the file")
+	assert.Equal(t, "task_manager.db", cfg.Storage.SQLitePath, "empty env vars are ignored")
+	assert.Equal(t, "sqlite", cfg.Storage.Backend)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cfg.Server.TrustedProxies)
+	assert.Equal(t, 5*time.Minute, cfg.Auth.AccessTokenTTL)
+}
+
+func TestLoad_TOMLFromEnv(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[storage]
+backend = "memory"
+
+[passwords]
+hash_algorithm = "bcrypt"
+bcrypt_cost = 11
+`)
+
+	cfg, err := Config.Load(nil, envFrom(map[string]string{"CONFIG_FILE": path, "SECRET_KEY": testSecret}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "memory", cfg.Storage.Backend)
+	assert.Equal(t, "bcrypt", cfg.Passwords.Hashing.Algorithm)
+	assert.Equal(t, 11, cfg.Passwords.Hashing.BcryptCost)
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  prot: 8080\n")
+
+	_, err := Config.Load([]string{"-config", path}, envFrom(map[string]string{
+		"PORT":                    "eighty",
+		"TRASH_RETENTION":         "a month",
+		"ARGON2_PARALLELISM":      "300",
+		"UNVERIFIED_ACCESS":       "sometimes",
+		"PASSWORD_HASH_ALGORITHM": "md5",
+		"PASSWORD_POLICY_FILE":    "/does/not/exist.json",
+	}))
+
+	if assert.Error(t, err) {
+		for _, want := range []string{
+			"unknown setting server.prot",
+			"PORT: invalid value",
+			"TRASH_RETENTION: invalid value",
+			"ARGON2_PARALLELISM: invalid value",
+			"storage.mongo_uri (MONGODB_URI) is required",
+			"auth.secret_key (SECRET_KEY) is required unless auth.keys_file (JWT_KEYS_FILE) is set",
+			"auth.unverified_access",
+			`unknown password hash algorithm "md5"`,
+			"passwords.policy_file",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	}
+}
+
+func TestLoad_LogLevel(t *testing.T) {
+	env := map[string]string{"SECRET_KEY": testSecret, "STORAGE": "memory"}
+
+	cfg, err := Config.Load(nil, envFrom(env))
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, cfg.Log.Level)
+
+	env["LOG_LEVEL"] = "debug"
+	cfg, err = Config.Load(nil, envFrom(env))
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, cfg.Log.Level)
+
+	env["LOG_LEVEL"] = "loud"
+	_, err = Config.Load(nil, envFrom(env))
+	assert.ErrorContains(t, err, "LOG_LEVEL: invalid value")
+}
+
+func TestLoad_MetricsAuth(t *testing.T) {
+	env := map[string]string{"SECRET_KEY": testSecret, "STORAGE": "memory"}
+
+	_, err := Config.Load([]string{"-metrics.require_auth"}, envFrom(env))
+	assert.ErrorContains(t, err, "metrics.auth_token (METRICS_AUTH_TOKEN) is required")
+
+	env["METRICS_AUTH_TOKEN"] = "scrape-secret"
+	cfg, err := Config.Load([]string{"-metrics.require_auth"}, envFrom(env))
+	assert.NoError(t, err)
+	assert.True(t, cfg.Metrics.RequireAuth)
+	assert.Equal(t, "scrape-secret", cfg.Metrics.AuthToken)
+}
+
+func TestLoad_SecretKey(t *testing.T) {
+	short := "too-short"
+	path := writeFile(t, "config.yaml", "auth:\n  secret_key: "+short+"\n")
+	for name, load := range map[string]func() (Config.Config, error){
+		"file": func() (Config.Config, error) {
+			return Config.Load([]string{"-config", path}, envFrom(map[string]string{"STORAGE": "memory"}))
+		},
+		"env": func() (Config.Config, error) {
+			return Config.Load(nil, envFrom(map[string]string{"STORAGE": "memory", "SECRET_KEY": short}))
+		},
+		"flag": func() (Config.Config, error) {
+			return Config.Load([]string{"-auth.secret_key=" + short}, envFrom(map[string]string{"STORAGE": "memory"}))
+		},
+	} {
+		cfg, err := load()
+		// Short keys are only warned about for now.
+		if assert.NoError(t, err, name) && assert.Len(t, cfg.Warnings(), 1, name) {
+			assert.Contains(t, cfg.Warnings()[0], "auth.secret_key (SECRET_KEY) is shorter than 32 bytes", name)
+		}
+	}
+
+	_, err := Config.Load(nil, envFrom(map[string]string{"STORAGE": "memory"}))
+	assert.ErrorContains(t, err, "auth.secret_key (SECRET_KEY) is required")
+
+	keys := writeFile(t, "keys.json", "{}")
+	cfg, err := Config.Load(nil, envFrom(map[string]string{"STORAGE": "memory", "JWT_KEYS_FILE": keys}))
+	assert.NoError(t, err, "a keys file replaces the secret key")
+	assert.Empty(t, cfg.Warnings())
+}
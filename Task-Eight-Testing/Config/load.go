@@ -0,0 +1,305 @@
+package Config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// setting ties one field of Config to its key in the config file, which is
+// also its flag name, and to its environment variable.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.port", "PORT", "port to listen on", intValue{&c.Server.Port}},
+		{"server.trusted_proxies", "TRUSTED_PROXIES", "comma-separated proxies allowed to set X-Forwarded-For", listValue{&c.Server.TrustedProxies}},
+		{"server.shutdown_grace_period", "SHUTDOWN_GRACE_PERIOD", "time in-flight requests get to finish on shutdown", durationValue{&c.Server.ShutdownGracePeriod}},
+
+		{"storage.backend", "STORAGE", "mongo, sqlite or memory", stringValue{&c.Storage.Backend}},
+		{"storage.sqlite_path", "SQLITE_PATH", "database file for the sqlite backend", stringValue{&c.Storage.SQLitePath}},
+		{"storage.mongo_uri", "MONGODB_URI", "connection string for the mongo backend", stringValue{&c.Storage.MongoURI}},
+		{"storage.database", "MONGODB_DATABASE", "mongo database name", stringValue{&c.Storage.Database}},
+		{"storage.collections.tasks", "MONGODB_TASKS_COLLECTION", "mongo collection for tasks", stringValue{&c.Storage.Collections.Tasks}},
+		{"storage.collections.users", "MONGODB_USERS_COLLECTION", "mongo collection for users", stringValue{&c.Storage.Collections.Users}},
+		{"storage.collections.roles", "MONGODB_ROLES_COLLECTION", "mongo collection for roles", stringValue{&c.Storage.Collections.Roles}},
+		{"storage.collections.audit", "MONGODB_AUDIT_COLLECTION", "mongo collection for the audit log", stringValue{&c.Storage.Collections.Audit}},
+		{"storage.collections.revoked_tokens", "MONGODB_REVOKED_TOKENS_COLLECTION", "mongo collection for revoked tokens", stringValue{&c.Storage.Collections.RevokedTokens}},
+		{"storage.collections.one_time_tokens", "MONGODB_ONE_TIME_TOKENS_COLLECTION", "mongo collection for password reset tokens", stringValue{&c.Storage.Collections.OneTimeTokens}},
+		{"storage.collections.login_attempts", "MONGODB_LOGIN_ATTEMPTS_COLLECTION", "mongo collection for failed logins", stringValue{&c.Storage.Collections.LoginAttempts}},
+		{"storage.read_timeout", "DB_READ_TIMEOUT", "limit on a single database read", durationValue{&c.Storage.ReadTimeout}},
+		{"storage.write_timeout", "DB_WRITE_TIMEOUT", "limit on a single database write", durationValue{&c.Storage.WriteTimeout}},
+		{"storage.mongo_max_pool_size", "MONGODB_MAX_POOL_SIZE", "most open connections per mongo server, 0 for no limit", uintValue[uint64]{&c.Storage.MaxPoolSize}},
+		{"storage.mongo_min_pool_size", "MONGODB_MIN_POOL_SIZE", "connections kept open per mongo server", uintValue[uint64]{&c.Storage.MinPoolSize}},
+		{"storage.mongo_max_conn_idle_time", "MONGODB_MAX_CONN_IDLE_TIME", "idle time after which a pooled connection closes, 0 for never", durationValue{&c.Storage.MaxConnIdleTime}},
+		{"storage.mongo_server_selection_timeout", "MONGODB_SERVER_SELECTION_TIMEOUT", "wait for a usable mongo server", durationValue{&c.Storage.ServerSelectionTimeout}},
+		{"storage.mongo_connect_attempts", "MONGODB_CONNECT_ATTEMPTS", "tries to reach mongo at startup", intValue{&c.Storage.ConnectAttempts}},
+		{"storage.mongo_connect_backoff", "MONGODB_CONNECT_BACKOFF", "first wait between startup connection tries, doubled each time", durationValue{&c.Storage.ConnectBackoff}},
+
+		{"auth.secret_key", "SECRET_KEY", "secret for HS256 tokens and email verification links", stringValue{&c.Auth.SecretKey}},
+		{"auth.keys_file", "JWT_KEYS_FILE", "JSON file listing the JWT signing keys", stringValue{&c.Auth.KeysFile}},
+		{"auth.access_token_ttl", "ACCESS_TOKEN_TTL", "lifetime of access tokens", durationValue{&c.Auth.AccessTokenTTL}},
+		{"auth.refresh_token_ttl", "REFRESH_TOKEN_TTL", "lifetime of refresh tokens", durationValue{&c.Auth.RefreshTokenTTL}},
+		{"auth.unverified_access", "UNVERIFIED_ACCESS", "read_only, block or allow for unverified users", stringValue{&c.Auth.UnverifiedAccess}},
+		{"auth.verification_ttl", "EMAIL_VERIFICATION_TTL", "lifetime of email verification links", durationValue{&c.Auth.VerificationTTL}},
+		{"auth.verification_url", "EMAIL_VERIFICATION_URL", "page email verification links point to", stringValue{&c.Auth.VerificationURL}},
+
+		{"passwords.policy_file", "PASSWORD_POLICY_FILE", "JSON file with the password policy", stringValue{&c.Passwords.PolicyFile}},
+		{"passwords.hash_algorithm", "PASSWORD_HASH_ALGORITHM", "argon2id or bcrypt", stringValue{&c.Passwords.Hashing.Algorithm}},
+		{"passwords.bcrypt_cost", "BCRYPT_COST", "bcrypt cost factor", intValue{&c.Passwords.Hashing.BcryptCost}},
+		{"passwords.argon2_memory_kib", "ARGON2_MEMORY_KIB", "argon2id memory in KiB", uintValue[uint32]{&c.Passwords.Hashing.Argon2.Memory}},
+		{"passwords.argon2_iterations", "ARGON2_ITERATIONS", "argon2id passes", uintValue[uint32]{&c.Passwords.Hashing.Argon2.Iterations}},
+		{"passwords.argon2_parallelism", "ARGON2_PARALLELISM", "argon2id threads", uintValue[uint8]{&c.Passwords.Hashing.Argon2.Parallelism}},
+		{"passwords.reset_ttl", "PASSWORD_RESET_TTL", "lifetime of password reset links", durationValue{&c.Passwords.ResetTTL}},
+		{"passwords.reset_url", "PASSWORD_RESET_URL", "page password reset links point to", stringValue{&c.Passwords.ResetURL}},
+
+		{"mail.outbox_dir", "MAIL_OUTBOX_DIR", "directory outgoing mail is written to", stringValue{&c.Mail.OutboxDir}},
+
+		{"tasks.workflow_file", "TASK_WORKFLOW_FILE", "JSON file with the task workflow", stringValue{&c.Tasks.WorkflowFile}},
+		{"tasks.trash_retention", "TRASH_RETENTION", "time deleted tasks stay in the trash", durationValue{&c.Tasks.TrashRetention}},
+		{"tasks.trash_purge_interval", "TRASH_PURGE_INTERVAL", "time between trash purges", durationValue{&c.Tasks.TrashPurgeInterval}},
+
+		{"log.level", "LOG_LEVEL", "debug, info, warn or error", levelValue{&c.Log.Level}},
+
+		{"metrics.require_auth", "METRICS_REQUIRE_AUTH", "require a bearer token to read /metrics", boolValue{&c.Metrics.RequireAuth}},
+		{"metrics.auth_token", "METRICS_AUTH_TOKEN", "bearer token for /metrics", stringValue{&c.Metrics.AuthToken}},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML or TOML file named by -config or CONFIG_FILE,
+// environment variables and command-line flags such as -server.port=9090.
+// Empty environment variables count as unset. Every problem is reported,
+// not just the first.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("task-manager", flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML or TOML file with settings (env CONFIG_FILE)")
+	// Flags are parsed first to find the config file, but applied last.
+	var flagged [][2]string
+	for _, s := range settings {
+		key := s.key
+		usage := fmt.Sprintf("%s (env %s, default %q)", s.usage, s.env, s.value.String())
+		record := func(value string) error {
+			flagged = append(flagged, [2]string{key, value})
+			return nil
+		}
+		if _, ok := s.value.(boolValue); ok {
+			fs.BoolFunc(key, usage, record)
+		} else {
+			fs.Func(key, usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	var problems []error
+	if fs.NArg() > 0 {
+		problems = append(problems, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+	}
+
+	byKey := map[string]setting{}
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	set := func(key, value, source string) {
+		if err := byKey[key].value.Set(value); err != nil {
+			problems = append(problems, fmt.Errorf("%s: invalid value %q: %v", source, value, err))
+		}
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := byKey[key]; !ok {
+				problems = append(problems, fmt.Errorf("%s: unknown setting %s", path, key))
+				continue
+			}
+			set(key, values[key], path+": "+key)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			set(s.key, value, s.env)
+		}
+	}
+	for _, f := range flagged {
+		set(f[0], f[1], "-"+f[0])
+	}
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	return cfg, errors.Join(problems...)
+}
+
+// readFile returns the settings in a YAML or TOML file keyed like
+// "server.port", with lists joined by commas.
+func readFile(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		return nil, fmt.Errorf("%s: config files must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+type stringValue struct{ p *string }
+
+func (v stringValue) String() string { return *v.p }
+
+func (v stringValue) Set(s string) error {
+	*v.p = s
+	return nil
+}
+
+type intValue struct{ p *int }
+
+func (v intValue) String() string { return strconv.Itoa(*v.p) }
+
+func (v intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not a whole number")
+	}
+	*v.p = n
+	return nil
+}
+
+type uintValue[T uint8 | uint32 | uint64] struct{ p *T }
+
+func (v uintValue[T]) String() string { return strconv.FormatUint(uint64(*v.p), 10) }
+
+func (v uintValue[T]) Set(s string) error {
+	var max T
+	max--
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n > uint64(max) {
+		return fmt.Errorf("not a whole number between 0 and %d", max)
+	}
+	*v.p = T(n)
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) String() string { return v.p.String() }
+
+func (v durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New(`not a duration such as "90s" or "12h"`)
+	}
+	*v.p = d
+	return nil
+}
+
+// boolValue settings may be given as a bare flag, such as
+// -metrics.require_auth.
+type boolValue struct{ p *bool }
+
+func (v boolValue) String() string { return strconv.FormatBool(*v.p) }
+
+func (v boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("not true or false")
+	}
+	*v.p = b
+	return nil
+}
+
+type levelValue struct{ p *slog.Level }
+
+func (v levelValue) String() string { return v.p.String() }
+
+func (v levelValue) Set(s string) error {
+	if err := v.p.UnmarshalText([]byte(s)); err != nil {
+		return errors.New("not one of debug, info, warn or error")
+	}
+	return nil
+}
+
+// listValue reads comma-separated lists.
+type listValue struct{ p *[]string }
+
+func (v listValue) String() string { return strings.Join(*v.p, ",") }
+
+func (v listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v.p = items
+	return nil
+}
@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"task-manager/Domain"
 	"task-manager/Usecases"
 	"time"
@@ -9,7 +13,6 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-
 type UserController struct {
 	UserUsecase *Usecases.UserUsecase
 }
@@ -21,13 +24,13 @@ func NewUserController(userUsecase *Usecases.UserUsecase) *UserController {
 func (c *UserController) Register(ctx *gin.Context) {
 	var user Domain.User
 	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		respondError(ctx, Domain.ErrInvalidInput)
 		return
 	}
 
-	createdUser, err := c.UserUsecase.Register(user)
+	createdUser, err := c.UserUsecase.Register(ctx.Request.Context(), user)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
@@ -42,25 +45,67 @@ func (c *UserController) Login(ctx *gin.Context) {
 	}
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Email and password required"})
+		respondError(ctx, invalidInput("email and password required"))
 		return
 	}
 
-	token, err := c.UserUsecase.Login(input.Email, input.Password)
+	tokens, err := c.UserUsecase.Login(ctx.Request.Context(), input.Email, input.Password, ctx.ClientIP())
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"token": token})
+	ctx.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken})
+}
+
+func (c *UserController) Refresh(ctx *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("refresh token required"))
+		return
+	}
+
+	tokens, err := c.UserUsecase.Refresh(ctx.Request.Context(), input.RefreshToken)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken})
+}
+
+func (c *UserController) Logout(ctx *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("refresh token required"))
+		return
+	}
+
+	claims, ok := ctx.Get("claims")
+	if !ok {
+		respondError(ctx, Domain.NewError(Domain.KindUnauthorized, "missing_token", "missing token"))
+		return
+	}
+
+	if err := c.UserUsecase.Logout(ctx.Request.Context(), claims.(*Domain.AuthClaims), input.RefreshToken); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
 
 func (c *UserController) PromoteUser(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	updatedUser, err := c.UserUsecase.PromoteUser(id)
+	updatedUser, err := c.UserUsecase.PromoteUser(ctx.Request.Context(), actorFrom(ctx), id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote user"})
+		respondError(ctx, err)
 		return
 	}
 
@@ -68,6 +113,174 @@ func (c *UserController) PromoteUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, updatedUser)
 }
 
+func (c *UserController) ListUsers(ctx *gin.Context) {
+	filter := Domain.UserFilter{
+		Search: ctx.Query("search"),
+		Role:   ctx.Query("role"),
+		Cursor: ctx.Query("cursor"),
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			respondError(ctx, invalidInput("limit must be a positive integer"))
+			return
+		}
+		filter.Limit = n
+	}
+
+	page, err := c.UserUsecase.ListUsers(ctx.Request.Context(), filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	for i := range page.Users {
+		page.Users[i].Password = ""
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": page.Users, "next_cursor": page.NextCursor})
+}
+
+func (c *UserController) DemoteUser(ctx *gin.Context) {
+	user, err := c.UserUsecase.DemoteUser(ctx.Request.Context(), actorFrom(ctx), ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	user.Password = ""
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (c *UserController) DisableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, true)
+}
+
+func (c *UserController) EnableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, false)
+}
+
+func (c *UserController) setDisabled(ctx *gin.Context, disabled bool) {
+	user, err := c.UserUsecase.SetUserDisabled(ctx.Request.Context(), actorFrom(ctx), ctx.Param("id"), disabled)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	user.Password = ""
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (c *UserController) UnlockUser(ctx *gin.Context) {
+	user, err := c.UserUsecase.UnlockUser(ctx.Request.Context(), actorFrom(ctx), ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	user.Password = ""
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (c *UserController) DeleteUser(ctx *gin.Context) {
+	if err := c.UserUsecase.DeleteUser(ctx.Request.Context(), actorFrom(ctx), ctx.Param("id")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// ForgotPassword answers the same way whether or not the email is
+// registered.
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("email required"))
+		return
+	}
+
+	if err := c.UserUsecase.RequestPasswordReset(ctx.Request.Context(), input.Email); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset link has been sent"})
+}
+
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("token required"))
+		return
+	}
+
+	if err := c.UserUsecase.ResetPassword(ctx.Request.Context(), input.Token, input.Password); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password updated"})
+}
+
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		respondError(ctx, invalidInput("token required"))
+		return
+	}
+
+	if _, err := c.UserUsecase.VerifyEmail(ctx.Request.Context(), token); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification answers the same way whether or not the email is
+// registered.
+func (c *UserController) ResendVerification(ctx *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("email required"))
+		return
+	}
+
+	if err := c.UserUsecase.ResendVerification(ctx.Request.Context(), input.Email); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If the account exists and is not verified, a verification link has been sent"})
+}
+
+// actorFrom rebuilds the caller's identity from the claims AuthMiddleware
+// stored on the request.
+func actorFrom(ctx *gin.Context) Domain.AuthClaims {
+	return Domain.AuthClaims{
+		UserID:      ctx.GetString("user_id"),
+		Email:       ctx.GetString("email"),
+		Role:        ctx.GetString("role"),
+		Permissions: ctx.GetStringSlice("permissions"),
+	}
+}
+
+// taskETag is the strong entity tag for the current version of a task.
+func taskETag(task Domain.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// ifMatchVersion returns the task version named by the If-Match header, or 0
+// when the header is absent or "*".
+func ifMatchVersion(ctx *gin.Context) (int64, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version < 1 {
+		return 0, invalidInput("If-Match must be an ETag returned by this API")
+	}
+	return version, nil
+}
 
 type TaskController struct {
 	TaskUsecase *Usecases.TaskUsecase
@@ -78,28 +291,60 @@ func NewTaskController(taskUsecase *Usecases.TaskUsecase) *TaskController {
 }
 
 func (c *TaskController) GetAllTasks(ctx *gin.Context) {
-	tasks, err := c.TaskUsecase.GetAllTasks()
+	filter := Domain.TaskFilter{
+		Status: ctx.Query("status"),
+		Sort:   ctx.Query("sort"),
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			respondError(ctx, invalidInput("limit must be a positive integer"))
+			return
+		}
+		filter.Limit = n
+	}
+	for _, bound := range []struct {
+		param  string
+		target *time.Time
+	}{
+		{"due_before", &filter.DueBefore},
+		{"due_after", &filter.DueAfter},
+	} {
+		if value := ctx.Query(bound.param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(ctx, invalidInput(bound.param+" must be an RFC 3339 timestamp"))
+				return
+			}
+			*bound.target = t
+		}
+	}
+
+	page, err := c.TaskUsecase.ListTasks(ctx.Request.Context(), actorFrom(ctx), filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tasks"})
+		respondError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, tasks)
+	ctx.JSON(http.StatusOK, gin.H{"tasks": page.Tasks, "next_cursor": page.NextCursor})
 }
 
 func (c *TaskController) GetTaskByID(ctx *gin.Context) {
 	id := ctx.Param("id")
-	task, err := c.TaskUsecase.GetTaskByID(id)
+	task, err := c.TaskUsecase.GetTaskByID(ctx.Request.Context(), actorFrom(ctx), id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		respondError(ctx, err)
 		return
 	}
+	ctx.Header("ETag", taskETag(task))
 	ctx.JSON(http.StatusOK, task)
 }
 
 func (c *TaskController) CreateTask(ctx *gin.Context) {
 	var task Domain.Task
 	if err := ctx.ShouldBindJSON(&task); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		respondError(ctx, Domain.ErrInvalidInput)
 		return
 	}
 
@@ -107,12 +352,13 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 		task.DueDate = time.Now().UTC()
 	}
 
-	createdTask, err := c.TaskUsecase.CreateTask(task)
+	createdTask, err := c.TaskUsecase.CreateTask(ctx.Request.Context(), actorFrom(ctx), task)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		respondError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", taskETag(createdTask))
 	ctx.JSON(http.StatusCreated, createdTask)
 }
 
@@ -120,24 +366,196 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var task Domain.Task
 	if err := ctx.ShouldBindJSON(&task); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		respondError(ctx, Domain.ErrInvalidInput)
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
+	task.Version = version
 
-	updatedTask, err := c.TaskUsecase.UpdateTask(id, task)
+	updatedTask, err := c.TaskUsecase.UpdateTask(ctx.Request.Context(), actorFrom(ctx), id, task)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		respondError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", taskETag(updatedTask))
 	ctx.JSON(http.StatusOK, updatedTask)
 }
 
+// PatchTask accepts a JSON Merge Patch (application/merge-patch+json) or a
+// JSON Patch (application/json-patch+json) and changes only the fields it
+// names.
+func (c *TaskController) PatchTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		respondError(ctx, Domain.ErrInvalidInput)
+		return
+	}
+
+	patch, err := taskPatcher(ctx.ContentType(), body)
+	if errors.Is(err, errUnsupportedPatch) {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be " + mergePatchContentType + " or " + jsonPatchContentType,
+			"code":  "unsupported_media_type",
+		})
+		return
+	}
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	patchedTask, err := c.TaskUsecase.PatchTask(ctx.Request.Context(), actorFrom(ctx), id, version, patch)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(patchedTask))
+	ctx.JSON(http.StatusOK, patchedTask)
+}
+
 func (c *TaskController) DeleteTask(ctx *gin.Context) {
 	id := ctx.Param("id")
-	if err := c.TaskUsecase.DeleteTask(id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+	err := c.TaskUsecase.DeleteTask(ctx.Request.Context(), actorFrom(ctx), id)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task moved to trash"})
+}
+
+func (c *TaskController) ListTrash(ctx *gin.Context) {
+	tasks, err := c.TaskUsecase.ListTrash(ctx.Request.Context(), actorFrom(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+func (c *TaskController) RestoreTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	task, err := c.TaskUsecase.RestoreTask(ctx.Request.Context(), actorFrom(ctx), id)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+type AdminController struct {
+	AuditUsecase *Usecases.AuditUsecase
+	RoleUsecase  *Usecases.RoleUsecase
+}
+
+func NewAdminController(auditUsecase *Usecases.AuditUsecase, roleUsecase *Usecases.RoleUsecase) *AdminController {
+	return &AdminController{AuditUsecase: auditUsecase, RoleUsecase: roleUsecase}
+}
+
+func (c *AdminController) ListAudit(ctx *gin.Context) {
+	filter := Domain.AuditFilter{
+		Actor:    ctx.Query("actor"),
+		Action:   ctx.Query("action"),
+		Entity:   ctx.Query("entity"),
+		EntityID: ctx.Query("entity_id"),
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			respondError(ctx, invalidInput("limit must be a positive integer"))
+			return
+		}
+		filter.Limit = n
+	}
+	for _, bound := range []struct {
+		param  string
+		target *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		if value := ctx.Query(bound.param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(ctx, invalidInput(bound.param+" must be an RFC 3339 timestamp"))
+				return
+			}
+			*bound.target = t
+		}
+	}
+
+	entries, err := c.AuditUsecase.ListEntries(ctx.Request.Context(), filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (c *AdminController) ListRoles(ctx *gin.Context) {
+	roles, err := c.RoleUsecase.ListRoles(ctx.Request.Context())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": Domain.Permissions})
+}
+
+func (c *AdminController) SaveRole(ctx *gin.Context) {
+	var input struct {
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("permissions required"))
+		return
+	}
+
+	role := Domain.Role{Name: ctx.Param("name"), Permissions: input.Permissions}
+	saved, err := c.RoleUsecase.SaveRole(ctx.Request.Context(), actorFrom(ctx), role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, saved)
+}
+
+func (c *AdminController) DeleteRole(ctx *gin.Context) {
+	if err := c.RoleUsecase.DeleteRole(ctx.Request.Context(), actorFrom(ctx), ctx.Param("name")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
+}
+
+func (c *AdminController) AssignRole(ctx *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		respondError(ctx, invalidInput("role required"))
+		return
+	}
+
+	user, err := c.RoleUsecase.AssignRole(ctx.Request.Context(), actorFrom(ctx), ctx.Param("id"), input.Role)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
+	user.Password = ""
+	ctx.JSON(http.StatusOK, user)
 }
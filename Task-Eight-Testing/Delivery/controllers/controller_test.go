@@ -2,14 +2,21 @@ package controllers_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"task-manager/Delivery/controllers"
 	"task-manager/Domain"
+	"task-manager/Repositories"
 	"task-manager/Usecases"
 
 	"github.com/gin-gonic/gin"
@@ -17,24 +24,58 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-
 type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user Domain.User) (Domain.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, user Domain.User) (Domain.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
-func (m *MockUserRepository) FindByEmail(email string) (Domain.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (Domain.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
-func (m *MockUserRepository) Promote(id string) (Domain.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (Domain.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) SetRole(ctx context.Context, id string, role string) (Domain.User, error) {
+	args := m.Called(id, role)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(Domain.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	args := m.Called(id, disabled)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetVerified(ctx context.Context, id string, verified bool) (Domain.User, error) {
+	args := m.Called(id, verified)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetPassword(ctx context.Context, id string, hash string) (Domain.User, error) {
+	args := m.Called(id, hash)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) NextTokenGeneration(ctx context.Context, id string) (Domain.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 type MockPasswordService struct {
 	mock.Mock
 }
@@ -48,6 +89,11 @@ func (m *MockPasswordService) Compare(raw, hashed string) bool {
 	return args.Bool(0)
 }
 
+func (m *MockPasswordService) NeedsRehash(hashed string) bool {
+	args := m.Called(hashed)
+	return args.Bool(0)
+}
+
 type MockJWTService struct {
 	mock.Mock
 }
@@ -57,11 +103,31 @@ func (m *MockJWTService) GenerateToken(user Domain.User) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockJWTService) ValidateToken(token string) (*Domain.AuthClaims, error) {
+func (m *MockJWTService) ValidateToken(ctx context.Context, token string) (*Domain.AuthClaims, error) {
 	args := m.Called(token)
 	return args.Get(0).(*Domain.AuthClaims), args.Error(1)
 }
 
+func (m *MockJWTService) GenerateRefreshToken(user Domain.User) (string, error) {
+	args := m.Called(user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) ValidateRefreshToken(ctx context.Context, tokenString string) (*Domain.AuthClaims, error) {
+	args := m.Called(tokenString)
+	claims, _ := args.Get(0).(*Domain.AuthClaims)
+	return claims, args.Error(1)
+}
+
+func (m *MockJWTService) RevokeToken(ctx context.Context, claims *Domain.AuthClaims) error {
+	args := m.Called(claims)
+	return args.Error(0)
+}
+
+func (m *MockJWTService) RevokeTokenOnce(ctx context.Context, claims *Domain.AuthClaims) error {
+	args := m.Called(claims)
+	return args.Error(0)
+}
 
 func setupUserController() (*gin.Engine, *MockUserRepository, *MockPasswordService, *MockJWTService) {
 	gin.SetMode(gin.TestMode)
@@ -70,25 +136,33 @@ func setupUserController() (*gin.Engine, *MockUserRepository, *MockPasswordServi
 	mockHasher := new(MockPasswordService)
 	mockJWT := new(MockJWTService)
 
-	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher,mockJWT)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
 	userController := controllers.NewUserController(usecase)
 
 	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "64b7f0c2e4b0a1a2b3c4d5e7")
+		c.Set("email", "admin@example.com")
+		c.Set("role", Domain.RoleAdmin)
+		c.Set("permissions", Domain.Permissions)
+		c.Next()
+	})
 	r.POST("/register", userController.Register)
 	r.POST("/login", userController.Login)
 	r.PUT("/users/:id/promote", userController.PromoteUser)
+	r.GET("/users", userController.ListUsers)
+	r.DELETE("/users/:id", userController.DeleteUser)
 
 	return r, mockRepo, mockHasher, mockJWT
 }
 
-
 func TestRegister_Success(t *testing.T) {
 	r, repo, hasher, _ := setupUserController()
-	input := Domain.User{Email: "test@example.com", Password: "pass"}
+	input := Domain.User{Email: "test@example.com", Password: "Correct-Horse-9"}
 	hashed := "hashed-pass"
 	created := Domain.User{ID: "1", Email: input.Email, Role: "user"}
 
-	repo.On("FindByEmail", input.Email).Return(Domain.User{}, errors.New("not found"))
+	repo.On("FindByEmail", input.Email).Return(Domain.User{}, Domain.ErrUserNotFound)
 	hasher.On("Hash", input.Password).Return(hashed, nil)
 	repo.On("Create", mock.MatchedBy(func(u Domain.User) bool {
 		return u.Email == input.Email && u.Password == hashed
@@ -114,7 +188,9 @@ func TestLogin_Success(t *testing.T) {
 
 	repo.On("FindByEmail", input["email"]).Return(user, nil)
 	hasher.On("Compare", input["password"], user.Password).Return(true)
+	hasher.On("NeedsRehash", user.Password).Return(false)
 	jwt.On("GenerateToken", user).Return("token-abc", nil)
+	jwt.On("GenerateRefreshToken", user).Return("refresh-abc", nil)
 
 	body, _ := json.Marshal(input)
 	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
@@ -126,13 +202,15 @@ func TestLogin_Success(t *testing.T) {
 	var resp map[string]string
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Equal(t, "token-abc", resp["token"])
+	assert.Equal(t, "refresh-abc", resp["refresh_token"])
 }
 
 func TestPromoteUser_Success(t *testing.T) {
 	r, repo, _, _ := setupUserController()
 	id := "abc123"
 	promoted := Domain.User{ID: id, Role: "admin"}
-	repo.On("Promote", id).Return(promoted, nil)
+	repo.On("FindByID", id).Return(Domain.User{ID: id, Role: "user"}, nil)
+	repo.On("SetRole", id, Domain.RoleAdmin).Return(promoted, nil)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/"+id+"/promote", nil)
 	w := httptest.NewRecorder()
@@ -143,3 +221,680 @@ func TestPromoteUser_Success(t *testing.T) {
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Equal(t, "admin", resp.Role)
 }
+
+func TestRegister_EmailTaken(t *testing.T) {
+	r, repo, _, _ := setupUserController()
+	input := Domain.User{Email: "taken@example.com", Password: "Correct-Horse-9"}
+	repo.On("FindByEmail", input.Email).Return(Domain.User{ID: "1", Email: input.Email}, nil)
+
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "email_taken", resp["code"])
+}
+
+func TestPromoteUser_ErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid id", Domain.ErrInvalidID, http.StatusBadRequest, "invalid_id"},
+		{"not found", Domain.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+		{"database outage", errors.New("connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, repo, _, _ := setupUserController()
+			repo.On("FindByID", "abc123").Return(Domain.User{}, tt.err)
+
+			req := httptest.NewRequest(http.MethodPut, "/users/abc123/promote", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			var resp map[string]string
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.Equal(t, tt.code, resp["code"])
+			assert.NotContains(t, resp["error"], "connection refused")
+		})
+	}
+}
+
+func setupTaskController() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskController := controllers.NewTaskController(Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository()))
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "64b7f0c2e4b0a1a2b3c4d5e6")
+		c.Set("email", "owner@example.com")
+		c.Set("role", "user")
+		c.Next()
+	})
+	r.POST("/tasks", taskController.CreateTask)
+	r.GET("/tasks/:id", taskController.GetTaskByID)
+	r.PUT("/tasks/:id", taskController.UpdateTask)
+	r.PATCH("/tasks/:id", taskController.PatchTask)
+	r.DELETE("/tasks/:id", taskController.DeleteTask)
+	r.GET("/tasks/trash", taskController.ListTrash)
+	r.POST("/tasks/:id/restore", taskController.RestoreTask)
+
+	return r
+}
+
+func TestUpdateTask_IfMatch(t *testing.T) {
+	r := setupTaskController()
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"Title": "Draft"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created Domain.Task
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodPut, "/tasks/"+created.ID, bytes.NewBufferString(`{"Title": "First", "DueDate": "2030-01-02T15:04:05Z"}`))
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodPut, "/tasks/"+created.ID, bytes.NewBufferString(`{"Title": "Stale", "DueDate": "2030-01-02T15:04:05Z"}`))
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "version_conflict", resp["code"])
+
+	req = httptest.NewRequest(http.MethodPut, "/tasks/"+created.ID, bytes.NewBufferString(`{"Title": "Bad", "DueDate": "2030-01-02T15:04:05Z"}`))
+	req.Header.Set("If-Match", `W/"2"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateTask_RejectsNonInitialStatus(t *testing.T) {
+	r := setupTaskController()
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"Title": "Done already", "Status": "done"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "invalid_initial_status", resp["code"])
+}
+
+func createTaskForPatch(t *testing.T, r *gin.Engine) Domain.Task {
+	t.Helper()
+	body := `{"Title": "Write report", "Description": "Quarterly numbers", "DueDate": "2030-01-02T15:04:05Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created Domain.Task
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	return created
+}
+
+func patchTask(r *gin.Engine, id, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/"+id, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPatchTask_MergePatchChangesOnlyNamedFields(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	w := patchTask(r, created.ID, "application/merge-patch+json", `{"status": "in_progress"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	var patched Domain.Task
+	_ = json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Equal(t, Domain.StatusInProgress, patched.Status)
+	assert.Equal(t, created.Title, patched.Title)
+	assert.Equal(t, created.Description, patched.Description)
+	assert.True(t, created.DueDate.Equal(patched.DueDate))
+}
+
+func TestPatchTask_MergePatchNullClearsField(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	w := patchTask(r, created.ID, "application/merge-patch+json", `{"description": null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var patched Domain.Task
+	_ = json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Empty(t, patched.Description)
+
+	w = patchTask(r, created.ID, "application/merge-patch+json", `{"title": null}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "invalid_task", resp["code"])
+}
+
+func TestPatchTask_RejectsInvalidResult(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"unknown field", `{"owner": "someone@example.com"}`, http.StatusUnprocessableEntity},
+		{"unknown status", `{"status": "someday"}`, http.StatusUnprocessableEntity},
+		{"illegal transition", `{"status": "archived"}`, http.StatusConflict},
+		{"not an object", `["title"]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := patchTask(r, created.ID, "application/merge-patch+json", tt.body)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+created.ID, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+}
+
+func TestPatchTask_JSONPatch(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	w := patchTask(r, created.ID, "application/json-patch+json",
+		`[{"op": "test", "path": "/title", "value": "Write report"}, {"op": "replace", "path": "/title", "value": "Write summary"}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var patched Domain.Task
+	_ = json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Equal(t, "Write summary", patched.Title)
+	assert.Equal(t, created.Description, patched.Description)
+
+	w = patchTask(r, created.ID, "application/json-patch+json",
+		`[{"op": "test", "path": "/title", "value": "Write report"}, {"op": "replace", "path": "/title", "value": "Lost"}]`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "patch_test_failed", resp["code"])
+}
+
+func TestPatchTask_UnsupportedMediaType(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	w := patchTask(r, created.ID, "text/plain", `status=done`)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestDeleteTask_MovesToTrashAndRestores(t *testing.T) {
+	r := setupTaskController()
+	created := createTaskForPatch(t, r)
+
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/"+created.ID, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/trash", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trash struct{ Tasks []Domain.Task }
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	assert.Len(t, trash.Tasks, 1)
+	assert.Equal(t, created.ID, trash.Tasks[0].ID)
+
+	req = httptest.NewRequest(http.MethodPost, "/tasks/"+created.ID+"/restore", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func setupAdminController(audit Domain.IAuditRepository) (*gin.Engine, Domain.IUserRepository) {
+	gin.SetMode(gin.TestMode)
+	users := Repositories.NewMemoryUserRepository()
+	roleUsecase := Usecases.NewRoleUsecase(Repositories.NewMemoryRoleRepository(), users)
+	roleUsecase.Audit = audit
+	_ = roleUsecase.SeedDefaults(context.Background())
+	adminController := controllers.NewAdminController(Usecases.NewAuditUsecase(audit), roleUsecase)
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "64b7f0c2e4b0a1a2b3c4d5e7")
+		c.Set("email", "admin@example.com")
+		c.Set("role", Domain.RoleAdmin)
+		c.Set("permissions", Domain.Permissions)
+		c.Next()
+	})
+	r.GET("/admin/audit", adminController.ListAudit)
+	r.GET("/admin/roles", adminController.ListRoles)
+	r.PUT("/admin/roles/:name", adminController.SaveRole)
+	r.DELETE("/admin/roles/:name", adminController.DeleteRole)
+	r.PUT("/admin/users/:id/role", adminController.AssignRole)
+	return r, users
+}
+
+func TestListAudit_FiltersEntries(t *testing.T) {
+	audit := Repositories.NewMemoryAuditRepository()
+	_ = audit.Record(context.Background(), Domain.AuditEntry{Actor: "owner@example.com", Action: Domain.AuditTaskCreate, Entity: Domain.AuditEntityTask, EntityID: "t1", Timestamp: time.Now()})
+	_ = audit.Record(context.Background(), Domain.AuditEntry{Actor: "admin@example.com", Action: Domain.AuditUserPromote, Entity: Domain.AuditEntityUser, EntityID: "u1", Timestamp: time.Now()})
+
+	r, _ := setupAdminController(audit)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit?entity=user", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Entries []Domain.AuditEntry
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Entries, 1) {
+		assert.Equal(t, Domain.AuditUserPromote, resp.Entries[0].Action)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit?since=yesterday", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSaveRole_CreatesAndProtectsAdmin(t *testing.T) {
+	r, _ := setupAdminController(Repositories.NewMemoryAuditRepository())
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/roles/auditor", bytes.NewBufferString(`{"permissions": ["audit:read", "tasks:read", "audit:read"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var role Domain.Role
+	_ = json.Unmarshal(w.Body.Bytes(), &role)
+	assert.Equal(t, []string{Domain.PermAuditRead, Domain.PermTasksRead}, role.Permissions)
+
+	for _, tt := range []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodPut, "/admin/roles/admin", `{"permissions": []}`, http.StatusConflict, "protected_role"},
+		{http.MethodDelete, "/admin/roles/member", "", http.StatusConflict, "protected_role"},
+		{http.MethodPut, "/admin/roles/auditor", `{"permissions": ["tasks:fly"]}`, http.StatusUnprocessableEntity, "invalid_role"},
+		{http.MethodDelete, "/admin/roles/missing", "", http.StatusNotFound, "role_not_found"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, tt.code, resp["code"], tt.path)
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	r, users := setupAdminController(Repositories.NewMemoryAuditRepository())
+	_, _ = users.Create(context.Background(), Domain.User{Email: "first@example.com"})
+	user, _ := users.Create(context.Background(), Domain.User{Email: "second@example.com", Password: "hash"})
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/users/"+user.ID+"/role", bytes.NewBufferString(`{"role": "manager"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated Domain.User
+	_ = json.Unmarshal(w.Body.Bytes(), &updated)
+	assert.Equal(t, Domain.RoleManager, updated.Role)
+	assert.Empty(t, updated.Password)
+
+	req = httptest.NewRequest(http.MethodPut, "/admin/users/"+user.ID+"/role", bytes.NewBufferString(`{"role": "overlord"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestListUsers_HidesPasswords(t *testing.T) {
+	r, repo, _, _ := setupUserController()
+	repo.On("List", Domain.UserFilter{Search: "example", Limit: Domain.MaxUserPageSize}).Return(Domain.UserPage{
+		Users:      []Domain.User{{ID: "1", Email: "a@example.com", Password: "hash", Role: Domain.RoleMember}},
+		NextCursor: "next",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?search=example&limit=1000", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Users      []Domain.User
+		NextCursor string `json:"next_cursor"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Users, 1) {
+		assert.Empty(t, resp.Users[0].Password)
+	}
+	assert.Equal(t, "next", resp.NextCursor)
+}
+
+func TestDeleteUser_LastAdmin(t *testing.T) {
+	r, repo, _, _ := setupUserController()
+	repo.On("FindByID", "abc123").Return(Domain.User{ID: "abc123", Role: Domain.RoleAdmin}, nil)
+	repo.On("Delete", "abc123").Return(Domain.ErrLastAdmin)
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/abc123", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "last_admin", resp["code"])
+}
+
+func TestRegister_FieldErrors(t *testing.T) {
+	r, repo, _, _ := setupUserController()
+
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(`{"email": "ada", "password": "Correct-Horse-9"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var resp struct {
+		Code   string
+		Fields []map[string]string
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "validation_failed", resp.Code)
+	if assert.Len(t, resp.Fields, 1) {
+		assert.Equal(t, "email", resp.Fields[0]["field"])
+		assert.Equal(t, "invalid_email", resp.Fields[0]["code"])
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+type discardMailer struct{}
+
+func (discardMailer) Send(ctx context.Context, message Domain.MailMessage) error {
+	return nil
+}
+
+func TestPasswordReset_Endpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(repo, new(MockPasswordService), new(MockJWTService))
+	usecase.ResetTokens = Repositories.NewMemoryOneTimeTokenRepository()
+	usecase.Mailer = discardMailer{}
+	userController := controllers.NewUserController(usecase)
+	r := gin.New()
+	r.POST("/password/forgot", userController.ForgotPassword)
+	r.POST("/password/reset", userController.ResetPassword)
+
+	repo.On("FindByEmail", "nobody@example.com").Return(Domain.User{}, Domain.ErrUserNotFound)
+
+	for _, tt := range []struct {
+		path, body string
+		status     int
+		code       string
+	}{
+		{"/password/forgot", `{"email": "nobody@example.com"}`, http.StatusAccepted, ""},
+		{"/password/forgot", `{}`, http.StatusBadRequest, "invalid_input"},
+		{"/password/reset", `{"token": "made-up", "password": "Correct-Horse-9"}`, http.StatusBadRequest, "invalid_reset_token"},
+		{"/password/reset", `{"token": "made-up", "password": "weak"}`, http.StatusUnprocessableEntity, "validation_failed"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.path+" "+tt.body)
+		var resp struct{ Code string }
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, tt.code, resp.Code, tt.path+" "+tt.body)
+	}
+}
+
+func TestVerifyEmail_RejectsMissingOrBadToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userController := controllers.NewUserController(Usecases.NewUserUsecase(new(MockUserRepository), new(MockPasswordService), new(MockJWTService)))
+	r := gin.New()
+	r.GET("/verify", userController.VerifyEmail)
+
+	for path, code := range map[string]string{
+		"/verify":           "invalid_input",
+		"/verify?token=abc": "invalid_verification_token",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		var resp struct{ Code string }
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, code, resp.Code, path)
+	}
+}
+
+// lockedOutStore reports every key as having just failed many times.
+type lockedOutStore struct{}
+
+func (lockedOutStore) Get(ctx context.Context, key string) (Domain.LoginAttempts, error) {
+	return Domain.LoginAttempts{Failures: 100, LastFailure: time.Now()}, nil
+}
+
+func (lockedOutStore) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (Domain.LoginAttempts, error) {
+	return Domain.LoginAttempts{Failures: 101, LastFailure: at}, nil
+}
+
+func (lockedOutStore) Reset(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestLogin_LockedOutSetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(repo, new(MockPasswordService), new(MockJWTService))
+	usecase.LoginAttempts = lockedOutStore{}
+	userController := controllers.NewUserController(usecase)
+	r := gin.New()
+	r.POST("/login", userController.Login)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"email": "ada@example.com", "password": "Correct-Horse-9"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	repo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+}
+
+type staticKeys []Domain.PublicKey
+
+func (k staticKeys) PublicKeys() []Domain.PublicKey { return k }
+
+func TestJWKS_EncodesPublicKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", controllers.NewKeysController(staticKeys{
+		{ID: "rsa-1", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+		{ID: "ed-1", Algorithm: "EdDSA", Key: edPublic},
+	}).JWKS)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.Len(t, body.Keys, 2) {
+		assert.Equal(t, "RSA", body.Keys[0]["kty"])
+		assert.Equal(t, "rsa-1", body.Keys[0]["kid"])
+		assert.Equal(t, "AQAB", body.Keys[0]["e"])
+		assert.NotEmpty(t, body.Keys[0]["n"])
+		assert.Equal(t, "OKP", body.Keys[1]["kty"])
+		assert.Equal(t, "Ed25519", body.Keys[1]["crv"])
+		assert.Equal(t, "EdDSA", body.Keys[1]["alg"])
+		assert.Len(t, body.Keys[1]["x"], 43)
+	}
+}
+
+func TestHealth_ReadyzReportsDependencies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	healthy := true
+	health := controllers.NewHealthController(Domain.HealthCheck{Name: "database", Check: func(ctx context.Context) error {
+		if healthy {
+			return nil
+		}
+		return errors.New("connection refused")
+	}})
+	router := gin.New()
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz)
+
+	probe := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := probe("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "ok", "checks": {"database": "ok"}}`, w.Body.String())
+
+	healthy = false
+	w = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status": "unavailable", "checks": {"database": "down"}}`, w.Body.String())
+	assert.Equal(t, http.StatusOK, probe("/healthz").Code, "liveness ignores dependencies")
+
+	healthy = true
+	health.StartDraining()
+	w = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status": "draining", "checks": {"database": "ok"}}`, w.Body.String())
+}
+
+type recordedRequest struct {
+	method, route string
+	status        int
+}
+
+type fakeMetrics struct {
+	requests []recordedRequest
+}
+
+func (m *fakeMetrics) ObserveRequest(method, route string, status int, took time.Duration) {
+	m.requests = append(m.requests, recordedRequest{method, route, status})
+}
+
+func (m *fakeMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		io.WriteString(w, "http_requests_total 1\n")
+	})
+}
+
+func TestMetrics_ObservesRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics := &fakeMetrics{}
+	c := controllers.NewMetricsController(metrics, "")
+
+	router := gin.New()
+	router.Use(c.ObserveRequests)
+	router.GET("/tasks/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/elsewhere/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/tasks/42", nil))
+
+	// Neither the path of a missed route nor a made-up method becomes a
+	// label of its own.
+	assert.Equal(t, []recordedRequest{
+		{http.MethodGet, "/tasks/:id", http.StatusNoContent},
+		{http.MethodGet, "unmatched", http.StatusNotFound},
+		{http.MethodGet, "unmatched", http.StatusNotFound},
+		{"other", "unmatched", http.StatusNotFound},
+	}, metrics.requests)
+}
+
+func TestMetrics_OptionalToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"open without a token", "", "", http.StatusOK},
+		{"missing token", "scrape-secret", "", http.StatusUnauthorized},
+		{"wrong token", "scrape-secret", "Bearer guess", http.StatusUnauthorized},
+		{"right token", "scrape-secret", "Bearer scrape-secret", http.StatusOK},
+	} {
+		router := gin.New()
+		router.GET("/metrics", controllers.NewMetricsController(&fakeMetrics{}, tt.token).Metrics)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.name)
+		if tt.status == http.StatusOK {
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"), tt.name)
+			assert.Equal(t, "http_requests_total 1\n", w.Body.String(), tt.name)
+		}
+	}
+}
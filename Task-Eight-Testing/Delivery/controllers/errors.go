@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var statusByKind = map[Domain.ErrorKind]int{
+	Domain.KindBadRequest:         http.StatusBadRequest,
+	Domain.KindValidation:         http.StatusUnprocessableEntity,
+	Domain.KindNotFound:           http.StatusNotFound,
+	Domain.KindConflict:           http.StatusConflict,
+	Domain.KindUnauthorized:       http.StatusUnauthorized,
+	Domain.KindForbidden:          http.StatusForbidden,
+	Domain.KindPreconditionFailed: http.StatusPreconditionFailed,
+	Domain.KindTooManyRequests:    http.StatusTooManyRequests,
+}
+
+// respondError writes err as {"error", "code"} with the status its kind maps
+// to, adding "fields" when the error lists field problems and a Retry-After
+// header when it says when to retry. Errors that are not domain errors
+// become a 500 whose message is logged but not leaked to the client.
+func respondError(ctx *gin.Context, err error) {
+	var domainErr *Domain.Error
+	if !errors.As(err, &domainErr) {
+		slog.ErrorContext(ctx.Request.Context(), "request failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "code": "internal_error"})
+		return
+	}
+
+	status, ok := statusByKind[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+		slog.ErrorContext(ctx.Request.Context(), "request failed", "error", err)
+	}
+	body := gin.H{"error": err.Error(), "code": domainErr.Code}
+	if len(domainErr.Fields) > 0 {
+		fields := make([]gin.H, len(domainErr.Fields))
+		for i, field := range domainErr.Fields {
+			fields[i] = gin.H{"field": field.Field, "code": field.Code, "message": field.Message}
+		}
+		body["fields"] = fields
+	}
+	if domainErr.RetryAfter > 0 {
+		seconds := (domainErr.RetryAfter + time.Second - 1) / time.Second
+		ctx.Header("Retry-After", strconv.FormatInt(int64(seconds), 10))
+	}
+	ctx.JSON(status, body)
+}
+
+// invalidInput reports a malformed request with a message specific to it.
+func invalidInput(message string) error {
+	return Domain.NewError(Domain.KindBadRequest, Domain.ErrInvalidInput.Code, message)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each readiness check so a hung dependency
+// cannot stall the probe.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthController answers liveness and readiness probes.
+type HealthController struct {
+	Checks   []Domain.HealthCheck
+	draining atomic.Bool
+}
+
+func NewHealthController(checks ...Domain.HealthCheck) *HealthController {
+	return &HealthController{Checks: checks}
+}
+
+// StartDraining makes the instance report itself as not ready, so load
+// balancers stop sending it new requests while it shuts down.
+func (c *HealthController) StartDraining() {
+	c.draining.Store(true)
+}
+
+// Healthz reports that the process is up. It checks no dependencies, so an
+// unavailable database does not get the instance restarted.
+func (c *HealthController) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the instance and each of its dependencies can
+// serve requests. Failures are logged rather than returned, since the
+// endpoint needs no authentication.
+func (c *HealthController) Readyz(ctx *gin.Context) {
+	ready := !c.draining.Load()
+	checks := gin.H{}
+	for _, check := range c.Checks {
+		checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), healthCheckTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+		if err != nil {
+			slog.WarnContext(ctx.Request.Context(), "readiness check failed", "check", check.Name, "error", err)
+			checks[check.Name] = "down"
+			ready = false
+			continue
+		}
+		checks[check.Name] = "ok"
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	if c.draining.Load() {
+		status = "draining"
+	}
+	ctx.JSON(code, gin.H{"status": status, "checks": checks})
+}
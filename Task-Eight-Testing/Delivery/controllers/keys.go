@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"task-manager/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeysController publishes the token verification keys as a JSON Web Key
+// Set (RFC 7517).
+type KeysController struct {
+	Keys Domain.IPublicKeyProvider
+}
+
+func NewKeysController(keys Domain.IPublicKeyProvider) *KeysController {
+	return &KeysController{Keys: keys}
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+func (c *KeysController) JWKS(ctx *gin.Context) {
+	keys := []jsonWebKey{}
+	for _, key := range c.Keys.PublicKeys() {
+		jwk := jsonWebKey{KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+		switch pub := key.Key.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	// Verifiers cache the set; keep it short so a rotated-in key is picked
+	// up well before tokens signed with it are common.
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsController records every request and serves the collected
+// metrics for Prometheus to scrape.
+type MetricsController struct {
+	Collector Domain.IMetrics
+	// Token, when set, must be presented as a bearer token to read the
+	// metrics. Scrapers cannot log in, so user tokens are not used.
+	Token string
+}
+
+func NewMetricsController(metrics Domain.IMetrics, token string) *MetricsController {
+	return &MetricsController{Collector: metrics, Token: token}
+}
+
+// unmatchedRoute labels requests that matched no route, whatever their path.
+const unmatchedRoute = "unmatched"
+
+// requestMethod returns the label for method. Clients choose the method, so
+// anything but the standard ones shares one label rather than adding series.
+func requestMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// ObserveRequests is middleware that records each request under its route
+// template, so /tasks/1 and /tasks/2 share a series.
+func (c *MetricsController) ObserveRequests(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	c.Collector.ObserveRequest(requestMethod(ctx.Request.Method), route, ctx.Writer.Status(), time.Since(start))
+}
+
+func (c *MetricsController) authorized(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1
+}
+
+// Metrics serves the metrics in the format the scraper asks for.
+func (c *MetricsController) Metrics(ctx *gin.Context) {
+	if c.Token != "" && !c.authorized(ctx.GetHeader("Authorization")) {
+		ctx.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+		respondError(ctx, Domain.ErrMetricsAuth)
+		return
+	}
+
+	c.Collector.Handler().ServeHTTP(ctx.Writer, ctx.Request)
+}
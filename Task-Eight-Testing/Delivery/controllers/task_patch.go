@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"task-manager/Domain"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	errUnsupportedPatch = errors.New("unsupported patch media type")
+
+	errInvalidPatch    = Domain.NewError(Domain.KindBadRequest, "invalid_patch", "invalid patch")
+	errPatchNotApplied = Domain.NewError(Domain.KindValidation, "patch_not_applicable", "patch cannot be applied")
+	errPatchTestFailed = Domain.NewError(Domain.KindConflict, "patch_test_failed", "patch test operation failed")
+)
+
+// taskDocument is the JSON document patches are applied to. Only these
+// fields can be patched.
+type taskDocument struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	DueDate     time.Time `json:"due_date"`
+	Status      string    `json:"status"`
+}
+
+// patchFieldNames resolves the spellings a client may use for a field to
+// its name in taskDocument. Keys are lower-cased first, matching the
+// case-insensitive binding PUT uses.
+var patchFieldNames = map[string]string{
+	"title":       "title",
+	"description": "description",
+	"due_date":    "due_date",
+	"duedate":     "due_date",
+	"status":      "status",
+}
+
+func patchFieldName(name string) string {
+	if field, ok := patchFieldNames[strings.ToLower(name)]; ok {
+		return field
+	}
+	return name
+}
+
+// taskPatcher returns a function that applies body to a task, choosing RFC
+// 7396 merge patch or RFC 6902 JSON Patch by content type. Plain JSON is
+// treated as a merge patch.
+func taskPatcher(contentType string, body []byte) (func(Domain.Task) (Domain.Task, error), error) {
+	var apply func(doc []byte) ([]byte, error)
+	switch contentType {
+	case mergePatchContentType, "application/json":
+		patch, err := normalizeMergePatch(body)
+		if err != nil {
+			return nil, err
+		}
+		apply = func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, patch)
+		}
+	case jsonPatchContentType:
+		patch, err := decodeJSONPatch(body)
+		if err != nil {
+			return nil, err
+		}
+		apply = patch.Apply
+	default:
+		return nil, errUnsupportedPatch
+	}
+
+	return func(task Domain.Task) (Domain.Task, error) {
+		doc, err := json.Marshal(taskDocument{
+			Title:       task.Title,
+			Description: task.Description,
+			DueDate:     task.DueDate,
+			Status:      task.Status,
+		})
+		if err != nil {
+			return Domain.Task{}, err
+		}
+
+		patched, err := apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return Domain.Task{}, errPatchTestFailed
+		}
+		if err != nil {
+			return Domain.Task{}, fmt.Errorf("%w: %v", errPatchNotApplied, err)
+		}
+
+		var result taskDocument
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&result); err != nil {
+			return Domain.Task{}, fmt.Errorf("%w: %v", errPatchNotApplied, err)
+		}
+
+		task.Title = result.Title
+		task.Description = result.Description
+		task.DueDate = result.DueDate
+		task.Status = result.Status
+		return task, nil
+	}, nil
+}
+
+// normalizeMergePatch rewrites the keys of a merge patch to taskDocument's
+// field names. Only objects are accepted; a merge patch of any other type
+// would replace the whole task.
+func normalizeMergePatch(body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: merge patch must be a JSON object", errInvalidPatch)
+	}
+
+	normalized := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		normalized[patchFieldName(name)] = value
+	}
+	return json.Marshal(normalized)
+}
+
+// decodeJSONPatch decodes an RFC 6902 patch, rewriting the top-level field
+// of each path to taskDocument's field names.
+func decodeJSONPatch(body []byte) (jsonpatch.Patch, error) {
+	var operations []map[string]interface{}
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, fmt.Errorf("%w: JSON Patch must be an array of operations", errInvalidPatch)
+	}
+
+	for _, operation := range operations {
+		for _, key := range []string{"path", "from"} {
+			if pointer, ok := operation[key].(string); ok && strings.HasPrefix(pointer, "/") {
+				field, rest, nested := strings.Cut(pointer[1:], "/")
+				normalized := "/" + patchFieldName(field)
+				if nested {
+					normalized += "/" + rest
+				}
+				operation[key] = normalized
+			}
+		}
+	}
+
+	normalized, err := json.Marshal(operations)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.DecodePatch(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPatch, err)
+	}
+	return patch, nil
+}
@@ -1,36 +1,279 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"task-manager/Config"
 	"task-manager/Delivery/controllers"
 	"task-manager/Delivery/router"
+	"task-manager/Domain"
 	"task-manager/Infrastructure"
 	"task-manager/Repositories"
 	"task-manager/Usecases"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+type stores struct {
+	Repositories.Stores
+
+	// checks back the readiness probe; close releases the database once
+	// the server has stopped.
+	checks []Domain.HealthCheck
+	close  func(ctx context.Context) error
+}
+
+// openStores builds the repositories for the configured backend. "sqlite"
+// stores everything in a single file; "memory" needs no database and is
+// meant for local development and demos. Both only serve a single
+// instance, so they count failed logins in memory.
+func openStores(ctx context.Context, cfg Config.StorageConfig) stores {
+	switch cfg.Backend {
+	case "mongo":
+		db, err := Repositories.ConnectMongo(ctx, cfg.MongoOptions())
+		if err != nil {
+			fatal("failed to connect to mongo", err)
+		}
+		s := stores{
+			checks: []Domain.HealthCheck{{Name: "mongo", Check: db.Ping}},
+			close:  db.Disconnect,
+		}
+		// Connecting retries on its own schedule; once the server answers,
+		// preparing the collections must not hang if it stops.
+		setupCtx, cancel := context.WithTimeout(ctx, cfg.StartupTimeout())
+		defer cancel()
+		s.Roles = Repositories.NewRoleRepository(db)
+		errs := make([]error, 6)
+		s.Tasks, errs[0] = Repositories.NewTaskRepository(setupCtx, db)
+		s.Users, errs[1] = Repositories.NewUserRepository(setupCtx, db)
+		s.Tokens, errs[2] = Repositories.NewTokenRepository(setupCtx, db)
+		s.Audit, errs[3] = Repositories.NewAuditRepository(setupCtx, db)
+		s.OneTimeTokens, errs[4] = Repositories.NewOneTimeTokenRepository(setupCtx, db)
+		s.LoginAttempts, errs[5] = Repositories.NewLoginAttemptRepository(setupCtx, db)
+		if err := errors.Join(errs...); err != nil {
+			fatal("failed to prepare mongo collections", err)
+		}
+		return s
+	case "sqlite":
+		db, err := Repositories.OpenSQLite(cfg.SQLitePath, cfg.Timeouts())
+		if err != nil {
+			fatal("failed to open sqlite database", err)
+		}
+		return stores{
+			Stores: Repositories.Stores{
+				Tasks:         Repositories.NewSQLiteTaskRepository(db),
+				Users:         Repositories.NewSQLiteUserRepository(db),
+				Tokens:        Repositories.NewSQLiteTokenRepository(db),
+				Audit:         Repositories.NewSQLiteAuditRepository(db),
+				Roles:         Repositories.NewSQLiteRoleRepository(db),
+				OneTimeTokens: Repositories.NewSQLiteOneTimeTokenRepository(db),
+				LoginAttempts: Infrastructure.NewInMemoryLoginAttemptStore(),
+			},
+			checks: []Domain.HealthCheck{{Name: "sqlite", Check: db.Ping}},
+			close:  func(context.Context) error { return db.Close() },
+		}
+	case "memory":
+		return stores{
+			Stores: Repositories.Stores{
+				Tasks:         Repositories.NewMemoryTaskRepository(),
+				Users:         Repositories.NewMemoryUserRepository(),
+				Tokens:        Infrastructure.NewInMemoryRevocationStore(),
+				Audit:         Repositories.NewMemoryAuditRepository(),
+				Roles:         Repositories.NewMemoryRoleRepository(),
+				OneTimeTokens: Repositories.NewMemoryOneTimeTokenRepository(),
+				LoginAttempts: Infrastructure.NewInMemoryLoginAttemptStore(),
+			},
+			close: func(context.Context) error { return nil },
+		}
+	}
+	fatal("unknown storage backend", fmt.Errorf("%q", cfg.Backend))
+	return stores{}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// purgeTrash permanently removes tasks older than retention from the trash
+// every interval until ctx is cancelled.
+func purgeTrash(ctx context.Context, taskUC *Usecases.TaskUsecase, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := taskUC.PurgeTrash(ctx, retention)
+		if err != nil {
+			slog.Error("trash purge failed", "error", err)
+		} else if purged > 0 {
+			slog.Info("purged tasks from the trash", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func main() {
-    err := godotenv.Load()
-    if err != nil {
-        log.Fatal("Error loading .env file")
-    }
+	// Until the configured level is known, log at the default one.
+	slog.SetDefault(Infrastructure.NewLogger(os.Stdout, slog.LevelInfo))
+
+	// A .env file is optional; real deployments set the environment directly.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fatal("failed to read .env", err)
+	}
+
+	cfg, err := Config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	slog.SetDefault(Infrastructure.NewLogger(os.Stdout, cfg.Log.Level))
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
+	}
+
+	// SIGINT and SIGTERM also abort waiting for the database at startup.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	metrics := Infrastructure.NewMetrics()
+	repos := openStores(ctx, cfg.Storage)
+	repos.Stores = Repositories.Instrument(repos.Stores, metrics)
+
+	passwordService := Infrastructure.NewPasswordServiceWithPolicy(cfg.Passwords.Hashing)
+	jwtService, err := Infrastructure.NewJWTServiceWithStore(cfg.Auth.KeysFile, cfg.Auth.SecretKey, repos.Tokens)
+	if err != nil {
+		fatal("failed to load JWT signing keys", err)
+	}
+	jwtService.AccessTTL = cfg.Auth.AccessTokenTTL
+	jwtService.RefreshTTL = cfg.Auth.RefreshTokenTTL
+
+	// Validated by Config.Load.
+	unverified, _ := Domain.ParseUnverifiedAccess(cfg.Auth.UnverifiedAccess)
+
+	authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, repos.Users, repos.Roles)
+	authMiddleware.Unverified = unverified
+	authMiddleware.Metrics = metrics
+
+	roleUC := Usecases.NewRoleUsecase(repos.Roles, repos.Users)
+	roleUC.Audit = repos.Audit
+	seedCtx, cancelSeed := context.WithTimeout(ctx, cfg.Storage.StartupTimeout())
+	err = roleUC.SeedDefaults(seedCtx)
+	cancelSeed()
+	if err != nil {
+		fatal("failed to store default roles", err)
+	}
+
+	userUC := Usecases.NewUserUsecase(repos.Users, passwordService, jwtService)
+	userUC.PasswordPolicy = Infrastructure.DefaultPasswordPolicy()
+	if cfg.Passwords.PolicyFile != "" {
+		policy, err := Infrastructure.LoadPasswordPolicy(cfg.Passwords.PolicyFile)
+		if err != nil {
+			fatal("failed to load password policy", err)
+		}
+		userUC.PasswordPolicy = policy
+	}
+	userUC.Audit = repos.Audit
+
+	// Mail is written to the outbox directory rather than sent; deliver it
+	// from there or read it directly during development.
+	userUC.Mailer = Infrastructure.NewOutboxMailer(cfg.Mail.OutboxDir)
+	userUC.ResetTokens = repos.OneTimeTokens
+	userUC.ResetTokenTTL = cfg.Passwords.ResetTTL
+	userUC.ResetURL = cfg.Passwords.ResetURL
+	// With only a keys file there is no shared secret; verification links
+	// are then signed with one derived from the active signing key, so
+	// rotating it invalidates links already mailed.
+	verificationSecret := cfg.Auth.SecretKey
+	if verificationSecret == "" {
+		verificationSecret = jwtService.DeriveSecret("email verification")
+	}
+	userUC.Verification, err = Infrastructure.NewVerificationTokenService(verificationSecret, cfg.Auth.VerificationTTL)
+	if err != nil {
+		fatal("failed to set up email verification", err)
+	}
+	userUC.VerifyURL = cfg.Auth.VerificationURL
+	userUC.Unverified = unverified
+	userUC.LoginAttempts = repos.LoginAttempts
+	userUC.Metrics = metrics
+	userUC.RoleRepo = repos.Roles
+
+	taskUC := Usecases.NewTaskUsecase(repos.Tasks)
+	taskUC.Audit = repos.Audit
+	if cfg.Tasks.WorkflowFile != "" {
+		workflow, err := Infrastructure.LoadWorkflow(cfg.Tasks.WorkflowFile)
+		if err != nil {
+			fatal("failed to load task workflow", err)
+		}
+		taskUC.Workflow = workflow
+	}
+
+	// The purge stops with ctx; the database is closed only after it has.
+	var purging sync.WaitGroup
+	purging.Add(1)
+	go func() {
+		defer purging.Done()
+		purgeTrash(ctx, taskUC, cfg.Tasks.TrashRetention, cfg.Tasks.TrashPurgeInterval)
+	}()
 
-    passwordService := Infrastructure.NewPasswordService()
-    jwtService := Infrastructure.NewJWTService()
+	userController := controllers.NewUserController(userUC)
+	taskController := controllers.NewTaskController(taskUC)
+	adminController := controllers.NewAdminController(Usecases.NewAuditUsecase(repos.Audit), roleUC)
+	keysController := controllers.NewKeysController(jwtService)
+	healthController := controllers.NewHealthController(repos.checks...)
+	metricsToken := ""
+	if cfg.Metrics.RequireAuth {
+		metricsToken = cfg.Metrics.AuthToken
+	}
+	metricsController := controllers.NewMetricsController(metrics, metricsToken)
 
-    authMiddleware := Infrastructure.NewAuthMiddleware(jwtService) 
+	r := router.SetupRouter(userController, taskController, adminController, keysController, healthController, metricsController, authMiddleware)
+	// Failed logins are counted per client address, so only trusted proxies
+	// may set it through X-Forwarded-For.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
 
-    userRepo := Repositories.NewUserRepository()
-    taskRepo := Repositories.NewTaskRepository()
+	server := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Server.Port), Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("server failed", err)
+		}
+	}()
+	slog.Info("listening", "addr", server.Addr)
 
-    userUC := Usecases.NewUserUsecase(userRepo, passwordService, jwtService)
-    taskUC := Usecases.NewTaskUsecase(taskRepo)
+	<-ctx.Done()
+	stop()
 
-    userController := controllers.NewUserController(userUC)
-    taskController := controllers.NewTaskController(taskUC)
+	// On SIGTERM, fail the readiness probe and give in-flight requests the
+	// shutdown grace period to finish before the database is closed.
+	healthController.StartDraining()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownGracePeriod)
+	defer cancel()
 
-    r := router.SetupRouter(userController, taskController, authMiddleware) 
-    r.Run()
+	slog.Info("shutting down")
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("requests still running at the end of the grace period", "error", err)
+	}
+	purging.Wait()
+	if err := repos.close(shutdownCtx); err != nil {
+		slog.Error("failed to close the database", "error", err)
+	}
 }
@@ -2,35 +2,144 @@ package router
 
 import (
 	controller "task-manager/Delivery/controllers"
+	"task-manager/Domain"
 	"task-manager/Infrastructure"
 
-		"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler, TaskHandler, AdminHandler, KeysHandler, HealthHandler and
+// MetricsHandler are satisfied by the controllers package and let tests
+// drive the routes with stand-ins.
+type UserHandler interface {
+	Register(c *gin.Context)
+	Login(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	PromoteUser(c *gin.Context)
+	ListUsers(c *gin.Context)
+	DemoteUser(c *gin.Context)
+	DisableUser(c *gin.Context)
+	EnableUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+}
+
+type TaskHandler interface {
+	GetAllTasks(c *gin.Context)
+	GetTaskByID(c *gin.Context)
+	CreateTask(c *gin.Context)
+	UpdateTask(c *gin.Context)
+	PatchTask(c *gin.Context)
+	DeleteTask(c *gin.Context)
+	ListTrash(c *gin.Context)
+	RestoreTask(c *gin.Context)
+}
+
+type AdminHandler interface {
+	ListAudit(c *gin.Context)
+	ListRoles(c *gin.Context)
+	SaveRole(c *gin.Context)
+	DeleteRole(c *gin.Context)
+	AssignRole(c *gin.Context)
+}
+
+type KeysHandler interface {
+	JWKS(c *gin.Context)
+}
 
+type HealthHandler interface {
+	Healthz(c *gin.Context)
+	Readyz(c *gin.Context)
+}
+
+type MetricsHandler interface {
+	ObserveRequests(c *gin.Context)
+	Metrics(c *gin.Context)
+}
+
+var (
+	_ UserHandler    = (*controller.UserController)(nil)
+	_ TaskHandler    = (*controller.TaskController)(nil)
+	_ AdminHandler   = (*controller.AdminController)(nil)
+	_ KeysHandler    = (*controller.KeysController)(nil)
+	_ HealthHandler  = (*controller.HealthController)(nil)
+	_ MetricsHandler = (*controller.MetricsController)(nil)
 )
 
 func SetupRouter(
-    userC *controller.UserController, 
-    taskC *controller.TaskController,
-    authMiddleware *Infrastructure.AuthMiddleware,
+	userC UserHandler,
+	taskC TaskHandler,
+	adminC AdminHandler,
+	keysC KeysHandler,
+	healthC HealthHandler,
+	metricsC MetricsHandler,
+	authMiddleware *Infrastructure.AuthMiddleware,
 ) *gin.Engine {
-    router := gin.Default()
-
-    userRoutes := router.Group("/users")
-    {
-        userRoutes.POST("/register", userC.Register)
-        userRoutes.POST("/login", userC.Login)
-
-        userRoutes.PUT("/promote/:id", authMiddleware.Middleware(), authMiddleware.AdminMiddleware(), userC.PromoteUser)
-    }
-
-    taskRoutes := router.Group("/tasks", authMiddleware.Middleware())
-    {
-        taskRoutes.GET("/", taskC.GetAllTasks)
-        taskRoutes.GET("/:id", taskC.GetTaskByID)
-        taskRoutes.POST("/", taskC.CreateTask)
-        taskRoutes.PUT("/:id", taskC.UpdateTask)
-        taskRoutes.DELETE("/:id", taskC.DeleteTask)
-    }
-
-    return router
+	// gin's own logger is replaced by the structured one, which also tags
+	// the request's context for everything it calls.
+	router := gin.New()
+	// Requests are counted outside Recovery so panics show up as 500s.
+	router.Use(Infrastructure.RequestLogger(), metricsC.ObserveRequests, Infrastructure.Recovery())
+
+	router.GET("/healthz", healthC.Healthz)
+	router.GET("/readyz", healthC.Readyz)
+	router.GET("/.well-known/jwks.json", keysC.JWKS)
+	router.GET("/metrics", metricsC.Metrics)
+
+	userRoutes := router.Group("/users")
+	{
+		userRoutes.POST("/register", userC.Register)
+		userRoutes.POST("/login", userC.Login)
+		userRoutes.POST("/refresh", userC.Refresh)
+		userRoutes.POST("/logout", authMiddleware.Middleware(), userC.Logout)
+		userRoutes.POST("/password/forgot", userC.ForgotPassword)
+		userRoutes.POST("/password/reset", userC.ResetPassword)
+		userRoutes.GET("/verify", userC.VerifyEmail)
+		userRoutes.POST("/verify/resend", userC.ResendVerification)
+
+		userRoutes.PUT("/promote/:id", authMiddleware.Middleware(), authMiddleware.RequirePermission(Domain.PermUsersPromote), userC.PromoteUser)
+
+		manageUsers := authMiddleware.RequirePermission(Domain.PermUsersManage)
+		userRoutes.GET("", authMiddleware.Middleware(), manageUsers, userC.ListUsers)
+		userRoutes.PUT("/:id/demote", authMiddleware.Middleware(), manageUsers, userC.DemoteUser)
+		userRoutes.PUT("/:id/disable", authMiddleware.Middleware(), manageUsers, userC.DisableUser)
+		userRoutes.PUT("/:id/enable", authMiddleware.Middleware(), manageUsers, userC.EnableUser)
+		userRoutes.PUT("/:id/unlock", authMiddleware.Middleware(), manageUsers, userC.UnlockUser)
+		userRoutes.DELETE("/:id", authMiddleware.Middleware(), manageUsers, userC.DeleteUser)
+	}
+
+	canRead := authMiddleware.RequirePermission(Domain.PermTasksRead)
+	canWrite := authMiddleware.RequirePermission(Domain.PermTasksWrite)
+	canDelete := authMiddleware.RequirePermission(Domain.PermTasksDelete)
+
+	taskRoutes := router.Group("/tasks", authMiddleware.Middleware())
+	{
+		taskRoutes.GET("/", canRead, taskC.GetAllTasks)
+		taskRoutes.GET("/trash", canRead, taskC.ListTrash)
+		taskRoutes.GET("/:id", canRead, taskC.GetTaskByID)
+		taskRoutes.POST("/", canWrite, taskC.CreateTask)
+		taskRoutes.PUT("/:id", canWrite, taskC.UpdateTask)
+		taskRoutes.PATCH("/:id", canWrite, taskC.PatchTask)
+		taskRoutes.DELETE("/:id", canDelete, taskC.DeleteTask)
+		taskRoutes.POST("/:id/restore", canWrite, taskC.RestoreTask)
+	}
+
+	adminRoutes := router.Group("/admin", authMiddleware.Middleware())
+	{
+		adminRoutes.GET("/audit", authMiddleware.RequirePermission(Domain.PermAuditRead), adminC.ListAudit)
+
+		manageRoles := authMiddleware.RequirePermission(Domain.PermRolesManage)
+		adminRoutes.GET("/roles", manageRoles, adminC.ListRoles)
+		adminRoutes.PUT("/roles/:name", manageRoles, adminC.SaveRole)
+		adminRoutes.DELETE("/roles/:name", manageRoles, adminC.DeleteRole)
+
+		adminRoutes.PUT("/users/:id/role", authMiddleware.RequirePermission(Domain.PermUsersPromote), adminC.AssignRole)
+	}
+
+	return router
 }
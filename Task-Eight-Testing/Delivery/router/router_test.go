@@ -1,228 +1,711 @@
 package router_test
 
 import (
-    "bytes"
-    "encoding/json"
-    "net/http"
-    "net/http/httptest"
-    "os"
-    "testing"
-    "task-manager/Delivery/router"
-    "task-manager/Domain"
-    "task-manager/Infrastructure"
-
-    "github.com/gin-gonic/gin"
-    "github.com/stretchr/testify/assert"
-    "github.com/stretchr/testify/mock"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task-manager/Delivery/router"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type MockUserController struct {
-    mock.Mock
+	mock.Mock
 }
 
 func (m *MockUserController) Register(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusCreated, gin.H{"id": "123", "email": "test@example.com"})
+	m.Called(c)
+	c.JSON(http.StatusCreated, gin.H{"id": "123", "email": "test@example.com"})
 }
 
 func (m *MockUserController) Login(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, gin.H{"token": "jwt.token.here"})
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"token": "jwt.token.here"})
+}
+
+func (m *MockUserController) Refresh(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"token": "jwt.token.here", "refresh_token": "refresh.token.here"})
+}
+
+func (m *MockUserController) Logout(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
 
 func (m *MockUserController) PromoteUser(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, gin.H{"id": "123", "role": "admin"})
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": "123", "role": "admin"})
+}
+
+func (m *MockUserController) ListUsers(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"users": []gin.H{}})
+}
+
+func (m *MockUserController) DemoteUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "role": "member"})
+}
+
+func (m *MockUserController) DisableUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "disabled": true})
+}
+
+func (m *MockUserController) EnableUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "disabled": false})
+}
+
+func (m *MockUserController) UnlockUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+}
+
+func (m *MockUserController) DeleteUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+func (m *MockUserController) ForgotPassword(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset link has been sent"})
+}
+
+func (m *MockUserController) ResetPassword(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated"})
+}
+
+func (m *MockUserController) VerifyEmail(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (m *MockUserController) ResendVerification(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists and is not verified, a verification link has been sent"})
 }
 
 type MockTaskController struct {
-    mock.Mock
+	mock.Mock
 }
 
 func (m *MockTaskController) GetAllTasks(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, []gin.H{{"id": "1", "title": "Test Task"}})
+	m.Called(c)
+	c.JSON(http.StatusOK, []gin.H{{"id": "1", "title": "Test Task"}})
 }
 
 func (m *MockTaskController) GetTaskByID(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Test Task"})
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Test Task"})
 }
 
 func (m *MockTaskController) CreateTask(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusCreated, gin.H{"id": "1", "title": "New Task"})
+	m.Called(c)
+	c.JSON(http.StatusCreated, gin.H{"id": "1", "title": "New Task"})
 }
 
 func (m *MockTaskController) UpdateTask(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Updated Task"})
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Updated Task"})
+}
+
+func (m *MockTaskController) PatchTask(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Patched Task"})
 }
 
 func (m *MockTaskController) DeleteTask(c *gin.Context) {
-    m.Called(c)
-    c.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
+}
+
+func (m *MockTaskController) ListTrash(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"tasks": []gin.H{}})
+}
+
+func (m *MockTaskController) RestoreTask(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": "1", "title": "Restored Task"})
+}
+
+type MockKeysController struct {
+	mock.Mock
+}
+
+func (m *MockKeysController) JWKS(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"keys": []gin.H{}})
+}
+
+type MockHealthController struct {
+	mock.Mock
+}
+
+func (m *MockHealthController) Healthz(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (m *MockHealthController) Readyz(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+type MockMetricsController struct {
+	mock.Mock
+}
+
+func (m *MockMetricsController) ObserveRequests(c *gin.Context) {
+	m.Called(c)
+	c.Next()
+}
+
+func (m *MockMetricsController) Metrics(c *gin.Context) {
+	m.Called(c)
+	c.String(http.StatusOK, "http_requests_total 0\n")
+}
+
+type MockAdminController struct {
+	mock.Mock
+}
+
+func (m *MockAdminController) ListAudit(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"entries": []gin.H{}})
+}
+
+func (m *MockAdminController) ListRoles(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"roles": []gin.H{}})
+}
+
+func (m *MockAdminController) SaveRole(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"name": c.Param("name")})
+}
+
+func (m *MockAdminController) DeleteRole(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
+}
+
+func (m *MockAdminController) AssignRole(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
 }
 
 type UserControllerWrapper struct {
-    Mock *MockUserController
+	Mock *MockUserController
 }
 
 func (w *UserControllerWrapper) Register(c *gin.Context) {
-    w.Mock.Register(c)
+	w.Mock.Register(c)
 }
 
 func (w *UserControllerWrapper) Login(c *gin.Context) {
-    w.Mock.Login(c)
+	w.Mock.Login(c)
+}
+
+func (w *UserControllerWrapper) Refresh(c *gin.Context) {
+	w.Mock.Refresh(c)
+}
+
+func (w *UserControllerWrapper) Logout(c *gin.Context) {
+	w.Mock.Logout(c)
 }
 
 func (w *UserControllerWrapper) PromoteUser(c *gin.Context) {
-    w.Mock.PromoteUser(c)
+	w.Mock.PromoteUser(c)
+}
+
+func (w *UserControllerWrapper) ListUsers(c *gin.Context) {
+	w.Mock.ListUsers(c)
+}
+
+func (w *UserControllerWrapper) DemoteUser(c *gin.Context) {
+	w.Mock.DemoteUser(c)
+}
+
+func (w *UserControllerWrapper) DisableUser(c *gin.Context) {
+	w.Mock.DisableUser(c)
+}
+
+func (w *UserControllerWrapper) EnableUser(c *gin.Context) {
+	w.Mock.EnableUser(c)
+}
+
+func (w *UserControllerWrapper) UnlockUser(c *gin.Context) {
+	w.Mock.UnlockUser(c)
+}
+
+func (w *UserControllerWrapper) DeleteUser(c *gin.Context) {
+	w.Mock.DeleteUser(c)
+}
+
+func (w *UserControllerWrapper) ForgotPassword(c *gin.Context) {
+	w.Mock.ForgotPassword(c)
+}
+
+func (w *UserControllerWrapper) ResetPassword(c *gin.Context) {
+	w.Mock.ResetPassword(c)
+}
+
+func (w *UserControllerWrapper) VerifyEmail(c *gin.Context) {
+	w.Mock.VerifyEmail(c)
+}
+
+func (w *UserControllerWrapper) ResendVerification(c *gin.Context) {
+	w.Mock.ResendVerification(c)
 }
 
 // Wrapper for TaskController
 type TaskControllerWrapper struct {
-    Mock *MockTaskController
+	Mock *MockTaskController
 }
 
 func (w *TaskControllerWrapper) GetAllTasks(c *gin.Context) {
-    w.Mock.GetAllTasks(c)
+	w.Mock.GetAllTasks(c)
 }
 
 func (w *TaskControllerWrapper) GetTaskByID(c *gin.Context) {
-    w.Mock.GetTaskByID(c)
+	w.Mock.GetTaskByID(c)
 }
 
 func (w *TaskControllerWrapper) CreateTask(c *gin.Context) {
-    w.Mock.CreateTask(c)
+	w.Mock.CreateTask(c)
 }
 
 func (w *TaskControllerWrapper) UpdateTask(c *gin.Context) {
-    w.Mock.UpdateTask(c)
+	w.Mock.UpdateTask(c)
+}
+
+func (w *TaskControllerWrapper) PatchTask(c *gin.Context) {
+	w.Mock.PatchTask(c)
 }
 
 func (w *TaskControllerWrapper) DeleteTask(c *gin.Context) {
-    w.Mock.DeleteTask(c)
+	w.Mock.DeleteTask(c)
+}
+
+func (w *TaskControllerWrapper) ListTrash(c *gin.Context) {
+	w.Mock.ListTrash(c)
+}
+
+func (w *TaskControllerWrapper) RestoreTask(c *gin.Context) {
+	w.Mock.RestoreTask(c)
 }
 
 func setupRouterTest(t *testing.T) (*gin.Engine, *MockUserController, *MockTaskController, func()) {
-    t.Helper()
+	t.Helper()
+
+	routerEngine, mockUserController, mockTaskController, _, cleanup := setupAdminRouterTest(t)
+	return routerEngine, mockUserController, mockTaskController, cleanup
+}
+
+func setupAdminRouterTest(t *testing.T) (*gin.Engine, *MockUserController, *MockTaskController, *MockAdminController, func()) {
+	t.Helper()
 
-    gin.SetMode(gin.TestMode)
+	gin.SetMode(gin.TestMode)
 
-    originalKey := os.Getenv("SECRET_KEY")
-    os.Setenv("SECRET_KEY", "test-secret-key-for-router-testing")
+	jwtService, err := Infrastructure.NewJWTService(testSecretKey)
+	if err != nil {
+		t.Fatalf("Failed to create JWT service: %v", err)
+	}
+	authMiddleware := Infrastructure.NewAuthMiddleware(jwtService)
 
-    jwtService := Infrastructure.NewJWTService()
-    authMiddleware := Infrastructure.NewAuthMiddleware(jwtService)
+	mockUserController := new(MockUserController)
+	mockTaskController := new(MockTaskController)
+	mockAdminController := new(MockAdminController)
 
-    mockUserController := new(MockUserController)
-    mockTaskController := new(MockTaskController)
+	userWrapper := &UserControllerWrapper{Mock: mockUserController}
+	taskWrapper := &TaskControllerWrapper{Mock: mockTaskController}
 
-    userWrapper := &UserControllerWrapper{Mock: mockUserController}
-    taskWrapper := &TaskControllerWrapper{Mock: mockTaskController}
+	mockKeysController := new(MockKeysController)
+	mockKeysController.On("JWKS", mock.Anything)
+	mockHealthController := new(MockHealthController)
+	mockHealthController.On("Healthz", mock.Anything)
+	mockHealthController.On("Readyz", mock.Anything)
+	mockMetricsController := new(MockMetricsController)
+	mockMetricsController.On("ObserveRequests", mock.Anything)
+	mockMetricsController.On("Metrics", mock.Anything)
 
-    routerEngine := router.SetupRouter(userWrapper, taskWrapper, authMiddleware)
+	routerEngine := router.SetupRouter(userWrapper, taskWrapper, mockAdminController, mockKeysController, mockHealthController, mockMetricsController, authMiddleware)
 
-    cleanup := func() {
-        if originalKey != "" {
-            os.Setenv("SECRET_KEY", originalKey)
-        } else {
-            os.Unsetenv("SECRET_KEY")
-        }
-        gin.SetMode(gin.DebugMode)
-    }
+	cleanup := func() {
+		gin.SetMode(gin.DebugMode)
+	}
 
-    return routerEngine, mockUserController, mockTaskController, cleanup
+	return routerEngine, mockUserController, mockTaskController, mockAdminController, cleanup
 }
 
+const testSecretKey = "test-secret-key-for-router-testing"
+
 func createValidToken(t *testing.T, email, role string) string {
-    t.Helper()
-    
-    jwtService := Infrastructure.NewJWTService()
-    user := Domain.User{Email: email, Role: role}
-    token, err := jwtService.GenerateToken(user)
-    assert.NoError(t, err)
-    return token
+	t.Helper()
+
+	jwtService, err := Infrastructure.NewJWTService(testSecretKey)
+	assert.NoError(t, err)
+	user := Domain.User{ID: "id-" + email, Email: email, Role: role}
+	token, err := jwtService.GenerateToken(user)
+	assert.NoError(t, err)
+	return token
 }
 
 // user routes tests
 
 func TestRouter_UserRegister_Success(t *testing.T) {
-    routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
-    defer cleanup()
-    
-    mockUserController.On("Register", mock.Anything)
-    
-    reqBody := map[string]string{"email": "test@example.com", "password": "password123"}
-    jsonData, _ := json.Marshal(reqBody)
-    
-    req := httptest.NewRequest("POST", "/users/register", bytes.NewBuffer(jsonData))
-    req.Header.Set("Content-Type", "application/json")
-    w := httptest.NewRecorder()
-    
-    routerEngine.ServeHTTP(w, req)
-    
-    assert.Equal(t, http.StatusCreated, w.Code)
-    mockUserController.AssertCalled(t, "Register", mock.Anything)
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("Register", mock.Anything)
+
+	reqBody := map[string]string{"email": "test@example.com", "password": "password123"}
+	jsonData, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest("POST", "/users/register", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockUserController.AssertCalled(t, "Register", mock.Anything)
 }
 
 func TestRouter_UserLogin_Success(t *testing.T) {
-    routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
-    defer cleanup()
-    
-    mockUserController.On("Login", mock.Anything)
-    
-    reqBody := map[string]string{"email": "test@example.com", "password": "password123"}
-    jsonData, _ := json.Marshal(reqBody)
-    
-    req := httptest.NewRequest("POST", "/users/login", bytes.NewBuffer(jsonData))
-    req.Header.Set("Content-Type", "application/json")
-    w := httptest.NewRecorder()
-    
-    routerEngine.ServeHTTP(w, req)
-    
-    assert.Equal(t, http.StatusOK, w.Code)
-    mockUserController.AssertCalled(t, "Login", mock.Anything)
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("Login", mock.Anything)
+
+	reqBody := map[string]string{"email": "test@example.com", "password": "password123"}
+	jsonData, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest("POST", "/users/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUserController.AssertCalled(t, "Login", mock.Anything)
 }
 
 func TestRouter_UserPromote_RequiresAuth(t *testing.T) {
-    routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
-    defer cleanup()
-    
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
 
 	req := httptest.NewRequest("PUT", "/users/promote/123", nil)
-    w := httptest.NewRecorder()
-    
-    routerEngine.ServeHTTP(w, req)
-    
-    assert.Equal(t, http.StatusUnauthorized, w.Code)
-    
-    var response map[string]interface{}
-    json.Unmarshal(w.Body.Bytes(), &response)
-    assert.Equal(t, "missing token", response["message"])
-    
-    mockUserController.AssertNotCalled(t, "PromoteUser")
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "missing token", response["message"])
+
+	mockUserController.AssertNotCalled(t, "PromoteUser")
 }
 
 func TestRouter_UserPromote_RequiresAdmin(t *testing.T) {
-    routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
-    defer cleanup()
-    
-    token := createValidToken(t, "user@example.com", "user")
-    
-    req := httptest.NewRequest("PUT", "/users/promote/123", nil)
-    req.Header.Set("Authorization", "Bearer "+token)
-    w := httptest.NewRecorder()
-    
-    routerEngine.ServeHTTP(w, req)
-    
-    assert.Equal(t, http.StatusForbidden, w.Code)
-    
-    var response map[string]interface{}
-    json.Unmarshal(w.Body.Bytes(), &response)
-    assert.Equal(t, "unauthorized", response["message"])
-    
-    mockUserController.AssertNotCalled(t, "PromoteUser")
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	token := createValidToken(t, "user@example.com", Domain.RoleMember)
+
+	req := httptest.NewRequest("PUT", "/users/promote/123", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "unauthorized", response["message"])
+
+	mockUserController.AssertNotCalled(t, "PromoteUser")
+}
+
+func TestRouter_UserLogout_RequiresAuth(t *testing.T) {
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/users/logout", nil)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockUserController.AssertNotCalled(t, "Logout", mock.Anything)
+}
+
+func TestRouter_UserRefresh_IsPublic(t *testing.T) {
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("Refresh", mock.Anything)
+
+	jsonData, _ := json.Marshal(map[string]string{"refresh_token": "refresh.token.here"})
+	req := httptest.NewRequest("POST", "/users/refresh", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUserController.AssertCalled(t, "Refresh", mock.Anything)
+}
+
+func TestRouter_PasswordReset_IsPublic(t *testing.T) {
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("ForgotPassword", mock.Anything)
+	mockUserController.On("ResetPassword", mock.Anything)
+
+	for path, status := range map[string]int{
+		"/users/password/forgot": http.StatusAccepted,
+		"/users/password/reset":  http.StatusOK,
+	} {
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		routerEngine.ServeHTTP(w, req)
+
+		assert.Equal(t, status, w.Code, path)
+	}
+	mockUserController.AssertNumberOfCalls(t, "ForgotPassword", 1)
+	mockUserController.AssertNumberOfCalls(t, "ResetPassword", 1)
 }
 
+func TestRouter_VerifyEmail_IsPublic(t *testing.T) {
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("VerifyEmail", mock.Anything)
+
+	req := httptest.NewRequest("GET", "/users/verify?token=abc", nil)
+	w := httptest.NewRecorder()
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUserController.AssertCalled(t, "VerifyEmail", mock.Anything)
+}
+
+func TestRouter_TaskPatch_RequiresAuth(t *testing.T) {
+	routerEngine, _, mockTaskController, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("PATCH", "/tasks/1", bytes.NewBufferString(`{"status": "done"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockTaskController.AssertNotCalled(t, "PatchTask", mock.Anything)
+}
+
+func TestRouter_TaskPatch_Success(t *testing.T) {
+	routerEngine, _, mockTaskController, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockTaskController.On("PatchTask", mock.Anything)
+	token := createValidToken(t, "user@example.com", Domain.RoleMember)
+
+	req := httptest.NewRequest("PATCH", "/tasks/1", bytes.NewBufferString(`{"status": "done"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockTaskController.AssertCalled(t, "PatchTask", mock.Anything)
+}
+
+func TestRouter_TaskTrash_IsNotATaskID(t *testing.T) {
+	routerEngine, _, mockTaskController, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockTaskController.On("ListTrash", mock.Anything)
+	token := createValidToken(t, "user@example.com", Domain.RoleMember)
+
+	req := httptest.NewRequest("GET", "/tasks/trash", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockTaskController.AssertCalled(t, "ListTrash", mock.Anything)
+	mockTaskController.AssertNotCalled(t, "GetTaskByID", mock.Anything)
+}
+
+func TestRouter_AdminAudit_RequiresAdmin(t *testing.T) {
+	routerEngine, _, _, mockAdminController, cleanup := setupAdminRouterTest(t)
+	defer cleanup()
+
+	token := createValidToken(t, "user@example.com", Domain.RoleMember)
+
+	req := httptest.NewRequest("GET", "/admin/audit", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAdminController.AssertNotCalled(t, "ListAudit", mock.Anything)
+}
+
+func TestRouter_AdminAudit_Success(t *testing.T) {
+	routerEngine, _, _, mockAdminController, cleanup := setupAdminRouterTest(t)
+	defer cleanup()
+
+	mockAdminController.On("ListAudit", mock.Anything)
+	token := createValidToken(t, "admin@example.com", "admin")
+
+	req := httptest.NewRequest("GET", "/admin/audit?entity=task", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAdminController.AssertCalled(t, "ListAudit", mock.Anything)
+}
+
+func TestRouter_TaskPatch_ViewerCannotWrite(t *testing.T) {
+	routerEngine, _, mockTaskController, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockTaskController.On("GetTaskByID", mock.Anything)
+	token := createValidToken(t, "viewer@example.com", Domain.RoleViewer)
+
+	req := httptest.NewRequest("PATCH", "/tasks/1", bytes.NewBufferString(`{"status": "done"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockTaskController.AssertNotCalled(t, "PatchTask", mock.Anything)
+
+	req = httptest.NewRequest("GET", "/tasks/1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRouter_AdminRoles_RequireRolesManage(t *testing.T) {
+	routerEngine, _, _, mockAdminController, cleanup := setupAdminRouterTest(t)
+	defer cleanup()
+
+	mockAdminController.On("SaveRole", mock.Anything)
+
+	for _, tt := range []struct {
+		role   string
+		status int
+	}{
+		{Domain.RoleManager, http.StatusForbidden},
+		{Domain.RoleAdmin, http.StatusOK},
+	} {
+		token := createValidToken(t, "someone@example.com", tt.role)
+		req := httptest.NewRequest("PUT", "/admin/roles/auditor", bytes.NewBufferString(`{"permissions": ["audit:read"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		routerEngine.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.role)
+	}
+	mockAdminController.AssertNumberOfCalls(t, "SaveRole", 1)
+}
+
+func TestRouter_UserManagement_RequiresUsersManage(t *testing.T) {
+	routerEngine, mockUserController, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	mockUserController.On("DemoteUser", mock.Anything)
+	mockUserController.On("DeleteUser", mock.Anything)
+	mockUserController.On("PromoteUser", mock.Anything)
+	mockUserController.On("UnlockUser", mock.Anything)
+	mockUserController.On("ListUsers", mock.Anything)
+
+	requests := []struct {
+		method, path, handler string
+	}{
+		{"PUT", "/users/123/demote", "DemoteUser"},
+		{"DELETE", "/users/123", "DeleteUser"},
+		{"PUT", "/users/promote/123", "PromoteUser"},
+		{"PUT", "/users/123/unlock", "UnlockUser"},
+		{"GET", "/users", "ListUsers"},
+	}
+	for _, tt := range requests {
+		for role, status := range map[string]int{
+			Domain.RoleManager: http.StatusForbidden,
+			Domain.RoleAdmin:   http.StatusOK,
+		} {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+createValidToken(t, "someone@example.com", role))
+			w := httptest.NewRecorder()
+			routerEngine.ServeHTTP(w, req)
+
+			assert.Equal(t, status, w.Code, tt.method+" "+tt.path+" as "+role)
+		}
+		mockUserController.AssertNumberOfCalls(t, tt.handler, 1)
+	}
+}
+
+func TestRouter_JWKS_IsPublic(t *testing.T) {
+	routerEngine, _, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys": []}`, w.Body.String())
+}
+
+func TestRouter_HealthProbesArePublic(t *testing.T) {
+	routerEngine, _, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		routerEngine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+func TestRouter_MetricsIsServedWithoutUserAuth(t *testing.T) {
+	routerEngine, _, _, cleanup := setupRouterTest(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	routerEngine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "http_requests_total")
+}
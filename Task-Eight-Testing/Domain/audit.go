@@ -0,0 +1,145 @@
+package Domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const (
+	AuditEntityTask = "task"
+	AuditEntityUser = "user"
+	AuditEntityRole = "role"
+)
+
+const (
+	AuditTaskCreate  = "task.create"
+	AuditTaskUpdate  = "task.update"
+	AuditTaskDelete  = "task.delete"
+	AuditTaskRestore = "task.restore"
+	AuditTaskPurge   = "task.purge"
+	AuditUserCreate  = "user.create"
+	AuditUserPromote = "user.promote"
+	AuditUserRole    = "user.role"
+	AuditUserDemote  = "user.demote"
+	AuditUserDisable = "user.disable"
+	AuditUserEnable  = "user.enable"
+	AuditUserDelete  = "user.delete"
+	AuditUserReset   = "user.password_reset"
+	AuditUserVerify  = "user.verify"
+	AuditUserUnlock  = "user.unlock"
+	AuditRoleSave    = "role.save"
+	AuditRoleDelete  = "role.delete"
+)
+
+// AuditSystemActor is recorded for changes no user asked for, such as the
+// scheduled trash purge.
+const AuditSystemActor = "system"
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// FieldChange is one field's value before and after a mutation. Before is
+// empty for created entities.
+type FieldChange struct {
+	Before string
+	After  string
+}
+
+// AuditEntry records who changed what and when. Changes only lists the fields
+// whose values differ.
+type AuditEntry struct {
+	ID        string
+	Actor     string
+	Action    string
+	Entity    string
+	EntityID  string
+	Timestamp time.Time
+	Changes   map[string]FieldChange
+}
+
+// AuditFilter selects audit entries; zero fields do not filter. Entries are
+// returned newest first.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+type IAuditRepository interface {
+	Record(ctx context.Context, entry AuditEntry) error
+	List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+}
+
+func taskAuditFields(task Task) map[string]string {
+	return map[string]string{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"owner":       task.Owner,
+		"due_date":    formatAuditTime(task.DueDate),
+		"deleted_at":  formatAuditTime(task.DeletedAt),
+	}
+}
+
+// userAuditFields never includes the password hash.
+func userAuditFields(user User) map[string]string {
+	return map[string]string{
+		"email":    user.Email,
+		"role":     user.Role,
+		"disabled": strconv.FormatBool(user.Disabled),
+		"verified": strconv.FormatBool(user.Verified),
+	}
+}
+
+func formatAuditTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func diffFields(before, after map[string]string) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	for name, value := range after {
+		if before[name] != value {
+			changes[name] = FieldChange{Before: before[name], After: value}
+		}
+	}
+	return changes
+}
+
+// DiffTasks lists the audited task fields that differ between before and
+// after. Pass a zero Task as before for a created task.
+func DiffTasks(before, after Task) map[string]FieldChange {
+	return diffFields(taskAuditFields(before), taskAuditFields(after))
+}
+
+// DiffRoles lists the permissions granted or revoked between before and
+// after, as "granted" or "revoked" changes keyed by permission.
+func DiffRoles(before, after Role) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	for _, permission := range after.Permissions {
+		if !before.Has(permission) {
+			changes[permission] = FieldChange{After: "granted"}
+		}
+	}
+	for _, permission := range before.Permissions {
+		if !after.Has(permission) {
+			changes[permission] = FieldChange{Before: "granted", After: "revoked"}
+		}
+	}
+	return changes
+}
+
+// DiffUsers lists the audited user fields that differ between before and
+// after. Pass a zero User as before for a created user.
+func DiffUsers(before, after User) map[string]FieldChange {
+	return diffFields(userAuditFields(before), userAuditFields(after))
+}
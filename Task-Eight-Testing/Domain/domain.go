@@ -1,6 +1,13 @@
 package Domain
 
-import "time"
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 type Task struct {
 	ID          string
@@ -8,14 +15,91 @@ type Task struct {
 	Description string
 	DueDate     time.Time
 	Status      string
+	// Owner is the ID of the user the task belongs to. Emails are not used:
+	// they can be registered again after an account is deleted.
+	Owner string
+	// Version starts at 1 and increases with every update. Repositories only
+	// apply an update whose Version matches the stored one.
+	Version   int64
+	UpdatedAt time.Time
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt time.Time
+}
+
+// Validate reports a task missing a field every stored task must have.
+// Whether the status is allowed is up to the workflow.
+func (t Task) Validate() error {
+	switch {
+	case strings.TrimSpace(t.Title) == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidTask)
+	case t.DueDate.IsZero():
+		return fmt.Errorf("%w: due_date is required", ErrInvalidTask)
+	case t.Status == "":
+		return fmt.Errorf("%w: status is required", ErrInvalidTask)
+	}
+	return nil
+}
+
+type TaskFilter struct {
+	Owner     string
+	Status    string
+	DueBefore time.Time
+	DueAfter  time.Time
+	Sort      string
+	Limit     int
+	Cursor    string
+}
+
+type TaskPage struct {
+	Tasks      []Task
+	NextCursor string
+}
+
+const (
+	DefaultTaskPageSize = 20
+	MaxTaskPageSize     = 100
+)
+
+// DefaultTrashRetention is how long deleted tasks stay restorable.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+const (
+	SortByCreated = "created"
+	SortByDueDate = "due_date"
+	SortByTitle   = "title"
+)
+
+// ParseTaskSort splits a sort expression such as "-due_date" into its field
+// and direction. An empty expression sorts by creation order.
+func ParseTaskSort(sort string) (field string, desc bool, err error) {
+	if strings.HasPrefix(sort, "-") {
+		desc = true
+		sort = sort[1:]
+	}
+	switch sort {
+	case "":
+		return SortByCreated, desc, nil
+	case SortByCreated, SortByDueDate, SortByTitle:
+		return sort, desc, nil
+	}
+	return "", false, ErrInvalidSort
 }
 
 type ITaskRepository interface {
-	GetAll() ([]Task, error)
-	GetByID(id string) (Task, error)
-	Create(task Task) (Task, error)
-	Update(id string, task Task) (Task, error)
-	Delete(id string) error
+	List(ctx context.Context, filter TaskFilter) (TaskPage, error)
+	GetByID(ctx context.Context, id string) (Task, error)
+	Create(ctx context.Context, task Task) (Task, error)
+	Update(ctx context.Context, id string, task Task) (Task, error)
+	// Delete moves a task to the trash. Trashed tasks are invisible to the
+	// methods above until they are restored.
+	Delete(ctx context.Context, id string) error
+	// ListTrash and Restore only see tasks owned by owner, or every task when
+	// owner is empty. Restore also reports when the task had been trashed.
+	ListTrash(ctx context.Context, owner string) ([]Task, error)
+	Restore(ctx context.Context, id string, owner string) (Task, time.Time, error)
+	// PurgeTrash permanently removes tasks trashed before the given time and
+	// returns their IDs.
+	PurgeTrash(ctx context.Context, before time.Time) ([]string, error)
 }
 
 type User struct {
@@ -23,25 +107,147 @@ type User struct {
 	Email    string
 	Password string
 	Role     string
+	// Disabled users cannot log in, and tokens issued to them are refused.
+	Disabled bool
+	// Verified is set once the user follows the link mailed to Email. What
+	// unverified users may do is decided by an UnverifiedAccess.
+	Verified bool
+	// TokenGeneration moves on to end every session the user has open:
+	// tokens carry the generation they were issued in, and those from an
+	// earlier one are refused.
+	TokenGeneration int
 }
 
-type IUserRepository interface {
-    FindByEmail(email string) (User, error)
-    Create(user User) (User, error)
-    Promote(id string) (User, error)
+// TokenRevoked reports whether a token issued to the user in generation was
+// revoked by moving TokenGeneration on since.
+func (u User) TokenRevoked(generation int) bool {
+	return generation < u.TokenGeneration
+}
+
+// UserFilter selects users for listing. Search matches part of the email
+// address, ignoring case.
+type UserFilter struct {
+	Search string
+	Role   string
+	Limit  int
+	Cursor string
 }
 
+type UserPage struct {
+	Users      []User
+	NextCursor string
+}
+
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+type IUserRepository interface {
+	FindByEmail(ctx context.Context, email string) (User, error)
+	FindByID(ctx context.Context, id string) (User, error)
+	Create(ctx context.Context, user User) (User, error)
+	// SetRole assigns the named role to the user. It does not check that
+	// the role exists.
+	// SetRole, SetDisabled and Delete refuse with ErrLastAdmin to take the
+	// last enabled admin out of that role, deciding atomically with the
+	// write so concurrent changes cannot remove every admin between them.
+	SetRole(ctx context.Context, id string, role string) (User, error)
+	// List returns users ordered by email.
+	List(ctx context.Context, filter UserFilter) (UserPage, error)
+	SetDisabled(ctx context.Context, id string, disabled bool) (User, error)
+	SetVerified(ctx context.Context, id string, verified bool) (User, error)
+	// SetPassword replaces the stored password hash.
+	SetPassword(ctx context.Context, id string, hash string) (User, error)
+	// NextTokenGeneration revokes every token issued to the user so far.
+	NextTokenGeneration(ctx context.Context, id string) (User, error)
+	Delete(ctx context.Context, id string) error
+}
 
 type IPasswordService interface {
 	Hash(password string) (string, error)
 	Compare(plain, hashed string) bool
+	// NeedsRehash reports a hash made under an older hashing policy.
+	NeedsRehash(hashed string) bool
 }
 
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
 type AuthClaims struct {
-	Email string
-	Role  string
+	// ID identifies the token itself; UserID, from the "sub" claim,
+	// identifies the user it was issued to.
+	ID        string
+	UserID    string
+	Email     string
+	Role      string
+	Type      string
+	ExpiresAt time.Time
+	// Generation is the user's TokenGeneration when the token was issued.
+	Generation int
+	// Permissions are those of Role when the request was authenticated.
+	// They are looked up per request rather than carried in the token.
+	Permissions []string
+}
+
+func (c AuthClaims) Can(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// CanGrant reports whether the claims hold every permission of role, which
+// a caller must before handing the role to someone else.
+func (c AuthClaims) CanGrant(role Role) bool {
+	for _, permission := range role.Permissions {
+		if !c.Can(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// ITokenRevocationStore remembers revoked token IDs (jti) until the token
+// would have expired anyway. RevokeOnce revokes id only if it is not
+// revoked yet, returning ErrTokenRevoked otherwise, so of several callers
+// racing to use a single-use token exactly one wins.
+type ITokenRevocationStore interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	RevokeOnce(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
 }
+
 type IJWTService interface {
 	GenerateToken(user User) (string, error)
-	ValidateToken(tokenString string) (*AuthClaims, error) 
-}
\ No newline at end of file
+	GenerateRefreshToken(user User) (string, error)
+	ValidateToken(ctx context.Context, tokenString string) (*AuthClaims, error)
+	ValidateRefreshToken(ctx context.Context, tokenString string) (*AuthClaims, error)
+	RevokeToken(ctx context.Context, claims *AuthClaims) error
+	// RevokeTokenOnce is RevokeToken for single-use tokens: it returns
+	// ErrTokenRevoked if the token was already revoked.
+	RevokeTokenOnce(ctx context.Context, claims *AuthClaims) error
+}
+
+// PublicKey is a key other services can verify our tokens with. Key holds
+// an *rsa.PublicKey or an ed25519.PublicKey.
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+type IPublicKeyProvider interface {
+	PublicKeys() []PublicKey
+}
+
+// HealthCheck reports whether a dependency, such as the database, can serve
+// requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
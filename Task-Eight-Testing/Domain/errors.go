@@ -0,0 +1,101 @@
+package Domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorKind classifies a failure independently of transport; the delivery
+// layer maps each kind onto a status code.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindBadRequest
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindPreconditionFailed
+	KindTooManyRequests
+)
+
+// Error is a failure the caller can act on. Code is a stable, machine-readable
+// identifier such as "task_not_found"; Message is meant for humans. Fields
+// lists per-field problems for validation failures. RetryAfter, when set,
+// is how long the caller should wait before trying again.
+type Error struct {
+	Kind       ErrorKind
+	Code       string
+	Message    string
+	Fields     []FieldError
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors by code, so an error built for one request, such as one
+// carrying field errors, still matches the sentinel it was derived from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// TooManyAttempts reports that the caller must wait before trying to log in
+// again. The result matches ErrTooManyAttempts with errors.Is.
+func TooManyAttempts(wait time.Duration) error {
+	return &Error{
+		Kind:       ErrTooManyAttempts.Kind,
+		Code:       ErrTooManyAttempts.Code,
+		Message:    ErrTooManyAttempts.Message,
+		RetryAfter: wait,
+	}
+}
+
+// KindOf reports the kind of the first *Error in err's chain, or
+// KindInternal when there is none.
+func KindOf(err error) ErrorKind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return KindInternal
+}
+
+var (
+	ErrInvalidInput         = NewError(KindBadRequest, "invalid_input", "invalid input")
+	ErrValidationFailed     = NewError(KindValidation, "validation_failed", "validation failed")
+	ErrInvalidID            = NewError(KindBadRequest, "invalid_id", "invalid id")
+	ErrInvalidSort          = NewError(KindBadRequest, "invalid_sort", "invalid sort field")
+	ErrInvalidCursor        = NewError(KindBadRequest, "invalid_cursor", "invalid cursor")
+	ErrUnknownStatus        = NewError(KindValidation, "unknown_status", "unknown task status")
+	ErrInvalidTransition    = NewError(KindConflict, "invalid_transition", "invalid status transition")
+	ErrInvalidInitialStatus = NewError(KindConflict, "invalid_initial_status", "tasks cannot be created in this status")
+	ErrInvalidTask          = NewError(KindValidation, "invalid_task", "invalid task")
+	ErrTaskNotFound         = NewError(KindNotFound, "task_not_found", "task not found")
+	ErrVersionConflict      = NewError(KindPreconditionFailed, "version_conflict", "task was modified since it was read")
+	ErrUserNotFound         = NewError(KindNotFound, "user_not_found", "user not found")
+	ErrEmailTaken           = NewError(KindConflict, "email_taken", "email already registered")
+	ErrInvalidCredentials   = NewError(KindUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrInvalidRefresh       = NewError(KindUnauthorized, "invalid_refresh_token", "invalid refresh token")
+	ErrRoleNotFound         = NewError(KindNotFound, "role_not_found", "role not found")
+	ErrInvalidRole          = NewError(KindValidation, "invalid_role", "invalid role")
+	ErrRoleNotGrantable     = NewError(KindForbidden, "role_not_grantable", "cannot grant a role with permissions you do not hold")
+	ErrProtectedRole        = NewError(KindConflict, "protected_role", "the admin role cannot be changed")
+	ErrLastAdmin            = NewError(KindConflict, "last_admin", "the last admin cannot be removed")
+	ErrUserDisabled         = NewError(KindForbidden, "user_disabled", "account disabled")
+	ErrTokenNotFound        = NewError(KindNotFound, "token_not_found", "token not found")
+	ErrTokenRevoked         = NewError(KindConflict, "token_revoked", "token already revoked")
+	ErrInvalidResetToken    = NewError(KindBadRequest, "invalid_reset_token", "invalid or expired reset token")
+	ErrEmailNotVerified     = NewError(KindForbidden, "email_not_verified", "email address not verified")
+	ErrInvalidVerifyToken   = NewError(KindBadRequest, "invalid_verification_token", "invalid or expired verification link")
+	ErrTooManyAttempts      = NewError(KindTooManyRequests, "too_many_attempts", "too many failed login attempts, try again later")
+	ErrMetricsAuth          = NewError(KindUnauthorized, "unauthorized", "a valid metrics token is required")
+)
@@ -0,0 +1,74 @@
+package Domain
+
+import (
+	"context"
+	"time"
+)
+
+// LockoutPolicy slows down repeated failed logins. From the BackoffAfter-th
+// failure on, the next attempt must wait BaseDelay, doubling with each
+// further failure; from the LockoutAfter-th failure on, it must wait
+// LockoutDuration. Zero thresholds turn that stage off.
+type LockoutPolicy struct {
+	BackoffAfter    int
+	BaseDelay       time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+// Delay is how long after the last of failures the next attempt must wait.
+// Backoff never exceeds LockoutDuration.
+func (p LockoutPolicy) Delay(failures int) time.Duration {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+	if p.BackoffAfter <= 0 || failures < p.BackoffAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.BackoffAfter; i < failures; i++ {
+		delay *= 2
+		if p.LockoutDuration > 0 && delay >= p.LockoutDuration {
+			return p.LockoutDuration
+		}
+	}
+	return delay
+}
+
+// LoginLimits applies one policy to failures for the same account and
+// another to failures from the same client address, which may try many
+// accounts.
+type LoginLimits struct {
+	Account LockoutPolicy
+	Client  LockoutPolicy
+	// Window is how long failures are remembered after the latest one. It
+	// should be at least as long as either LockoutDuration.
+	Window time.Duration
+}
+
+func DefaultLoginLimits() LoginLimits {
+	return LoginLimits{
+		Account: LockoutPolicy{BackoffAfter: 3, BaseDelay: time.Second, LockoutAfter: 10, LockoutDuration: 15 * time.Minute},
+		Client:  LockoutPolicy{BackoffAfter: 10, BaseDelay: time.Second, LockoutAfter: 50, LockoutDuration: 15 * time.Minute},
+		Window:  time.Hour,
+	}
+}
+
+// LoginAttempts counts the recent failed logins for an account or client.
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// ILoginAttemptStore keeps failed login counts by key, such as an account's
+// email or a client address.
+type ILoginAttemptStore interface {
+	// Get returns no failures for unknown keys and for keys whose last
+	// failure is more than the window given to RecordFailure ago.
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	// RecordFailure counts a failure at the given time and returns the
+	// updated count. Counting starts over once the window has passed.
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
+}
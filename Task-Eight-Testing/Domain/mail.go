@@ -0,0 +1,14 @@
+package Domain
+
+import "context"
+
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// IMailer delivers mail to users. Implementations decide the transport.
+type IMailer interface {
+	Send(ctx context.Context, message MailMessage) error
+}
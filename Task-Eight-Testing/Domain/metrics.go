@@ -0,0 +1,31 @@
+package Domain
+
+import (
+	"net/http"
+	"time"
+)
+
+// IMetrics records the requests the server handles and serves everything
+// collected for Prometheus to scrape.
+type IMetrics interface {
+	ObserveRequest(method, route string, status int, took time.Duration)
+	Handler() http.Handler
+}
+
+// IRepositoryMetrics records each call to a repository. Errors other than
+// domain errors, such as ErrTaskNotFound, count as failures.
+type IRepositoryMetrics interface {
+	ObserveOperation(repository, method string, took time.Duration, err error)
+}
+
+// ILoginMetrics counts login outcomes. reason is the code of the error the
+// login failed with.
+type ILoginMetrics interface {
+	LoginSucceeded()
+	LoginFailed(reason string)
+}
+
+// ITokenMetrics counts access tokens refused by the auth middleware.
+type ITokenMetrics interface {
+	TokenRejected(reason string)
+}
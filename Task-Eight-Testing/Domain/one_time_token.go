@@ -0,0 +1,34 @@
+package Domain
+
+import (
+	"context"
+	"time"
+)
+
+const TokenPurposePasswordReset = "password_reset"
+
+// DefaultResetTokenTTL is how long a password reset link can be used.
+const DefaultResetTokenTTL = time.Hour
+
+// OneTimeToken lets the holder of a secret sent out of band act on a user's
+// account once. Only the SHA-256 hash of the secret is stored.
+type OneTimeToken struct {
+	Hash      string
+	UserID    string
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+func (t OneTimeToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+type IOneTimeTokenRepository interface {
+	// Save stores a token and drops any earlier token with the same user and
+	// purpose, so only the latest one sent can be used.
+	Save(ctx context.Context, token OneTimeToken) error
+	// Consume removes and returns the token with the given purpose and hash.
+	// It returns ErrTokenNotFound when there is none; expiry is up to the
+	// caller.
+	Consume(ctx context.Context, purpose, hash string) (OneTimeToken, error)
+}
@@ -0,0 +1,87 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+const (
+	PermTasksRead   = "tasks:read"
+	PermTasksWrite  = "tasks:write"
+	PermTasksDelete = "tasks:delete"
+	// PermTasksAll extends the task permissions to tasks owned by others.
+	PermTasksAll     = "tasks:all"
+	PermUsersPromote = "users:promote"
+	// PermUsersManage allows listing, demoting, disabling and deleting users.
+	PermUsersManage = "users:manage"
+	PermRolesManage = "roles:manage"
+	PermAuditRead   = "audit:read"
+)
+
+// Permissions lists every permission a role may be granted.
+var Permissions = []string{
+	PermTasksRead,
+	PermTasksWrite,
+	PermTasksDelete,
+	PermTasksAll,
+	PermUsersPromote,
+	PermUsersManage,
+	PermRolesManage,
+	PermAuditRead,
+}
+
+const (
+	RoleViewer  = "viewer"
+	RoleMember  = "member"
+	RoleManager = "manager"
+	// RoleAdmin always holds every permission and cannot be redefined, so
+	// there is always a role able to manage the others.
+	RoleAdmin = "admin"
+	// DefaultRole is given to every registered user except the first, who
+	// becomes an admin.
+	DefaultRole = RoleMember
+)
+
+// Role is a named set of permissions. Users hold one role by name, so
+// changing a role's permissions applies to everyone who holds it.
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+func (r Role) Has(permission string) bool {
+	return slices.Contains(r.Permissions, permission)
+}
+
+// Validate reports a role without a name or with a permission that does not
+// exist.
+func (r Role) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+	for _, permission := range r.Permissions {
+		if !slices.Contains(Permissions, permission) {
+			return fmt.Errorf("%w: unknown permission %q", ErrInvalidRole, permission)
+		}
+	}
+	return nil
+}
+
+// DefaultRoles are stored when the role store is first used.
+func DefaultRoles() []Role {
+	return []Role{
+		{Name: RoleViewer, Permissions: []string{PermTasksRead}},
+		{Name: RoleMember, Permissions: []string{PermTasksRead, PermTasksWrite, PermTasksDelete}},
+		{Name: RoleManager, Permissions: []string{PermTasksRead, PermTasksWrite, PermTasksDelete, PermTasksAll, PermAuditRead}},
+		{Name: RoleAdmin, Permissions: slices.Clone(Permissions)},
+	}
+}
+
+type IRoleRepository interface {
+	List(ctx context.Context) ([]Role, error)
+	FindByName(ctx context.Context, name string) (Role, error)
+	// Save creates the role or replaces its permissions.
+	Save(ctx context.Context, role Role) error
+	Delete(ctx context.Context, name string) error
+}
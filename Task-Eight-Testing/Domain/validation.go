@@ -0,0 +1,125 @@
+package Domain
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// FieldError describes what is wrong with one field of a request. Code is
+// stable and machine-readable, such as "too_short".
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationFailed reports every field error of a request at once. The
+// result matches ErrValidationFailed with errors.Is.
+func ValidationFailed(fields ...FieldError) error {
+	return &Error{
+		Kind:    ErrValidationFailed.Kind,
+		Code:    ErrValidationFailed.Code,
+		Message: ErrValidationFailed.Message,
+		Fields:  fields,
+	}
+}
+
+// NormalizeEmail trims the address and lower-cases it so the same mailbox
+// cannot register twice under different spellings.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks that email is a bare address such as
+// "ada@example.com", without a display name.
+func ValidateEmail(email string) *FieldError {
+	if email == "" {
+		return &FieldError{Field: "email", Code: "required", Message: "email is required"}
+	}
+	// The domain must contain a dot; mail.ParseAddress accepts local hosts.
+	addr, err := mail.ParseAddress(email)
+	if err == nil && addr.Name == "" && addr.Address == email {
+		if at := strings.LastIndex(email, "@"); strings.Contains(email[at+1:], ".") {
+			return nil
+		}
+	}
+	return &FieldError{Field: "email", Code: "invalid_email", Message: "email must be a valid address such as name@example.com"}
+}
+
+// PasswordPolicy is what a password must satisfy to be accepted at
+// registration.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Denylist holds lower-cased passwords that are refused however well
+	// they satisfy the other rules.
+	Denylist map[string]bool
+}
+
+// DefaultPasswordPolicy has no denylist; the infrastructure layer supplies
+// one. MaxLength is 72 because bcrypt ignores anything longer.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    72,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+	}
+}
+
+// Check lists every rule password breaks.
+func (p PasswordPolicy) Check(password string) []FieldError {
+	if password == "" {
+		return []FieldError{{Field: "password", Code: "required", Message: "password is required"}}
+	}
+
+	var upper, lower, digit, symbol bool
+	length := 0
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	var problems []FieldError
+	add := func(code, message string) {
+		problems = append(problems, FieldError{Field: "password", Code: code, Message: message})
+	}
+	if length < p.MinLength {
+		add("too_short", fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		add("too_long", fmt.Sprintf("password must be at most %d bytes", p.MaxLength))
+	}
+	if p.RequireUpper && !upper {
+		add("missing_uppercase", "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		add("missing_lowercase", "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add("missing_digit", "password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add("missing_symbol", "password must contain a symbol")
+	}
+	if p.Denylist[strings.ToLower(password)] {
+		add("too_common", "password is too common; choose one that is harder to guess")
+	}
+	return problems
+}
@@ -0,0 +1,60 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// UnverifiedAccess decides what a user who has not verified their email
+// address may do.
+type UnverifiedAccess string
+
+const (
+	// UnverifiedAllowed places no restriction, as does the zero value. It
+	// suits deployments that do not verify addresses.
+	UnverifiedAllowed UnverifiedAccess = "allow"
+	// UnverifiedReadOnly caps the user's permissions at UnverifiedPermissions.
+	UnverifiedReadOnly UnverifiedAccess = "read_only"
+	// UnverifiedBlocked refuses to log the user in.
+	UnverifiedBlocked UnverifiedAccess = "block"
+)
+
+// UnverifiedPermissions are the most an unverified user's role can grant
+// under UnverifiedReadOnly.
+var UnverifiedPermissions = []string{PermTasksRead}
+
+func ParseUnverifiedAccess(value string) (UnverifiedAccess, error) {
+	switch access := UnverifiedAccess(value); access {
+	case UnverifiedAllowed, UnverifiedReadOnly, UnverifiedBlocked:
+		return access, nil
+	}
+	return "", fmt.Errorf("unknown unverified access %q, want %q, %q or %q", value, UnverifiedAllowed, UnverifiedReadOnly, UnverifiedBlocked)
+}
+
+// Restrict narrows the permissions granted to an unverified user.
+func (a UnverifiedAccess) Restrict(granted []string) []string {
+	if a != UnverifiedReadOnly {
+		return granted
+	}
+	restricted := []string{}
+	for _, permission := range granted {
+		if slices.Contains(UnverifiedPermissions, permission) {
+			restricted = append(restricted, permission)
+		}
+	}
+	return restricted
+}
+
+// VerificationClaims identify the account and address a verification link
+// was sent for.
+type VerificationClaims struct {
+	UserID string
+	Email  string
+}
+
+// IVerificationTokenService signs the tokens in email verification links,
+// so they need not be stored.
+type IVerificationTokenService interface {
+	Generate(user User) (string, error)
+	Validate(token string) (VerificationClaims, error)
+}
@@ -0,0 +1,92 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusArchived   = "archived"
+)
+
+// Workflow is the status lifecycle of a task: the status new tasks start in
+// and, for every status, the statuses it may move to. Creatable lists any
+// further statuses a task may be created in, for example to record work
+// that is already under way.
+type Workflow struct {
+	Initial     string
+	Creatable   []string
+	Transitions map[string][]string
+}
+
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Initial: StatusTodo,
+		Transitions: map[string][]string{
+			StatusTodo:       {StatusInProgress},
+			StatusInProgress: {StatusTodo, StatusBlocked, StatusDone},
+			StatusBlocked:    {StatusInProgress},
+			StatusDone:       {StatusInProgress, StatusArchived},
+			StatusArchived:   {},
+		},
+	}
+}
+
+func (w Workflow) IsKnown(status string) bool {
+	_, ok := w.Transitions[status]
+	return ok
+}
+
+// CheckTransition returns nil when a task may move from one status to
+// another. Staying in the same status is always allowed, and tasks whose
+// current status predates the workflow may move to any known status.
+func (w Workflow) CheckTransition(from, to string) error {
+	if !w.IsKnown(to) {
+		return fmt.Errorf("%w: %q", ErrUnknownStatus, to)
+	}
+	if from == to || !w.IsKnown(from) {
+		return nil
+	}
+	for _, next := range w.Transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
+// CheckInitial returns nil when a new task may be created in status.
+func (w Workflow) CheckInitial(status string) error {
+	if !w.IsKnown(status) {
+		return fmt.Errorf("%w: %q", ErrUnknownStatus, status)
+	}
+	if status == w.Initial || slices.Contains(w.Creatable, status) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidInitialStatus, status)
+}
+
+// Validate reports a workflow whose initial status, creatable statuses or
+// transition targets are not themselves statuses of the workflow.
+func (w Workflow) Validate() error {
+	if !w.IsKnown(w.Initial) {
+		return fmt.Errorf("workflow initial status %q is not defined", w.Initial)
+	}
+	for _, status := range w.Creatable {
+		if !w.IsKnown(status) {
+			return fmt.Errorf("workflow creatable status %q is not defined", status)
+		}
+	}
+	for from, targets := range w.Transitions {
+		for _, to := range targets {
+			if !w.IsKnown(to) {
+				return fmt.Errorf("workflow transition %s -> %s targets an undefined status", from, to)
+			}
+		}
+	}
+	return nil
+}
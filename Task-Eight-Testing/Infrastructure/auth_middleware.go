@@ -1,7 +1,11 @@
 package Infrastructure
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"task-manager/Domain"
 
@@ -10,44 +14,147 @@ import (
 
 type AuthMiddleware struct {
 	jwtService Domain.IJWTService
+	users      Domain.IUserRepository
+	roles      Domain.IRoleRepository
+	// Unverified limits users who have not verified their address. It only
+	// applies when the middleware looks users up.
+	Unverified Domain.UnverifiedAccess
+	// Metrics, when set, counts refused access tokens.
+	Metrics Domain.ITokenMetrics
 }
 
+// NewAuthMiddleware grants permissions according to Domain.DefaultRoles.
 func NewAuthMiddleware(jwtService Domain.IJWTService) *AuthMiddleware {
 	return &AuthMiddleware{jwtService: jwtService}
 }
 
+// NewAuthMiddlewareWithStores looks the caller up in users and their role up
+// in roles on every request, so disabling a user or changing their role or
+// its permissions applies from the next request on.
+func NewAuthMiddlewareWithStores(jwtService Domain.IJWTService, users Domain.IUserRepository, roles Domain.IRoleRepository) *AuthMiddleware {
+	return &AuthMiddleware{jwtService: jwtService, users: users, roles: roles}
+}
+
+// permissions resolves a role name to its permissions. Unknown roles have
+// none; admin has every permission whatever is stored.
+func (a *AuthMiddleware) permissions(ctx context.Context, name string) ([]string, error) {
+	if name == Domain.RoleAdmin {
+		return Domain.Permissions, nil
+	}
+	if a.roles == nil {
+		for _, role := range Domain.DefaultRoles() {
+			if role.Name == name {
+				return role.Permissions, nil
+			}
+		}
+		return []string{}, nil
+	}
+
+	role, err := a.roles.FindByName(ctx, name)
+	if errors.Is(err, Domain.ErrRoleNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return role.Permissions, nil
+}
+
+func (a *AuthMiddleware) tokenRejected(reason string) {
+	if a.Metrics != nil {
+		a.Metrics.TokenRejected(reason)
+	}
+}
+
 func (a *AuthMiddleware) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
+			a.tokenRejected("missing")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "missing token"})
 			return
 		}
 
 		authParts := strings.Split(auth, " ")
 		if len(authParts) != 2 || strings.ToLower(authParts[0]) != "bearer" {
+			a.tokenRejected("malformed")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid authorization token"})
 			return
 		}
 
-		claims, err := a.jwtService.ValidateToken(authParts[1])
+		claims, err := a.jwtService.ValidateToken(c.Request.Context(), authParts[1])
 		if err != nil {
+			slog.InfoContext(c.Request.Context(), "access token rejected", "reason", err)
+			a.tokenRejected("invalid")
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unauthorized"})
 			return
 		}
+		AddLogFields(c.Request.Context(), slog.String("email", claims.Email), slog.String("user_id", claims.UserID))
+
+		verified := true
+		if a.users != nil {
+			// Looked up by ID: a token issued to a deleted account must not
+			// pass for whoever registers its email next.
+			user, err := a.users.FindByID(c.Request.Context(), claims.UserID)
+			if errors.Is(err, Domain.ErrUserNotFound) {
+				a.tokenRejected("user_not_found")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unauthorized"})
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "failed to load user", "error", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to load user"})
+				return
+			}
+			if user.Disabled {
+				a.tokenRejected("disabled")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "account disabled"})
+				return
+			}
+			if user.TokenRevoked(claims.Generation) {
+				a.tokenRejected("revoked")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unauthorized"})
+				return
+			}
+			if !user.Verified && a.Unverified == Domain.UnverifiedBlocked {
+				a.tokenRejected("unverified")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "email not verified"})
+				return
+			}
+			claims.Role = user.Role
+			verified = user.Verified
+		}
 
+		permissions, err := a.permissions(c.Request.Context(), claims.Role)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to load permissions", "role", claims.Role, "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to load permissions"})
+			return
+		}
+		if !verified {
+			permissions = a.Unverified.Restrict(permissions)
+		}
+		claims.Permissions = permissions
+
+		c.Set("claims", claims)
+		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("permissions", permissions)
 		c.Next()
 	}
 }
 
-func (a *AuthMiddleware) AdminMiddleware() gin.HandlerFunc {
+// RequirePermission lets a request through only if the caller's role grants
+// every one of permissions. It must run after Middleware.
+func (a *AuthMiddleware) RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unauthorized"})
-			return
+		granted := c.GetStringSlice("permissions")
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unauthorized"})
+				return
+			}
 		}
 		c.Next()
 	}
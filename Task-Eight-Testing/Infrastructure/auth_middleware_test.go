@@ -1,142 +1,392 @@
 package Infrastructure_test
 
 import (
-    "encoding/json"
-    "net/http"
-    "net/http/httptest"
-    "os"
-    "testing"
-    "task-manager/Domain"
-    "task-manager/Infrastructure"
-
-    "github.com/gin-gonic/gin"
-    "github.com/stretchr/testify/assert"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 )
 
 func setupMiddlewareTest(t *testing.T) (*Infrastructure.AuthMiddleware, Domain.IJWTService, func()) {
 	t.Helper()
-	
-    originalKey := os.Getenv("SECRET_KEY")
-    os.Setenv("SECRET_KEY", "test-secret-key-for-middleware-testing")
-	
-    jwtService := Infrastructure.NewJWTService()
-    authMiddleware := Infrastructure.NewAuthMiddleware(jwtService)
-	
-    gin.SetMode(gin.TestMode)
-	
-    cleanup := func() {
-		if originalKey != "" {
-			os.Setenv("SECRET_KEY", originalKey)
-			} else {
-				os.Unsetenv("SECRET_KEY")
-			}
-			gin.SetMode(gin.DebugMode)
-		}
-		
-		return authMiddleware, jwtService, cleanup
+
+	jwtService, err := Infrastructure.NewJWTService("test-secret-key-for-middleware-testing")
+	if err != nil {
+		t.Fatalf("Failed to create JWT service: %v", err)
 	}
-	
-	func createTestRouter(middleware gin.HandlerFunc) *gin.Engine {
-		router := gin.New()
-		router.Use(middleware)
-		router.GET("/protected", func(c *gin.Context) {
-			email, emailExists := c.Get("email")
-			role, roleExists := c.Get("role")
-        c.JSON(http.StatusOK, gin.H{
-            "message":      "success",
-            "email":        email,
-            "role":         role,
-            "email_exists": emailExists,
-            "role_exists":  roleExists,
-        })
-    })
-    return router
+	authMiddleware := Infrastructure.NewAuthMiddleware(jwtService)
+
+	gin.SetMode(gin.TestMode)
+
+	cleanup := func() {
+		gin.SetMode(gin.DebugMode)
+	}
+
+	return authMiddleware, jwtService, cleanup
+}
+
+func createTestRouter(middleware gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware)
+	router.GET("/protected", func(c *gin.Context) {
+		email, emailExists := c.Get("email")
+		role, roleExists := c.Get("role")
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "success",
+			"email":        email,
+			"role":         role,
+			"email_exists": emailExists,
+			"role_exists":  roleExists,
+		})
+	})
+	return router
 }
 
 func TestAuthMiddleware_ValidToken(t *testing.T) {
 	authMiddleware, jwtService, cleanup := setupMiddlewareTest(t)
-    defer cleanup()
-	
-    user := Domain.User{Email: "test@example.com", Role: "user"}
-    token, err := jwtService.GenerateToken(user)
-    assert.NoError(t, err)
-	
-    router := createTestRouter(authMiddleware.Middleware())
-	
-    req := httptest.NewRequest("GET", "/protected", nil)
-    req.Header.Set("Authorization", "Bearer "+token)
-    w := httptest.NewRecorder()
-	
-    router.ServeHTTP(w, req)
-	
-    assert.Equal(t, http.StatusOK, w.Code)
-	
-    var response map[string]interface{}
-    err = json.Unmarshal(w.Body.Bytes(), &response)
-    assert.NoError(t, err)
-	
-    assert.Equal(t, "success", response["message"])
-    assert.Equal(t, user.Email, response["email"])
-    assert.Equal(t, user.Role, response["role"])
-    assert.True(t, response["email_exists"].(bool))
-    assert.True(t, response["role_exists"].(bool))
+	defer cleanup()
+
+	user := Domain.User{ID: "1", Email: "test@example.com", Role: "user"}
+	token, err := jwtService.GenerateToken(user)
+	assert.NoError(t, err)
+
+	router := createTestRouter(authMiddleware.Middleware())
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "success", response["message"])
+	assert.Equal(t, user.Email, response["email"])
+	assert.Equal(t, user.Role, response["role"])
+	assert.True(t, response["email_exists"].(bool))
+	assert.True(t, response["role_exists"].(bool))
 }
 
 func TestAuthMiddleware_MissingToken(t *testing.T) {
 	authMiddleware, _, cleanup := setupMiddlewareTest(t)
-    defer cleanup()
-	
-    router := createTestRouter(authMiddleware.Middleware())
-	
-    req := httptest.NewRequest("GET", "/protected", nil)
-    w := httptest.NewRecorder()
-	
-    router.ServeHTTP(w, req)
-	
-    assert.Equal(t, http.StatusUnauthorized, w.Code)
-	
-    var response map[string]interface{}
-    json.Unmarshal(w.Body.Bytes(), &response)
-    assert.Equal(t, "missing token", response["message"])
-}
+	defer cleanup()
+
+	router := createTestRouter(authMiddleware.Middleware())
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	w := httptest.NewRecorder()
 
+	router.ServeHTTP(w, req)
 
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "missing token", response["message"])
+}
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	authMiddleware, _, cleanup := setupMiddlewareTest(t)
-    defer cleanup()
-	
-    router := createTestRouter(authMiddleware.Middleware())
-	
-    invalidTokens := []string{
+	defer cleanup()
+
+	router := createTestRouter(authMiddleware.Middleware())
+
+	invalidTokens := []string{
 		"invalid.jwt.token",
-        "completely-invalid-token",
-        "",
-        "header.payload",
-    }
-	
-    for _, invalidToken := range invalidTokens {
+		"completely-invalid-token",
+		"",
+		"header.payload",
+	}
+
+	for _, invalidToken := range invalidTokens {
 		limit := min(10, len(invalidToken))
-        t.Run("Token_"+invalidToken[:limit], func(t *testing.T) {
+		t.Run("Token_"+invalidToken[:limit], func(t *testing.T) {
 			req := httptest.NewRequest("GET", "/protected", nil)
-            req.Header.Set("Authorization", "Bearer "+invalidToken)
-            w := httptest.NewRecorder()
-			
-            router.ServeHTTP(w, req)
-			
-            assert.Equal(t, http.StatusForbidden, w.Code)
-			
-            var response map[string]interface{}
-            json.Unmarshal(w.Body.Bytes(), &response)
-            assert.Equal(t, "unauthorized", response["message"])
-        })
-    }
-}
+			req.Header.Set("Authorization", "Bearer "+invalidToken)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
 
+			assert.Equal(t, http.StatusForbidden, w.Code)
+
+			var response map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			assert.Equal(t, "unauthorized", response["message"])
+		})
+	}
+}
 
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+
+type stubRoleRepository struct {
+	roles map[string]Domain.Role
+}
+
+func (s stubRoleRepository) List(ctx context.Context) ([]Domain.Role, error) {
+	return nil, nil
+}
+
+func (s stubRoleRepository) FindByName(ctx context.Context, name string) (Domain.Role, error) {
+	role, ok := s.roles[name]
+	if !ok {
+		return Domain.Role{}, Domain.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (s stubRoleRepository) Save(ctx context.Context, role Domain.Role) error {
+	s.roles[role.Name] = role
+	return nil
+}
+
+func (s stubRoleRepository) Delete(ctx context.Context, name string) error {
+	delete(s.roles, name)
+	return nil
+}
+
+func TestRequirePermission_UsesStoredRoles(t *testing.T) {
+	_, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	roles := stubRoleRepository{roles: map[string]Domain.Role{
+		"auditor": {Name: "auditor", Permissions: []string{Domain.PermAuditRead}},
+	}}
+	authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, nil, roles)
+
+	router := gin.New()
+	router.GET("/audit", authMiddleware.Middleware(), authMiddleware.RequirePermission(Domain.PermAuditRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(role string) int {
+		token, err := jwtService.GenerateToken(Domain.User{ID: role, Email: role + "@example.com", Role: role})
+		assert.NoError(t, err)
+		req := httptest.NewRequest("GET", "/audit", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("auditor"))
+	assert.Equal(t, http.StatusForbidden, request("unknown"))
+	assert.Equal(t, http.StatusOK, request(Domain.RoleAdmin))
+
+	_ = roles.Save(context.Background(), Domain.Role{Name: "auditor"})
+	assert.Equal(t, http.StatusForbidden, request("auditor"))
+}
+
+func TestRequirePermission_DefaultRoles(t *testing.T) {
+	authMiddleware, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.DELETE("/tasks/1", authMiddleware.Middleware(), authMiddleware.RequirePermission(Domain.PermTasksDelete), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for role, status := range map[string]int{
+		Domain.RoleViewer: http.StatusForbidden,
+		Domain.RoleMember: http.StatusOK,
+	} {
+		token, err := jwtService.GenerateToken(Domain.User{ID: "someone", Email: "someone@example.com", Role: role})
+		assert.NoError(t, err)
+		req := httptest.NewRequest("DELETE", "/tasks/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code, role)
+	}
+}
+
+func TestAuthMiddleware_RejectsDisabledAndDeletedUsers(t *testing.T) {
+	_, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	users := Repositories.NewMemoryUserRepository()
+	_, _ = users.Create(context.Background(), Domain.User{Email: "admin@example.com"})
+	member, _ := users.Create(context.Background(), Domain.User{Email: "member@example.com"})
+	authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, users, nil)
+	router := createTestRouter(authMiddleware.Middleware())
+
+	// The token claims admin, but the stored role is what counts.
+	token, err := jwtService.GenerateToken(Domain.User{ID: member.ID, Email: member.Email, Role: Domain.RoleAdmin})
+	assert.NoError(t, err)
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request()
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, Domain.DefaultRole, response["role"])
+
+	_, _ = users.SetDisabled(context.Background(), member.ID, true)
+	w = request()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "account disabled", response["message"])
+
+	_ = users.Delete(context.Background(), member.ID)
+	assert.Equal(t, http.StatusForbidden, request().Code)
+
+	// Someone registering the deleted address gets a new account; the old
+	// token does not carry over to it.
+	_, _ = users.Create(context.Background(), Domain.User{Email: member.Email})
+	assert.Equal(t, http.StatusForbidden, request().Code)
+}
+
+func TestAuthMiddleware_RejectsTokensIssuedBeforeRevocation(t *testing.T) {
+	_, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	users := Repositories.NewMemoryUserRepository()
+	user, _ := users.Create(context.Background(), Domain.User{Email: "admin@example.com", Verified: true})
+	authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, users, nil)
+	router := createTestRouter(authMiddleware.Middleware())
+
+	token, err := jwtService.GenerateToken(user)
+	assert.NoError(t, err)
+	_, _ = users.NextTokenGeneration(context.Background(), user.ID)
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAuthMiddleware_RestrictsUnverifiedUsers(t *testing.T) {
+	_, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	users := Repositories.NewMemoryUserRepository()
+	_, _ = users.Create(context.Background(), Domain.User{Email: "admin@example.com", Verified: true})
+	member, _ := users.Create(context.Background(), Domain.User{Email: "member@example.com"})
+	token, err := jwtService.GenerateToken(member)
+	assert.NoError(t, err)
+
+	request := func(access Domain.UnverifiedAccess, method string) int {
+		authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, users, nil)
+		authMiddleware.Unverified = access
+		router := gin.New()
+		router.GET("/tasks", authMiddleware.Middleware(), authMiddleware.RequirePermission(Domain.PermTasksRead), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		router.POST("/tasks", authMiddleware.Middleware(), authMiddleware.RequirePermission(Domain.PermTasksWrite), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(method, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(Domain.UnverifiedAllowed, "POST"))
+	assert.Equal(t, http.StatusOK, request(Domain.UnverifiedReadOnly, "GET"))
+	assert.Equal(t, http.StatusForbidden, request(Domain.UnverifiedReadOnly, "POST"))
+	assert.Equal(t, http.StatusForbidden, request(Domain.UnverifiedBlocked, "GET"))
+
+	_, _ = users.SetVerified(context.Background(), member.ID, true)
+	assert.Equal(t, http.StatusOK, request(Domain.UnverifiedBlocked, "POST"))
+}
+
+type recordingTokenMetrics struct {
+	reasons []string
+}
+
+func (m *recordingTokenMetrics) TokenRejected(reason string) {
+	m.reasons = append(m.reasons, reason)
+}
+
+func TestAuthMiddleware_CountsEveryRejection(t *testing.T) {
+	_, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	users := Repositories.NewMemoryUserRepository()
+	_, _ = users.Create(context.Background(), Domain.User{Email: "admin@example.com", Verified: true})
+	member, _ := users.Create(context.Background(), Domain.User{Email: "member@example.com"})
+	token, err := jwtService.GenerateToken(member)
+	assert.NoError(t, err)
+
+	metrics := &recordingTokenMetrics{}
+	authMiddleware := Infrastructure.NewAuthMiddlewareWithStores(jwtService, users, nil)
+	authMiddleware.Unverified = Domain.UnverifiedBlocked
+	authMiddleware.Metrics = metrics
+	router := createTestRouter(authMiddleware.Middleware())
+	request := func(header string) {
+		req := httptest.NewRequest("GET", "/protected", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.NotEqual(t, http.StatusOK, w.Code, header)
+	}
+
+	request("")
+	request("Token " + token)
+	request("Bearer not-a-token")
+	request("Bearer " + token)
+
+	_, _ = users.SetVerified(context.Background(), member.ID, true)
+	_, _ = users.SetDisabled(context.Background(), member.ID, true)
+	request("Bearer " + token)
+
+	_, _ = users.SetDisabled(context.Background(), member.ID, false)
+	_, _ = users.NextTokenGeneration(context.Background(), member.ID)
+	request("Bearer " + token)
+
+	_ = users.Delete(context.Background(), member.ID)
+	request("Bearer " + token)
+
+	assert.Equal(t, []string{
+		"missing", "malformed", "invalid", "unverified", "disabled", "revoked", "user_not_found",
+	}, metrics.reasons)
+}
+
+func TestAuthMiddleware_LogsTheCallerWithoutTheirAddress(t *testing.T) {
+	authMiddleware, jwtService, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+	buf := useLogger(t, slog.LevelInfo)
+
+	router := gin.New()
+	router.Use(Infrastructure.RequestLogger(), authMiddleware.Middleware())
+	router.GET("/protected", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	token, err := jwtService.GenerateToken(Domain.User{ID: "64b7f0c2e4b0a1a2b3c4d5e6", Email: "ada@example.com", Role: Domain.RoleMember})
+	assert.NoError(t, err)
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NotContains(t, buf.String(), "ada@example.com")
+	lines := logLines(t, buf)
+	if assert.Len(t, lines, 1) {
+		assert.Equal(t, "64b7f0c2e4b0a1a2b3c4d5e6", lines[0]["user_id"])
+		assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, lines[0]["email"])
+	}
+}
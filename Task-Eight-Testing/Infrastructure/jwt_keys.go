@@ -0,0 +1,294 @@
+package Infrastructure
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Signing algorithms a key set may use.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is one entry of a KeySet. Tokens carry its ID in their kid
+// header so validators know which key to check them with.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	// VerifyUntil ends the grace period of a retired key; tokens signed with
+	// it are refused afterwards. The zero value keeps it until it is removed
+	// from the set.
+	VerifyUntil time.Time
+
+	private interface{}
+	public  interface{}
+}
+
+// NewHMACKey returns an HS256 key. The secret is shared by everyone who
+// validates its tokens, so HS256 keys are never published.
+func NewHMACKey(id string, secret []byte) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgorithmHS256, private: secret, public: secret}
+}
+
+// NewRSAKey returns an RS256 key.
+func NewRSAKey(id string, key *rsa.PrivateKey) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgorithmRS256, private: key, public: &key.PublicKey}
+}
+
+// NewEd25519Key returns an EdDSA key.
+func NewEd25519Key(id string, key ed25519.PrivateKey) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgorithmEdDSA, private: key, public: key.Public()}
+}
+
+func (k SigningKey) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func (k SigningKey) usable(now time.Time) bool {
+	return k.VerifyUntil.IsZero() || now.Before(k.VerifyUntil)
+}
+
+// KeySet signs new tokens with its active key and still accepts tokens
+// signed with the retired keys, so rotating keys does not sign everyone out.
+type KeySet struct {
+	active  SigningKey
+	retired []SigningKey
+}
+
+// NewKeySet checks that every key has a unique ID and a supported algorithm.
+func NewKeySet(active SigningKey, retired ...SigningKey) (*KeySet, error) {
+	seen := map[string]bool{}
+	for _, key := range append([]SigningKey{active}, retired...) {
+		if key.ID == "" {
+			return nil, errors.New("signing key has no kid")
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		seen[key.ID] = true
+		if key.private == nil {
+			return nil, fmt.Errorf("signing key %q has no key material", key.ID)
+		}
+		switch key.Algorithm {
+		case AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA:
+		default:
+			return nil, fmt.Errorf("signing key %q: unsupported algorithm %q", key.ID, key.Algorithm)
+		}
+	}
+	return &KeySet{active: active, retired: retired}, nil
+}
+
+// DeriveSecret returns a secret for purpose derived from the active key,
+// for services that need to sign something other than tokens. It changes
+// when the active key is rotated.
+func (s *KeySet) DeriveSecret(purpose string) string {
+	var material []byte
+	switch key := s.active.private.(type) {
+	case []byte:
+		material = key
+	case ed25519.PrivateKey:
+		material = key
+	case *rsa.PrivateKey:
+		material = x509.MarshalPKCS1PrivateKey(key)
+	}
+	mac := hmac.New(sha256.New, material)
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// keys returns the active key followed by the retired keys still in their
+// grace period.
+func (s *KeySet) keys(now time.Time) []SigningKey {
+	keys := []SigningKey{s.active}
+	for _, key := range s.retired {
+		if key.usable(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// algorithms lists the algorithms of the usable keys. Tokens using any
+// other algorithm are refused before their signature is looked at.
+func (s *KeySet) algorithms(now time.Time) []string {
+	var algs []string
+	seen := map[string]bool{}
+	for _, key := range s.keys(now) {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	return algs
+}
+
+// verificationKey finds the key a token was signed with. Tokens issued
+// before key IDs were introduced have no kid; they are checked against the
+// set's HS256 key when it has exactly one.
+func (s *KeySet) verificationKey(token *jwt.Token, now time.Time) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var match *SigningKey
+	for _, key := range s.keys(now) {
+		key := key
+		if kid == "" && key.Algorithm == AlgorithmHS256 {
+			if match != nil {
+				return nil, errors.New("token has no kid")
+			}
+			match = &key
+		} else if kid != "" && key.ID == kid {
+			match = &key
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	// Pin the algorithm to the key so that, for example, an RSA public key
+	// can never be used as an HMAC secret.
+	if token.Method.Alg() != match.Algorithm {
+		return nil, fmt.Errorf("signing key %q does not use %s", match.ID, token.Method.Alg())
+	}
+	return match.public, nil
+}
+
+// PublicKeys returns the asymmetric keys tokens may currently be signed
+// with, for other services to verify them.
+func (s *KeySet) PublicKeys() []Domain.PublicKey {
+	var keys []Domain.PublicKey
+	for _, key := range s.keys(time.Now()) {
+		if key.Algorithm == AlgorithmHS256 {
+			continue
+		}
+		keys = append(keys, Domain.PublicKey{ID: key.ID, Algorithm: key.Algorithm, Key: key.public})
+	}
+	return keys
+}
+
+// keySetFile is the on-disk form of a key set, for example:
+//
+//	{
+//	  "active": "2026-10",
+//	  "keys": [
+//	    {"kid": "2026-10", "alg": "EdDSA", "key_file": "/etc/task-manager/keys/2026-10.pem"},
+//	    {"kid": "2026-07", "alg": "RS256", "key_file": "/etc/task-manager/keys/2026-07.pem", "verify_until": "2026-10-25T00:00:00Z"}
+//	  ]
+//	}
+type keySetFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID          string    `json:"kid"`
+		Algorithm   string    `json:"alg"`
+		KeyFile     string    `json:"key_file"`
+		VerifyUntil time.Time `json:"verify_until"`
+	} `json:"keys"`
+}
+
+// LoadKeySet reads a key set from a JSON file. Every key other than the
+// active one is retired. The key files hold a PEM private key for RS256
+// (PKCS #1 or PKCS #8, as written by "openssl genrsa") and EdDSA (PKCS #8,
+// as written by "openssl genpkey -algorithm ed25519"), and the raw secret
+// for HS256.
+func LoadKeySet(path string) (*KeySet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keySetFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+
+	var active *SigningKey
+	var retired []SigningKey
+	for _, entry := range file.Keys {
+		material, err := os.ReadFile(entry.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseSigningKey(entry.ID, entry.Algorithm, material)
+		if err != nil {
+			return nil, err
+		}
+		if entry.ID == file.Active {
+			active = &key
+			continue
+		}
+		key.VerifyUntil = entry.VerifyUntil
+		retired = append(retired, key)
+	}
+	if active == nil {
+		return nil, fmt.Errorf("active signing key %q is not in %s", file.Active, path)
+	}
+	return NewKeySet(*active, retired...)
+}
+
+func parseSigningKey(id, algorithm string, material []byte) (SigningKey, error) {
+	if algorithm == AlgorithmHS256 {
+		secret := []byte(strings.TrimSpace(string(material)))
+		if len(secret) < 32 {
+			return SigningKey{}, fmt.Errorf("signing key %q: HS256 secrets need at least 32 bytes", id)
+		}
+		return NewHMACKey(id, secret), nil
+	}
+
+	block, _ := pem.Decode(material)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("signing key %q: no PEM data found", id)
+	}
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("signing key %q: %w", id, err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != AlgorithmRS256 {
+			break
+		}
+		if key.N.BitLen() < 2048 {
+			return SigningKey{}, fmt.Errorf("signing key %q: RSA keys need at least 2048 bits", id)
+		}
+		return NewRSAKey(id, key), nil
+	case ed25519.PrivateKey:
+		if algorithm == AlgorithmEdDSA {
+			return NewEd25519Key(id, key), nil
+		}
+	}
+	return SigningKey{}, fmt.Errorf("signing key %q: key file does not hold a %s key", id, algorithm)
+}
+
+// OpenKeySet loads the key set in keysFile. Without one, tokens are signed
+// with secretKey alone, as they were before key rotation.
+func OpenKeySet(keysFile, secretKey string) (*KeySet, error) {
+	if keysFile != "" {
+		return LoadKeySet(keysFile)
+	}
+	if secretKey == "" {
+		return nil, errors.New("no secret key or keys file to sign tokens with")
+	}
+	return NewKeySet(NewHMACKey("default", []byte(secretKey)))
+}
@@ -0,0 +1,176 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEd25519Key(t *testing.T, id string) Infrastructure.SigningKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return Infrastructure.NewEd25519Key(id, private)
+}
+
+func newKeyService(t *testing.T, active Infrastructure.SigningKey, retired ...Infrastructure.SigningKey) *Infrastructure.JWTService {
+	t.Helper()
+	keys, err := Infrastructure.NewKeySet(active, retired...)
+	if err != nil {
+		t.Fatalf("failed to build key set: %v", err)
+	}
+	return Infrastructure.NewJWTServiceWithKeys(keys, Infrastructure.NewInMemoryRevocationStore())
+}
+
+func TestJWTService_ValidatesTokensFromRetiredKeys(t *testing.T) {
+	oldKey := newEd25519Key(t, "2026-07")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	newKey := Infrastructure.NewRSAKey("2026-10", rsaKey)
+
+	before := newKeyService(t, oldKey)
+	oldToken, err := before.GenerateToken(createTestUser())
+	assert.NoError(t, err)
+
+	after := newKeyService(t, newKey, oldKey)
+	claims, err := after.ValidateToken(context.Background(), oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+
+	newToken, err := after.GenerateToken(createTestUser())
+	assert.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-10", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+
+	_, err = before.ValidateToken(context.Background(), newToken)
+	assert.Error(t, err, "a key the validator does not know is refused")
+}
+
+func TestJWTService_RefusesKeysPastTheirGracePeriod(t *testing.T) {
+	oldKey := newEd25519Key(t, "old")
+	oldToken, err := newKeyService(t, oldKey).GenerateToken(createTestUser())
+	assert.NoError(t, err)
+
+	oldKey.VerifyUntil = time.Now().Add(-time.Minute)
+	service := newKeyService(t, newEd25519Key(t, "new"), oldKey)
+
+	_, err = service.ValidateToken(context.Background(), oldToken)
+	assert.Error(t, err)
+	assert.Len(t, service.PublicKeys(), 1, "expired keys are no longer published")
+}
+
+func TestJWTService_PinsAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	service := newKeyService(t, Infrastructure.NewRSAKey("rsa", rsaKey))
+
+	claims := jwt.MapClaims{"email": "mallory@example.com", "typ": Domain.AccessToken, "exp": time.Now().Add(time.Hour).Unix()}
+
+	// Signing with the published public key as an HMAC secret must not work.
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "rsa"
+	forgedToken, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	assert.NoError(t, err)
+	_, err = service.ValidateToken(context.Background(), forgedToken)
+	assert.Error(t, err)
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	unsigned.Header["kid"] = "rsa"
+	unsignedToken, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+	_, err = service.ValidateToken(context.Background(), unsignedToken)
+	assert.Error(t, err)
+}
+
+func TestJWTService_AcceptsTokensWithoutKid(t *testing.T) {
+	secret := []byte("test-secret-key-for-testing-123")
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "64b7f0c2e4b0a1a2b3c4d5e6",
+		"email": "test@example.com",
+		"typ":   Domain.AccessToken,
+		"jti":   "legacy",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	token, err := legacy.SignedString(secret)
+	assert.NoError(t, err)
+
+	service := newKeyService(t, newEd25519Key(t, "ed"), Infrastructure.NewHMACKey("default", secret))
+	claims, err := service.ValidateToken(context.Background(), token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	edFile := write("ed.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}))
+	rsaFile := write("rsa.pem", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	secretFile := write("secret", []byte("an-hs256-secret-of-at-least-32-bytes\n"))
+	path := write("keys.json", []byte(`{"active": "ed", "keys": [
+		{"kid": "ed", "alg": "EdDSA", "key_file": "`+edFile+`"},
+		{"kid": "rsa", "alg": "RS256", "key_file": "`+rsaFile+`", "verify_until": "2999-01-01T00:00:00Z"},
+		{"kid": "hmac", "alg": "HS256", "key_file": "`+secretFile+`"}
+	]}`))
+
+	keys, err := Infrastructure.LoadKeySet(path)
+
+	assert.NoError(t, err)
+	var ids []string
+	for _, key := range keys.PublicKeys() {
+		ids = append(ids, key.ID)
+	}
+	assert.Equal(t, []string{"ed", "rsa"}, ids, "HS256 secrets are never published")
+
+	mismatched := write("mismatched.json", []byte(`{"active": "ed", "keys": [{"kid": "ed", "alg": "RS256", "key_file": "`+edFile+`"}]}`))
+	_, err = Infrastructure.LoadKeySet(mismatched)
+	assert.Error(t, err)
+
+	noActive := write("no-active.json", []byte(`{"active": "missing", "keys": [{"kid": "ed", "alg": "EdDSA", "key_file": "`+edFile+`"}]}`))
+	_, err = Infrastructure.LoadKeySet(noActive)
+	assert.Error(t, err)
+}
+
+func TestJWTService_DeriveSecret_FollowsTheActiveKey(t *testing.T) {
+	first := newEd25519Key(t, "first")
+	service := newKeyService(t, first)
+
+	secret := service.DeriveSecret("email verification")
+	assert.Len(t, secret, 64)
+	assert.Equal(t, secret, newKeyService(t, first).DeriveSecret("email verification"))
+	assert.NotEqual(t, secret, service.DeriveSecret("something else"))
+	assert.NotEqual(t, secret, newKeyService(t, newEd25519Key(t, "second"), first).DeriveSecret("email verification"))
+}
@@ -1,48 +1,109 @@
 package Infrastructure
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"os"
 	"task-manager/Domain"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type jwtCustomClaims struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	Type  string `json:"typ"`
+	// Generation is left out until the user's tokens are first revoked.
+	Generation int `json:"gen,omitempty"`
 	jwt.StandardClaims
 }
 
 type JWTService struct {
-	secretKey string
+	keys       *KeySet
+	store      Domain.ITokenRevocationStore
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
 }
 
-func NewJWTService() Domain.IJWTService {
-	secretKey := os.Getenv("SECRET_KEY")
-	if secretKey == "" {
-		panic("SECRET_KEY not set in env")
+// NewJWTService signs tokens with secretKey and remembers revoked tokens in
+// memory.
+func NewJWTService(secretKey string) (*JWTService, error) {
+	return NewJWTServiceWithStore("", secretKey, NewInMemoryRevocationStore())
+}
+
+// NewJWTServiceWithStore signs tokens with the keys in keysFile or, without
+// one, with secretKey, and records revoked tokens in store.
+func NewJWTServiceWithStore(keysFile, secretKey string, store Domain.ITokenRevocationStore) (*JWTService, error) {
+	keys, err := OpenKeySet(keysFile, secretKey)
+	if err != nil {
+		return nil, err
 	}
-	return &JWTService{secretKey: secretKey}
+	return NewJWTServiceWithKeys(keys, store), nil
 }
 
-func (j *JWTService) GenerateToken(user Domain.User) (string, error) {
+// NewJWTServiceWithKeys signs tokens with the active key of keys.
+func NewJWTServiceWithKeys(keys *KeySet, store Domain.ITokenRevocationStore) *JWTService {
+	return &JWTService{
+		keys:       keys,
+		store:      store,
+		AccessTTL:  DefaultAccessTokenTTL,
+		RefreshTTL: DefaultRefreshTokenTTL,
+	}
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (j *JWTService) generate(user Domain.User, tokenType string, ttl time.Duration) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwtCustomClaims{
-		Email: user.Email,
-		Role:  user.Role,
+		Email:      user.Email,
+		Role:       user.Role,
+		Type:       tokenType,
+		Generation: user.TokenGeneration,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
-			Subject:   user.Email,
+			Id:        id,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			Subject:   user.ID,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	key := j.keys.active
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
 }
 
-func (j *JWTService) ValidateToken(tokenStr string) (*Domain.AuthClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &jwtCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(j.secretKey), nil
+func (j *JWTService) GenerateToken(user Domain.User) (string, error) {
+	return j.generate(user, Domain.AccessToken, j.AccessTTL)
+}
+
+func (j *JWTService) GenerateRefreshToken(user Domain.User) (string, error) {
+	return j.generate(user, Domain.RefreshToken, j.RefreshTTL)
+}
+
+func (j *JWTService) validate(ctx context.Context, tokenStr, tokenType string) (*Domain.AuthClaims, error) {
+	now := time.Now()
+	parser := jwt.Parser{ValidMethods: j.keys.algorithms(now)}
+	token, err := parser.ParseWithClaims(tokenStr, &jwtCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return j.keys.verificationKey(token, now)
 	})
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid or expired token")
@@ -52,9 +113,63 @@ func (j *JWTService) ValidateToken(tokenStr string) (*Domain.AuthClaims, error)
 	if !ok {
 		return nil, errors.New("invalid token claims")
 	}
+	if claims.Type != tokenType {
+		return nil, errors.New("invalid token type")
+	}
+	// Tokens name their user by ID. Older ones named the email instead;
+	// they are refused rather than trusted to mean the same account.
+	if claims.Subject == "" || claims.Subject == claims.Email {
+		return nil, errors.New("invalid token subject")
+	}
+
+	revoked, err := j.store.IsRevoked(ctx, claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("invalid token: revoked")
+	}
 
 	return &Domain.AuthClaims{
-		Email: claims.Email,
-		Role:  claims.Role,
+		ID:         claims.Id,
+		UserID:     claims.Subject,
+		Email:      claims.Email,
+		Role:       claims.Role,
+		Type:       claims.Type,
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0),
+		Generation: claims.Generation,
 	}, nil
 }
+
+func (j *JWTService) ValidateToken(ctx context.Context, tokenStr string) (*Domain.AuthClaims, error) {
+	return j.validate(ctx, tokenStr, Domain.AccessToken)
+}
+
+func (j *JWTService) ValidateRefreshToken(ctx context.Context, tokenStr string) (*Domain.AuthClaims, error) {
+	return j.validate(ctx, tokenStr, Domain.RefreshToken)
+}
+
+// DeriveSecret returns a secret for purpose derived from the active signing
+// key; see KeySet.DeriveSecret.
+func (j *JWTService) DeriveSecret(purpose string) string {
+	return j.keys.DeriveSecret(purpose)
+}
+
+// PublicKeys returns the keys other services need to verify tokens.
+func (j *JWTService) PublicKeys() []Domain.PublicKey {
+	return j.keys.PublicKeys()
+}
+
+func (j *JWTService) RevokeToken(ctx context.Context, claims *Domain.AuthClaims) error {
+	if claims == nil || claims.ID == "" {
+		return errors.New("token has no id")
+	}
+	return j.store.Revoke(ctx, claims.ID, claims.ExpiresAt)
+}
+
+func (j *JWTService) RevokeTokenOnce(ctx context.Context, claims *Domain.AuthClaims) error {
+	if claims == nil || claims.ID == "" {
+		return errors.New("token has no id")
+	}
+	return j.store.RevokeOnce(ctx, claims.ID, claims.ExpiresAt)
+}
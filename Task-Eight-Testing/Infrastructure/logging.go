@@ -0,0 +1,195 @@
+package Infrastructure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// Request IDs from callers are kept only if they look like one, so they
+// cannot be used to inject arbitrary text into the logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Attributes whose key contains one of these are never written out.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+// Attributes whose key contains one of these are logged as a hash of the
+// normalized value, and email addresses are only ever logged under such a
+// key. Addresses typed at login may belong to nobody, or even be a password
+// typed into the wrong field, but lines about the same address can still be
+// matched up.
+var hashedKeys = []string{"email"}
+
+// Values that look like a JWT or a bearer credential are masked wherever
+// they turn up, for example inside an error message.
+var credentialPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*|(?i:bearer\s+)\S+`)
+
+const redacted = "[REDACTED]"
+
+// NewLogger returns a JSON logger writing lines of at least level to w.
+// Lines logged with a request's context carry the fields added by
+// RequestLogger and AddLogFields.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})})
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	if value, ok := a.Value.Any().(string); ok {
+		for _, hashed := range hashedKeys {
+			if strings.Contains(key, hashed) {
+				return slog.String(a.Key, hashForLog(Domain.NormalizeEmail(value)))
+			}
+		}
+	}
+
+	switch value := a.Value.Any().(type) {
+	case string:
+		return slog.String(a.Key, credentialPattern.ReplaceAllString(value, redacted))
+	case error:
+		return slog.String(a.Key, credentialPattern.ReplaceAllString(value.Error(), redacted))
+	}
+	return a
+}
+
+func hashForLog(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+type logFieldsKey struct{}
+
+// logFields is shared by every context derived from a request, so fields
+// added deep in the handler chain also appear on the final request line.
+type logFields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func (f *logFields) snapshot() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+// WithLogFields returns a context whose log lines carry attrs on top of the
+// fields already in ctx.
+func WithLogFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	fields := &logFields{}
+	if parent, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		fields.attrs = parent.snapshot()
+	}
+	fields.attrs = append(fields.attrs, attrs...)
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// AddLogFields adds attrs to the fields of the request ctx belongs to. It
+// does nothing for contexts without fields.
+func AddLogFields(ctx context.Context, attrs ...slog.Attr) {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		fields.mu.Lock()
+		fields.attrs = append(fields.attrs, attrs...)
+		fields.mu.Unlock()
+	}
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		r.AddAttrs(fields.snapshot()...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// RequestLogger gives each request an ID, keeping a well-formed one sent by
+// the caller or a proxy, and returns it in the X-Request-ID header. Log
+// lines written with the request's context carry the ID, method and route
+// template; the raw path and query are left out since they can hold
+// tokens. The request itself is logged when it completes.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			var err error
+			if id, err = newTokenID(); err != nil {
+				id = "unknown"
+			}
+		}
+		c.Header(RequestIDHeader, id)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := WithLogFields(c.Request.Context(),
+			slog.String("request_id", id),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+		)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		slog.Default().LogAttrs(ctx, level, "request completed",
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		)
+	}
+}
+
+// Recovery turns a panic into a 500 response and logs it, with its stack,
+// through the request's logger.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "panic serving request",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "code": "internal_error"})
+	})
+}
@@ -0,0 +1,68 @@
+package Infrastructure
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"time"
+)
+
+type loginAttemptRecord struct {
+	attempts  Domain.LoginAttempts
+	expiresAt time.Time
+}
+
+// InMemoryLoginAttemptStore keeps failed login counts in process memory. Like
+// InMemoryRevocationStore it suits a single instance; counts are lost on
+// restart.
+type InMemoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	records map[string]loginAttemptRecord
+	now     func() time.Time
+}
+
+func NewInMemoryLoginAttemptStore() Domain.ILoginAttemptStore {
+	return &InMemoryLoginAttemptStore{
+		records: make(map[string]loginAttemptRecord),
+		now:     time.Now,
+	}
+}
+
+func (s *InMemoryLoginAttemptStore) Get(ctx context.Context, key string) (Domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || !s.now().Before(record.expiresAt) {
+		return Domain.LoginAttempts{}, nil
+	}
+	return record.attempts, nil
+}
+
+// RecordFailure also drops expired records so the map does not grow with
+// every address that ever failed to log in.
+func (s *InMemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (Domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, record := range s.records {
+		if !at.Before(record.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+
+	record := s.records[key]
+	record.attempts.Failures++
+	record.attempts.LastFailure = at
+	record.expiresAt = at.Add(window)
+	s.records[key] = record
+	return record.attempts, nil
+}
+
+func (s *InMemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
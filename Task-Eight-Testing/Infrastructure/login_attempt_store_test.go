@@ -0,0 +1,49 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryLoginAttemptStore_CountsWithinWindow(t *testing.T) {
+	store := Infrastructure.NewInMemoryLoginAttemptStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	attempts, err := store.Get(ctx, "account:ada@example.com")
+	assert.NoError(t, err)
+	assert.Zero(t, attempts.Failures)
+
+	_, _ = store.RecordFailure(ctx, "account:ada@example.com", now.Add(-time.Second), time.Hour)
+	attempts, err = store.RecordFailure(ctx, "account:ada@example.com", now, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+	assert.True(t, now.Equal(attempts.LastFailure))
+
+	attempts, _ = store.Get(ctx, "account:ada@example.com")
+	assert.Equal(t, 2, attempts.Failures)
+
+	assert.NoError(t, store.Reset(ctx, "account:ada@example.com"))
+	attempts, _ = store.Get(ctx, "account:ada@example.com")
+	assert.Zero(t, attempts.Failures)
+}
+
+func TestInMemoryLoginAttemptStore_ForgetsOldFailures(t *testing.T) {
+	store := Infrastructure.NewInMemoryLoginAttemptStore()
+	ctx := context.Background()
+	longAgo := time.Now().Add(-2 * time.Hour)
+
+	_, _ = store.RecordFailure(ctx, "ip:192.0.2.1", longAgo, time.Hour)
+	_, _ = store.RecordFailure(ctx, "ip:192.0.2.1", longAgo, time.Hour)
+
+	attempts, _ := store.Get(ctx, "ip:192.0.2.1")
+	assert.Zero(t, attempts.Failures)
+
+	attempts, _ = store.RecordFailure(ctx, "ip:192.0.2.1", time.Now(), time.Hour)
+	assert.Equal(t, 1, attempts.Failures, "counting starts over after the window")
+}
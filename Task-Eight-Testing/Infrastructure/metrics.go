@@ -0,0 +1,116 @@
+package Infrastructure
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics collects the server's metrics in its own Prometheus registry and
+// serves them for scraping. It is safe for concurrent use.
+type Metrics struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	repoDuration    *prometheus.HistogramVec
+	repoErrors      *prometheus.CounterVec
+	loginSuccesses  prometheus.Counter
+	loginFailures   *prometheus.CounterVec
+	tokenRejections *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled, by route template and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "http_request_duration_seconds",
+			Help: "Time taken to handle HTTP requests, by route template and status.",
+		}, []string{"method", "route", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "repository_operation_duration_seconds",
+			Help: "Time taken by repository calls, by repository and method.",
+		}, []string{"repository", "method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "repository_operation_errors_total",
+			Help: "Repository calls that failed, by repository and method.",
+		}, []string{"repository", "method"}),
+		loginSuccesses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth_login_success_total",
+			Help: "Logins that issued tokens.",
+		}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_login_failures_total",
+			Help: "Logins refused, by error code.",
+		}, []string{"reason"}),
+		tokenRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_token_validation_failures_total",
+			Help: "Requests whose access token was refused, by reason.",
+		}, []string{"reason"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.loginSuccesses,
+		m.loginFailures,
+		m.tokenRejections,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return m
+}
+
+var (
+	_ Domain.IMetrics           = (*Metrics)(nil)
+	_ Domain.IRepositoryMetrics = (*Metrics)(nil)
+	_ Domain.ILoginMetrics      = (*Metrics)(nil)
+	_ Domain.ITokenMetrics      = (*Metrics)(nil)
+)
+
+func (m *Metrics) ObserveRequest(method, route string, status int, took time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	m.requests.With(labels).Inc()
+	m.requestDuration.With(labels).Observe(took.Seconds())
+}
+
+// ObserveOperation counts err as a failure unless it is a domain error: a
+// task that does not exist is an answer, not a fault in the repository.
+func (m *Metrics) ObserveOperation(repository, method string, took time.Duration, err error) {
+	m.repoDuration.WithLabelValues(repository, method).Observe(took.Seconds())
+	var domainErr *Domain.Error
+	if err != nil && !errors.As(err, &domainErr) {
+		m.repoErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+func (m *Metrics) LoginSucceeded() {
+	m.loginSuccesses.Inc()
+}
+
+func (m *Metrics) LoginFailed(reason string) {
+	m.loginFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) TokenRejected(reason string) {
+	m.tokenRejections.WithLabelValues(reason).Inc()
+}
+
+// Handler serves every metric in whichever exposition format the scraper
+// asks for.
+func (m *Metrics) Handler() http.Handler {
+	return m.handler
+}
@@ -0,0 +1,73 @@
+package Infrastructure_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMetrics(t *testing.T, m *Infrastructure.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMetrics_TextFormat(t *testing.T) {
+	m := Infrastructure.NewMetrics()
+	m.ObserveRequest("GET", "/tasks/:id", 200, 30*time.Millisecond)
+	m.ObserveRequest("GET", "/tasks/:id", 200, 2*time.Second)
+	m.ObserveRequest("GET", "/tasks/:id", 404, time.Millisecond)
+
+	out := writeMetrics(t, m)
+	for _, want := range []string{
+		"# TYPE http_requests_total counter\n",
+		`http_requests_total{method="GET",route="/tasks/:id",status="200"} 2` + "\n",
+		`http_requests_total{method="GET",route="/tasks/:id",status="404"} 1` + "\n",
+		"# TYPE http_request_duration_seconds histogram\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/tasks/:id",status="200",le="0.025"} 0` + "\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/tasks/:id",status="200",le="0.05"} 1` + "\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/tasks/:id",status="200",le="2.5"} 2` + "\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/tasks/:id",status="200",le="+Inf"} 2` + "\n",
+		`http_request_duration_seconds_sum{method="GET",route="/tasks/:id",status="200"} 2.03` + "\n",
+		`http_request_duration_seconds_count{method="GET",route="/tasks/:id",status="200"} 2` + "\n",
+		"auth_login_success_total 0\n",
+		"# TYPE go_goroutines gauge\n",
+	} {
+		assert.Contains(t, out, want)
+	}
+}
+
+func TestMetrics_CountsOnlyRepositoryFaults(t *testing.T) {
+	m := Infrastructure.NewMetrics()
+	m.ObserveOperation("tasks", "GetByID", time.Millisecond, nil)
+	m.ObserveOperation("tasks", "GetByID", time.Millisecond, Domain.ErrTaskNotFound)
+	m.ObserveOperation("tasks", "Create", time.Millisecond, errors.New("connection reset"))
+
+	out := writeMetrics(t, m)
+	assert.Contains(t, out, `repository_operation_duration_seconds_count{method="GetByID",repository="tasks"} 2`)
+	assert.Contains(t, out, `repository_operation_errors_total{method="Create",repository="tasks"} 1`)
+	assert.NotContains(t, out, `repository_operation_errors_total{method="GetByID",repository="tasks"}`)
+}
+
+func TestMetrics_LoginAndTokenCounters(t *testing.T) {
+	m := Infrastructure.NewMetrics()
+	m.LoginSucceeded()
+	m.LoginFailed("invalid_credentials")
+	m.LoginFailed("invalid_credentials")
+	m.TokenRejected("invalid")
+	m.TokenRejected(`odd "reason"`)
+
+	out := writeMetrics(t, m)
+	assert.Contains(t, out, "auth_login_success_total 1\n")
+	assert.Contains(t, out, `auth_login_failures_total{reason="invalid_credentials"} 2`)
+	assert.Contains(t, out, `auth_token_validation_failures_total{reason="invalid"} 1`)
+	assert.Contains(t, out, `auth_token_validation_failures_total{reason="odd \"reason\""} 1`)
+}
@@ -0,0 +1,44 @@
+package Infrastructure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"task-manager/Domain"
+	"time"
+)
+
+// OutboxMailer writes each message to its own file in a directory instead of
+// sending it. It lets the mail flows run without an SMTP server, in
+// development and in tests; a separate process may deliver the files.
+type OutboxMailer struct {
+	dir string
+	now func() time.Time
+}
+
+func NewOutboxMailer(dir string) *OutboxMailer {
+	return &OutboxMailer{dir: dir, now: time.Now}
+}
+
+// Send writes message as a plain RFC 5322 file. File names start with the
+// time of sending so a directory listing shows the oldest first. Messages
+// may carry secrets such as reset tokens, so only the owner can read them.
+func (m *OutboxMailer) Send(ctx context.Context, message Domain.MailMessage) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	now := m.now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	content := fmt.Sprintf("Date: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		now.Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,35 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxMailer_WritesOneFilePerMessage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer := Infrastructure.NewOutboxMailer(dir)
+
+	assert.NoError(t, mailer.Send(context.Background(), Domain.MailMessage{To: "a@example.com", Subject: "First", Body: "hello"}))
+	assert.NoError(t, mailer.Send(context.Background(), Domain.MailMessage{To: "b@example.com", Subject: "Second", Body: "again"}))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "To: a@example.com\r\n")
+		assert.Contains(t, string(content), "Subject: First\r\n")
+		assert.Contains(t, string(content), "\r\n\r\nhello")
+
+		info, err := entries[0].Info()
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
@@ -0,0 +1,98 @@
+package Infrastructure
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"task-manager/Domain"
+)
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// DefaultPasswordPolicy is Domain.DefaultPasswordPolicy with the built-in
+// list of common passwords as its denylist.
+func DefaultPasswordPolicy() Domain.PasswordPolicy {
+	policy := Domain.DefaultPasswordPolicy()
+	policy.Denylist = map[string]bool{}
+	_ = readDenylist(strings.NewReader(commonPasswords), policy.Denylist)
+	return policy
+}
+
+// passwordPolicyFile is the on-disk form of a password policy. Omitted
+// settings keep their defaults, for example:
+//
+//	{"min_length": 12, "require_symbol": true, "denylist_file": "/etc/task-manager/breached.txt"}
+type passwordPolicyFile struct {
+	MinLength     *int   `json:"min_length"`
+	MaxLength     *int   `json:"max_length"`
+	RequireUpper  *bool  `json:"require_upper"`
+	RequireLower  *bool  `json:"require_lower"`
+	RequireDigit  *bool  `json:"require_digit"`
+	RequireSymbol *bool  `json:"require_symbol"`
+	DenylistFile  string `json:"denylist_file"`
+}
+
+// LoadPasswordPolicy reads a password policy from a JSON file. The passwords
+// in its denylist file, one per line, are refused in addition to the
+// built-in list.
+func LoadPasswordPolicy(path string) (Domain.PasswordPolicy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Domain.PasswordPolicy{}, err
+	}
+
+	var file passwordPolicyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return Domain.PasswordPolicy{}, err
+	}
+
+	policy := DefaultPasswordPolicy()
+	if file.MinLength != nil {
+		policy.MinLength = *file.MinLength
+	}
+	if file.MaxLength != nil {
+		policy.MaxLength = *file.MaxLength
+	}
+	if file.RequireUpper != nil {
+		policy.RequireUpper = *file.RequireUpper
+	}
+	if file.RequireLower != nil {
+		policy.RequireLower = *file.RequireLower
+	}
+	if file.RequireDigit != nil {
+		policy.RequireDigit = *file.RequireDigit
+	}
+	if file.RequireSymbol != nil {
+		policy.RequireSymbol = *file.RequireSymbol
+	}
+
+	if file.DenylistFile != "" {
+		denylist, err := os.Open(file.DenylistFile)
+		if err != nil {
+			return Domain.PasswordPolicy{}, err
+		}
+		defer denylist.Close()
+		if err := readDenylist(denylist, policy.Denylist); err != nil {
+			return Domain.PasswordPolicy{}, err
+		}
+	}
+	return policy, nil
+}
+
+// readDenylist adds the lower-cased passwords in r, one per line, to
+// denylist. Blank lines and lines starting with # are skipped.
+func readDenylist(r io.Reader, denylist map[string]bool) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = true
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,63 @@
+package Infrastructure_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPasswordPolicy_RefusesCommonPasswords(t *testing.T) {
+	policy := Infrastructure.DefaultPasswordPolicy()
+
+	assert.Empty(t, policy.Check("Correct-Horse-9"))
+
+	problems := policy.Check("Password123")
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, "too_common", problems[0].Code)
+	}
+}
+
+func TestLoadPasswordPolicy_OverridesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	denylist := filepath.Join(dir, "breached.txt")
+	if err := os.WriteFile(denylist, []byte("# breached\nCorrect-Horse-9\n\n"), 0o600); err != nil {
+		t.Fatalf("failed to write denylist: %v", err)
+	}
+	path := filepath.Join(dir, "policy.json")
+	content := `{"min_length": 16, "require_upper": false, "require_symbol": true, "denylist_file": "` + denylist + `"}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+
+	policy, err := Infrastructure.LoadPasswordPolicy(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 16, policy.MinLength)
+	assert.Equal(t, 72, policy.MaxLength)
+	assert.False(t, policy.RequireUpper)
+	assert.True(t, policy.RequireLower)
+	assert.True(t, policy.RequireSymbol)
+	assert.True(t, policy.Denylist["correct-horse-9"])
+	assert.True(t, policy.Denylist["password123"], "the built-in list still applies")
+
+	var codes []string
+	for _, problem := range policy.Check("correct horse") {
+		codes = append(codes, problem.Code)
+	}
+	assert.Equal(t, []string{"too_short", "missing_digit"}, codes)
+}
+
+func TestLoadPasswordPolicy_MissingDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"denylist_file": "/does/not/exist"}`), 0o600); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+
+	_, err := Infrastructure.LoadPasswordPolicy(path)
+
+	assert.Error(t, err)
+}
@@ -1,22 +1,173 @@
 package Infrastructure
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 	"task-manager/Domain"
 )
 
-type PasswordService struct{}
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHashing is the policy new hashes are made with. Hashes made under
+// an earlier policy still verify; NeedsRehash reports them.
+type PasswordHashing struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// DefaultPasswordHashing uses argon2id with the parameters OWASP recommends
+// as a minimum: 19 MiB of memory, two passes and one thread.
+func DefaultPasswordHashing() PasswordHashing {
+	return PasswordHashing{
+		Algorithm:  AlgorithmArgon2id,
+		BcryptCost: 12,
+		Argon2: Argon2Params{
+			Memory:      19 * 1024,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+}
+
+// Validate refuses settings that would weaken every new hash rather than
+// silently falling back to the defaults.
+func (p PasswordHashing) Validate() error {
+	switch p.Algorithm {
+	case AlgorithmBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if p.Argon2.Memory < 8*uint32(p.Argon2.Parallelism) || p.Argon2.Iterations < 1 || p.Argon2.Parallelism < 1 {
+			return errors.New("argon2id needs at least one iteration, one thread and 8 KiB of memory per thread")
+		}
+		if p.Argon2.SaltLength < 8 || p.Argon2.KeyLength < 16 {
+			return errors.New("argon2id salt must be at least 8 bytes and key at least 16 bytes")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+type PasswordService struct {
+	policy PasswordHashing
+}
 
 func NewPasswordService() Domain.IPasswordService {
-	return &PasswordService{}
+	return NewPasswordServiceWithPolicy(DefaultPasswordHashing())
+}
+
+func NewPasswordServiceWithPolicy(policy PasswordHashing) Domain.IPasswordService {
+	return &PasswordService{policy: policy}
 }
 
 func (p *PasswordService) Hash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	if p.policy.Algorithm == AlgorithmBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.policy.BcryptCost)
+		return string(bytes), err
+	}
+
+	params := p.policy.Argon2
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return encodeArgon2id(params, salt, key), nil
 }
 
+// Compare accepts hashes made with either algorithm, whatever the current
+// policy is.
 func (p *PasswordService) Compare(plain, hashed string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain))
-	return err == nil
+	if !strings.HasPrefix(hashed, "$argon2id$") {
+		err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain))
+		return err == nil
+	}
+
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(plain), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, candidate) == 1
+}
+
+// NeedsRehash reports a hash made with another algorithm or other
+// parameters than the current policy. Salt length is not compared; it does
+// not affect the cost of an attack.
+func (p *PasswordService) NeedsRehash(hashed string) bool {
+	if p.policy.Algorithm == AlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(hashed))
+		return err != nil || cost != p.policy.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2id(hashed)
+	if err != nil {
+		return true
+	}
+	want := p.policy.Argon2
+	return params.Memory != want.Memory || params.Iterations != want.Iterations ||
+		params.Parallelism != want.Parallelism || params.KeyLength != want.KeyLength
+}
+
+// encodeArgon2id writes the PHC string format other argon2 libraries read:
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>, base64 without padding.
+func encodeArgon2id(params Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+var errMalformedArgon2id = errors.New("malformed argon2id hash")
+
+func decodeArgon2id(hashed string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return Argon2Params{}, nil, nil, errMalformedArgon2id
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, errMalformedArgon2id
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, errMalformedArgon2id
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, errMalformedArgon2id
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, errMalformedArgon2id
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
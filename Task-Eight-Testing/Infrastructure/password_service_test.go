@@ -1,122 +1,188 @@
 package Infrastructure_test
 
 import (
-    "testing"
-    "task-manager/Infrastructure"
-    
-    "github.com/stretchr/testify/assert"
+	"task-manager/Infrastructure"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPasswordService_Hash_Success(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    password := "mySecretPassword123"
-    
-    hashed, err := service.Hash(password)
-    
-    assert.NoError(t, err)
-    assert.NotEmpty(t, hashed)
-    assert.NotEqual(t, password, hashed, "Hashed password should be different from plain text")
-    assert.Greater(t, len(hashed), 50, "Bcrypt hash should be reasonably long")
-    
-    assert.Contains(t, hashed, "$2a$", "Should contain bcrypt identifier")
+	service := Infrastructure.NewPasswordService()
+	password := "mySecretPassword123"
+
+	hashed, err := service.Hash(password)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hashed)
+	assert.NotEqual(t, password, hashed, "Hashed password should be different from plain text")
+	assert.Greater(t, len(hashed), 50, "Argon2id hash should be reasonably long")
+
+	assert.Contains(t, hashed, "$argon2id$v=19$m=19456,t=2,p=1$", "Should carry the algorithm and its parameters")
 }
 
-func TestPasswordService_Hash_EmptyPassword(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    
-    hashed, err := service.Hash("")
-    
-    assert.NoError(t, err)
-    assert.NotEmpty(t, hashed)
+func TestPasswordService_Hash_Bcrypt(t *testing.T) {
+	policy := Infrastructure.DefaultPasswordHashing()
+	policy.Algorithm = Infrastructure.AlgorithmBcrypt
+	policy.BcryptCost = 10
+	service := Infrastructure.NewPasswordServiceWithPolicy(policy)
+
+	hashed, err := service.Hash("mySecretPassword123")
+
+	assert.NoError(t, err)
+	assert.Contains(t, hashed, "$2a$10$", "Should contain bcrypt identifier and cost")
+	assert.True(t, service.Compare("mySecretPassword123", hashed))
 }
 
+func TestPasswordService_Hash_EmptyPassword(t *testing.T) {
+	service := Infrastructure.NewPasswordService()
+
+	hashed, err := service.Hash("")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hashed)
+}
 
 func TestPasswordService_Compare_ValidPassword(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    password := "testPassword123"
-    
-    hashed, err := service.Hash(password)
-    assert.NoError(t, err)
-    
-    isValid := service.Compare(password, hashed)
-    
-    assert.True(t, isValid, "Valid password should match its hash")
+	service := Infrastructure.NewPasswordService()
+	password := "testPassword123"
+
+	hashed, err := service.Hash(password)
+	assert.NoError(t, err)
+
+	isValid := service.Compare(password, hashed)
+
+	assert.True(t, isValid, "Valid password should match its hash")
 }
 
 func TestPasswordService_Compare_InvalidPassword(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    correctPassword := "correctPassword"
-    wrongPassword := "wrongPassword"
-    
-    hashed, err := service.Hash(correctPassword)
-    assert.NoError(t, err)
-    
-    isValid := service.Compare(wrongPassword, hashed)
-    
-    assert.False(t, isValid, "Invalid password should not match hash")
+	service := Infrastructure.NewPasswordService()
+	correctPassword := "correctPassword"
+	wrongPassword := "wrongPassword"
+
+	hashed, err := service.Hash(correctPassword)
+	assert.NoError(t, err)
+
+	isValid := service.Compare(wrongPassword, hashed)
+
+	assert.False(t, isValid, "Invalid password should not match hash")
 }
 
 func TestPasswordService_Compare_InvalidHash(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    password := "testPassword"
-    invalidHash := "not-a-valid-bcrypt-hash"
-    
-    isValid := service.Compare(password, invalidHash)
-    
-    assert.False(t, isValid, "Invalid hash should return false")
+	service := Infrastructure.NewPasswordService()
+	password := "testPassword"
+	invalidHash := "not-a-valid-bcrypt-hash"
+
+	isValid := service.Compare(password, invalidHash)
+
+	assert.False(t, isValid, "Invalid hash should return false")
 }
 
 func TestPasswordService_Compare_EmptyPassword(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    
-    hashed, _ := service.Hash("")
-    
-    isValid := service.Compare("", hashed)
-    assert.True(t, isValid, "Empty password should match its hash")
-    
-    nonEmptyHashed, _ := service.Hash("nonempty")
-    isValid = service.Compare("", nonEmptyHashed)
-    assert.False(t, isValid, "Empty password should not match non-empty hash")
+	service := Infrastructure.NewPasswordService()
+
+	hashed, _ := service.Hash("")
+
+	isValid := service.Compare("", hashed)
+	assert.True(t, isValid, "Empty password should match its hash")
+
+	nonEmptyHashed, _ := service.Hash("nonempty")
+	isValid = service.Compare("", nonEmptyHashed)
+	assert.False(t, isValid, "Empty password should not match non-empty hash")
 }
 
 func TestPasswordService_HashConsistency(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    password := "consistencyTest"
-    
-    hash1, err1 := service.Hash(password)
-    hash2, err2 := service.Hash(password)
-    
-    assert.NoError(t, err1)
-    assert.NoError(t, err2)
-    
-    assert.NotEqual(t, hash1, hash2, "Bcrypt should generate different hashes each time due to salt")
-    
-    assert.True(t, service.Compare(password, hash1), "First hash should validate correctly")
-    assert.True(t, service.Compare(password, hash2), "Second hash should validate correctly")
-}
+	service := Infrastructure.NewPasswordService()
+	password := "consistencyTest"
 
+	hash1, err1 := service.Hash(password)
+	hash2, err2 := service.Hash(password)
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+
+	assert.NotEqual(t, hash1, hash2, "Bcrypt should generate different hashes each time due to salt")
+
+	assert.True(t, service.Compare(password, hash1), "First hash should validate correctly")
+	assert.True(t, service.Compare(password, hash2), "Second hash should validate correctly")
+}
 
 func TestPasswordService_CaseSensitivity(t *testing.T) {
-    service := Infrastructure.NewPasswordService()
-    password := "CaseSensitivePassword"
-    
-    hashed, _ := service.Hash(password)
-    
-    testCases := []struct {
-        testPassword string
-        shouldMatch  bool
-    }{
-        {"CaseSensitivePassword", true},  
-        {"casesensitivepassword", false}, 
-        {"CASESENSITIVEPASSWORD", false}, 
-        {"CaseSensitivepassword", false}, 
-    }
-    
-    for _, tc := range testCases {
-        t.Run("Case_"+tc.testPassword, func(t *testing.T) {
-            isValid := service.Compare(tc.testPassword, hashed)
-            assert.Equal(t, tc.shouldMatch, isValid, 
-                "Password comparison should be case sensitive")
-        })
-    }
-}
\ No newline at end of file
+	service := Infrastructure.NewPasswordService()
+	password := "CaseSensitivePassword"
+
+	hashed, _ := service.Hash(password)
+
+	testCases := []struct {
+		testPassword string
+		shouldMatch  bool
+	}{
+		{"CaseSensitivePassword", true},
+		{"casesensitivepassword", false},
+		{"CASESENSITIVEPASSWORD", false},
+		{"CaseSensitivepassword", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run("Case_"+tc.testPassword, func(t *testing.T) {
+			isValid := service.Compare(tc.testPassword, hashed)
+			assert.Equal(t, tc.shouldMatch, isValid,
+				"Password comparison should be case sensitive")
+		})
+	}
+}
+
+func TestPasswordService_NeedsRehash(t *testing.T) {
+	bcryptPolicy := Infrastructure.DefaultPasswordHashing()
+	bcryptPolicy.Algorithm = Infrastructure.AlgorithmBcrypt
+	bcryptPolicy.BcryptCost = 10
+	bcryptService := Infrastructure.NewPasswordServiceWithPolicy(bcryptPolicy)
+	argonService := Infrastructure.NewPasswordService()
+	strongerPolicy := Infrastructure.DefaultPasswordHashing()
+	strongerPolicy.Argon2.Iterations = 3
+	strongerService := Infrastructure.NewPasswordServiceWithPolicy(strongerPolicy)
+
+	bcryptHash, _ := bcryptService.Hash("testPassword123")
+	argonHash, _ := argonService.Hash("testPassword123")
+
+	assert.False(t, bcryptService.NeedsRehash(bcryptHash))
+	assert.False(t, argonService.NeedsRehash(argonHash))
+	assert.True(t, argonService.NeedsRehash(bcryptHash), "bcrypt hashes are upgraded to argon2id")
+	assert.True(t, bcryptService.NeedsRehash(argonHash))
+	assert.True(t, strongerService.NeedsRehash(argonHash), "hashes with older parameters are upgraded")
+
+	assert.True(t, argonService.Compare("testPassword123", bcryptHash), "old hashes keep working")
+	assert.True(t, strongerService.Compare("testPassword123", argonHash))
+}
+
+func TestPasswordService_Compare_MalformedArgon2id(t *testing.T) {
+	service := Infrastructure.NewPasswordService()
+
+	for _, hashed := range []string{
+		"$argon2id$v=19$m=19456,t=2,p=1$c2FsdA",
+		"$argon2id$v=18$m=19456,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=x,t=2,p=1$c2FsdHNhbHQ$a2V5",
+	} {
+		assert.False(t, service.Compare("testPassword", hashed), hashed)
+		assert.True(t, service.NeedsRehash(hashed), hashed)
+	}
+}
+
+func TestPasswordHashing_Validate(t *testing.T) {
+	assert.NoError(t, Infrastructure.DefaultPasswordHashing().Validate())
+
+	policy := Infrastructure.DefaultPasswordHashing()
+	policy.Algorithm = Infrastructure.AlgorithmBcrypt
+	policy.BcryptCost = 11
+	assert.NoError(t, policy.Validate())
+
+	policy.BcryptCost = 99
+	assert.Error(t, policy.Validate())
+
+	policy.Algorithm = "md5"
+	assert.Error(t, policy.Validate())
+
+	policy = Infrastructure.DefaultPasswordHashing()
+	policy.Argon2.Iterations = 0
+	assert.Error(t, policy.Validate())
+}
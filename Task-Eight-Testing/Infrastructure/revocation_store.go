@@ -0,0 +1,62 @@
+package Infrastructure
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"time"
+)
+
+// InMemoryRevocationStore keeps revoked token IDs in process memory. It is
+// suitable for a single instance; revocations are lost on restart.
+type InMemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+func NewInMemoryRevocationStore() Domain.ITokenRevocationStore {
+	return &InMemoryRevocationStore{
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (s *InMemoryRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	s.revoked[id] = expiresAt
+	return nil
+}
+
+func (s *InMemoryRevocationStore) RevokeOnce(ctx context.Context, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	if _, ok := s.revoked[id]; ok {
+		return Domain.ErrTokenRevoked
+	}
+	s.revoked[id] = expiresAt
+	return nil
+}
+
+// prune drops expired revocations. s.mu must be held.
+func (s *InMemoryRevocationStore) prune() {
+	now := s.now()
+	for jti, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, jti)
+		}
+	}
+}
+
+func (s *InMemoryRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revoked[id]
+	return ok, nil
+}
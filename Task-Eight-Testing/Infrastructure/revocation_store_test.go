@@ -0,0 +1,50 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryRevocationStore_RevokeAndCheck(t *testing.T) {
+	store := Infrastructure.NewInMemoryRevocationStore()
+
+	revoked, err := store.IsRevoked(context.Background(), "jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, store.Revoke(context.Background(), "jti-1", time.Now().Add(time.Hour)))
+
+	revoked, err = store.IsRevoked(context.Background(), "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestInMemoryRevocationStore_ForgetsExpiredEntries(t *testing.T) {
+	store := Infrastructure.NewInMemoryRevocationStore()
+
+	assert.NoError(t, store.Revoke(context.Background(), "expired", time.Now().Add(-time.Minute)))
+	assert.NoError(t, store.Revoke(context.Background(), "fresh", time.Now().Add(time.Hour)))
+
+	revoked, _ := store.IsRevoked(context.Background(), "expired")
+	assert.False(t, revoked, "expired revocations should be pruned")
+
+	revoked, _ = store.IsRevoked(context.Background(), "fresh")
+	assert.True(t, revoked)
+}
+
+func TestInMemoryRevocationStore_RevokeOnce(t *testing.T) {
+	store := Infrastructure.NewInMemoryRevocationStore()
+
+	assert.NoError(t, store.RevokeOnce(context.Background(), "jti-1", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, store.RevokeOnce(context.Background(), "jti-1", time.Now().Add(time.Hour)), Domain.ErrTokenRevoked)
+
+	revoked, err := store.IsRevoked(context.Background(), "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
@@ -0,0 +1,71 @@
+package Infrastructure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"task-manager/Domain"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// DefaultVerificationTokenTTL is how long an email verification link works.
+const DefaultVerificationTokenTTL = 48 * time.Hour
+
+const verificationTokenType = "verify_email"
+
+type verificationClaims struct {
+	Email string `json:"email"`
+	Type  string `json:"typ"`
+	jwt.StandardClaims
+}
+
+// VerificationTokenService signs verification links as short JWTs. Its key
+// is derived from the secret key, so a verification token never validates
+// as an access token or the other way round.
+type VerificationTokenService struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewVerificationTokenService(secretKey string, ttl time.Duration) (Domain.IVerificationTokenService, error) {
+	if secretKey == "" {
+		return nil, errors.New("no secret key to sign verification links with")
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte("email verification"))
+	return &VerificationTokenService{key: mac.Sum(nil), ttl: ttl}, nil
+}
+
+func (s *VerificationTokenService) Generate(user Domain.User) (string, error) {
+	now := time.Now()
+	claims := verificationClaims{
+		Email: user.Email,
+		Type:  verificationTokenType,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.ttl).Unix(),
+			Subject:   user.ID,
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+}
+
+func (s *VerificationTokenService) Validate(tokenStr string) (Domain.VerificationClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &verificationClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.key, nil
+	})
+	if err != nil || !token.Valid {
+		return Domain.VerificationClaims{}, errors.New("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(*verificationClaims)
+	if !ok || claims.Type != verificationTokenType {
+		return Domain.VerificationClaims{}, errors.New("invalid token type")
+	}
+	return Domain.VerificationClaims{UserID: claims.Subject, Email: claims.Email}, nil
+}
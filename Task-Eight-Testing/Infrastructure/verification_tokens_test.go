@@ -0,0 +1,54 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationTokenService_RoundTrip(t *testing.T) {
+	service, err := Infrastructure.NewVerificationTokenService(testSecretKey, time.Hour)
+	assert.NoError(t, err)
+
+	token, err := service.Generate(Domain.User{ID: "123", Email: "ada@example.com"})
+	assert.NoError(t, err)
+
+	claims, err := service.Validate(token)
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.VerificationClaims{UserID: "123", Email: "ada@example.com"}, claims)
+
+	_, err = service.Validate(token + "x")
+	assert.Error(t, err)
+}
+
+func TestVerificationTokenService_RejectsExpiredTokens(t *testing.T) {
+	service, _ := Infrastructure.NewVerificationTokenService(testSecretKey, -time.Minute)
+
+	token, _ := service.Generate(Domain.User{ID: "123", Email: "ada@example.com"})
+	_, err := service.Validate(token)
+	assert.Error(t, err)
+}
+
+func TestVerificationTokenService_IsNotInterchangeableWithAccessTokens(t *testing.T) {
+	verifier, _ := Infrastructure.NewVerificationTokenService(testSecretKey, time.Hour)
+	jwtService := newTestJWTService(t, testSecretKey)
+	user := Domain.User{ID: "123", Email: "ada@example.com", Role: Domain.RoleMember}
+
+	access, _ := jwtService.GenerateToken(user)
+	_, err := verifier.Validate(access)
+	assert.Error(t, err)
+
+	verification, _ := verifier.Generate(user)
+	_, err = jwtService.ValidateToken(context.Background(), verification)
+	assert.Error(t, err)
+}
+
+func TestNewVerificationTokenService_FailsWithoutSecretKey(t *testing.T) {
+	_, err := Infrastructure.NewVerificationTokenService("", time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package Infrastructure
+
+import (
+	"encoding/json"
+	"os"
+	"task-manager/Domain"
+)
+
+// workflowFile is the on-disk form of a workflow, for example:
+//
+//	{"initial": "todo", "creatable": ["doing"], "transitions": {"todo": ["doing"], "doing": ["todo", "done"], "done": []}}
+//
+// "creatable" is optional.
+type workflowFile struct {
+	Initial     string              `json:"initial"`
+	Creatable   []string            `json:"creatable"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// LoadWorkflow reads a team's task workflow from a JSON file and validates it.
+func LoadWorkflow(path string) (Domain.Workflow, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Domain.Workflow{}, err
+	}
+
+	var file workflowFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return Domain.Workflow{}, err
+	}
+
+	workflow := Domain.Workflow{Initial: file.Initial, Creatable: file.Creatable, Transitions: file.Transitions}
+	if err := workflow.Validate(); err != nil {
+		return Domain.Workflow{}, err
+	}
+	return workflow, nil
+}
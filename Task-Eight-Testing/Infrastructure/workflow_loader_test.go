@@ -0,0 +1,61 @@
+package Infrastructure_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeWorkflowFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write workflow file: %v", err)
+	}
+	return path
+}
+
+func TestLoadWorkflow_Success(t *testing.T) {
+	path := writeWorkflowFile(t, `{"initial": "open", "transitions": {"open": ["review"], "review": ["open", "closed"], "closed": []}}`)
+
+	workflow, err := Infrastructure.LoadWorkflow(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "open", workflow.Initial)
+	assert.NoError(t, workflow.CheckTransition("review", "closed"))
+	assert.Error(t, workflow.CheckTransition("open", "closed"))
+}
+
+func TestLoadWorkflow_RejectsUndefinedTargets(t *testing.T) {
+	path := writeWorkflowFile(t, `{"initial": "open", "transitions": {"open": ["closed"]}}`)
+
+	_, err := Infrastructure.LoadWorkflow(path)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "closed")
+}
+
+func TestLoadWorkflow_Creatable(t *testing.T) {
+	path := writeWorkflowFile(t, `{"initial": "open", "creatable": ["review"], "transitions": {"open": ["review"], "review": ["closed"], "closed": []}}`)
+
+	workflow, err := Infrastructure.LoadWorkflow(path)
+
+	assert.NoError(t, err)
+	assert.NoError(t, workflow.CheckInitial("review"))
+	assert.ErrorIs(t, workflow.CheckInitial("closed"), Domain.ErrInvalidInitialStatus)
+
+	path = writeWorkflowFile(t, `{"initial": "open", "creatable": ["shipped"], "transitions": {"open": []}}`)
+	_, err = Infrastructure.LoadWorkflow(path)
+	assert.ErrorContains(t, err, "shipped")
+}
+
+func TestLoadWorkflow_MissingFile(t *testing.T) {
+	_, err := Infrastructure.LoadWorkflow(filepath.Join(t.TempDir(), "missing.json"))
+
+	assert.Error(t, err)
+}
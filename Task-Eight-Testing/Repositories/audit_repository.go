@@ -0,0 +1,135 @@
+package Repositories
+
+import (
+	"context"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type auditRepository struct {
+	auditCollection *mongo.Collection
+	timeouts        Timeouts
+}
+
+func NewAuditRepository(ctx context.Context, db *MongoDatabase) (Domain.IAuditRepository, error) {
+	collection := db.Collection(db.collections.Audit)
+	if err := ensureAuditIndexes(ctx, collection); err != nil {
+		return nil, err
+	}
+	return &auditRepository{auditCollection: collection, timeouts: db.timeouts}, nil
+}
+
+func NewAuditRepositoryWithCollection(collection *mongo.Collection) Domain.IAuditRepository {
+	_ = ensureAuditIndexes(context.Background(), collection)
+	return &auditRepository{auditCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// ensureAuditIndexes covers the newest-first listing, alone and narrowed to
+// an actor or a single entity.
+func ensureAuditIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "entity", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+type auditChange struct {
+	Before string `bson:"before"`
+	After  string `bson:"after"`
+}
+
+type auditDocument struct {
+	ID        primitive.ObjectID     `bson:"_id"`
+	Actor     string                 `bson:"actor"`
+	Action    string                 `bson:"action"`
+	Entity    string                 `bson:"entity"`
+	EntityID  string                 `bson:"entity_id"`
+	Timestamp time.Time              `bson:"timestamp"`
+	Changes   map[string]auditChange `bson:"changes"`
+}
+
+func (r *auditRepository) Record(ctx context.Context, entry Domain.AuditEntry) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	doc := auditDocument{
+		ID:        primitive.NewObjectID(),
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Entity:    entry.Entity,
+		EntityID:  entry.EntityID,
+		Timestamp: entry.Timestamp,
+		Changes:   map[string]auditChange{},
+	}
+	for field, change := range entry.Changes {
+		doc.Changes[field] = auditChange{Before: change.Before, After: change.After}
+	}
+
+	_, err := r.auditCollection.InsertOne(ctx, doc)
+	return err
+}
+
+func (r *auditRepository) List(ctx context.Context, filter Domain.AuditFilter) ([]Domain.AuditEntry, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	query := bson.M{}
+	for field, value := range map[string]string{
+		"actor":     filter.Actor,
+		"action":    filter.Action,
+		"entity":    filter.Entity,
+		"entity_id": filter.EntityID,
+	} {
+		if value != "" {
+			query[field] = value
+		}
+	}
+	timestamp := bson.M{}
+	if !filter.Since.IsZero() {
+		timestamp["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		timestamp["$lt"] = filter.Until
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(filter.Limit))
+	cursor, err := r.auditCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Domain.AuditEntry{}
+	for cursor.Next(ctx) {
+		var doc auditDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		entry := Domain.AuditEntry{
+			ID:        doc.ID.Hex(),
+			Actor:     doc.Actor,
+			Action:    doc.Action,
+			Entity:    doc.Entity,
+			EntityID:  doc.EntityID,
+			Timestamp: doc.Timestamp.UTC(),
+			Changes:   map[string]Domain.FieldChange{},
+		}
+		for field, change := range doc.Changes {
+			entry.Changes[field] = Domain.FieldChange{Before: change.Before, After: change.After}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, cursor.Err()
+}
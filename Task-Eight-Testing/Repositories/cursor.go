@@ -0,0 +1,63 @@
+package Repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"task-manager/Domain"
+	"time"
+)
+
+// listCursor marks the last task of a page. It records the sort expression it
+// was issued for so it cannot be replayed against a different ordering.
+type listCursor struct {
+	Sort    string    `json:"s"`
+	ID      string    `json:"id"`
+	Title   string    `json:"t,omitempty"`
+	DueDate time.Time `json:"d,omitempty"`
+}
+
+func newListCursor(sort string, task Domain.Task) listCursor {
+	return listCursor{Sort: sort, ID: task.ID, Title: task.Title, DueDate: task.DueDate}
+}
+
+func (c listCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeListCursor(token, sort string) (listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return listCursor{}, Domain.ErrInvalidCursor
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" || c.Sort != sort {
+		return listCursor{}, Domain.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// userCursor marks the last user of a page. Users are listed by email, which
+// is unique, so the email alone identifies the position.
+type userCursor struct {
+	Email string `json:"e"`
+}
+
+func encodeUserCursor(user Domain.User) string {
+	raw, _ := json.Marshal(userCursor{Email: user.Email})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeUserCursor(token string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", Domain.ErrInvalidCursor
+	}
+
+	var c userCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Email == "" {
+		return "", Domain.ErrInvalidCursor
+	}
+	return c.Email, nil
+}
@@ -0,0 +1,28 @@
+package Repositories
+
+import (
+	"errors"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// parseID reports a malformed ID as Domain.ErrInvalidID so every backend
+// rejects the same IDs the same way.
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, Domain.ErrInvalidID
+	}
+	return objectID, nil
+}
+
+// notFound translates the driver's no-documents error into the domain error
+// for the missing entity and passes every other error through.
+func notFound(err error, domainErr error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domainErr
+	}
+	return err
+}
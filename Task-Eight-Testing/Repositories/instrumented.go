@@ -0,0 +1,252 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"task-manager/Domain"
+	"time"
+)
+
+// Stores are the repositories Instrument wraps.
+type Stores struct {
+	Tasks         Domain.ITaskRepository
+	Users         Domain.IUserRepository
+	Tokens        Domain.ITokenRevocationStore
+	Audit         Domain.IAuditRepository
+	Roles         Domain.IRoleRepository
+	OneTimeTokens Domain.IOneTimeTokenRepository
+	LoginAttempts Domain.ILoginAttemptStore
+}
+
+// Instrument returns stores whose every call is timed, reported to metrics
+// and logged, whichever backend they use.
+func Instrument(stores Stores, metrics Domain.IRepositoryMetrics) Stores {
+	return Stores{
+		Tasks:         &instrumentedTasks{stores.Tasks, instrument{metrics, "tasks"}},
+		Users:         &instrumentedUsers{stores.Users, instrument{metrics, "users"}},
+		Tokens:        &instrumentedTokens{stores.Tokens, instrument{metrics, "revoked_tokens"}},
+		Audit:         &instrumentedAudit{stores.Audit, instrument{metrics, "audit"}},
+		Roles:         &instrumentedRoles{stores.Roles, instrument{metrics, "roles"}},
+		OneTimeTokens: &instrumentedOneTimeTokens{stores.OneTimeTokens, instrument{metrics, "one_time_tokens"}},
+		LoginAttempts: &instrumentedLoginAttempts{stores.LoginAttempts, instrument{metrics, "login_attempts"}},
+	}
+}
+
+type instrument struct {
+	metrics    Domain.IRepositoryMetrics
+	repository string
+}
+
+// done reports a call to method that began at start and logs it with the
+// caller's context, so the line carries the request it was made for. It is
+// deferred with a pointer to the call's named error result.
+func (i instrument) done(ctx context.Context, method string, start time.Time, err *error) {
+	took := time.Since(start)
+	i.metrics.ObserveOperation(i.repository, method, took, *err)
+
+	// Domain errors such as a missing task are answers, not failures.
+	var domainErr *Domain.Error
+	if *err != nil && !errors.As(*err, &domainErr) {
+		slog.WarnContext(ctx, "repository call failed",
+			"repository", i.repository, "method", method, "duration", took, "error", *err)
+		return
+	}
+	slog.DebugContext(ctx, "repository call",
+		"repository", i.repository, "method", method, "duration", took)
+}
+
+type instrumentedTasks struct {
+	next Domain.ITaskRepository
+	instrument
+}
+
+func (r *instrumentedTasks) List(ctx context.Context, filter Domain.TaskFilter) (page Domain.TaskPage, err error) {
+	defer r.done(ctx, "List", time.Now(), &err)
+	return r.next.List(ctx, filter)
+}
+
+func (r *instrumentedTasks) GetByID(ctx context.Context, id string) (task Domain.Task, err error) {
+	defer r.done(ctx, "GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedTasks) Create(ctx context.Context, task Domain.Task) (created Domain.Task, err error) {
+	defer r.done(ctx, "Create", time.Now(), &err)
+	return r.next.Create(ctx, task)
+}
+
+func (r *instrumentedTasks) Update(ctx context.Context, id string, task Domain.Task) (updated Domain.Task, err error) {
+	defer r.done(ctx, "Update", time.Now(), &err)
+	return r.next.Update(ctx, id, task)
+}
+
+func (r *instrumentedTasks) Delete(ctx context.Context, id string) (err error) {
+	defer r.done(ctx, "Delete", time.Now(), &err)
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedTasks) ListTrash(ctx context.Context, owner string) (tasks []Domain.Task, err error) {
+	defer r.done(ctx, "ListTrash", time.Now(), &err)
+	return r.next.ListTrash(ctx, owner)
+}
+
+func (r *instrumentedTasks) Restore(ctx context.Context, id string, owner string) (task Domain.Task, trashedAt time.Time, err error) {
+	defer r.done(ctx, "Restore", time.Now(), &err)
+	return r.next.Restore(ctx, id, owner)
+}
+
+func (r *instrumentedTasks) PurgeTrash(ctx context.Context, before time.Time) (purged []string, err error) {
+	defer r.done(ctx, "PurgeTrash", time.Now(), &err)
+	return r.next.PurgeTrash(ctx, before)
+}
+
+type instrumentedUsers struct {
+	next Domain.IUserRepository
+	instrument
+}
+
+func (r *instrumentedUsers) FindByEmail(ctx context.Context, email string) (user Domain.User, err error) {
+	defer r.done(ctx, "FindByEmail", time.Now(), &err)
+	return r.next.FindByEmail(ctx, email)
+}
+
+func (r *instrumentedUsers) FindByID(ctx context.Context, id string) (user Domain.User, err error) {
+	defer r.done(ctx, "FindByID", time.Now(), &err)
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *instrumentedUsers) Create(ctx context.Context, user Domain.User) (created Domain.User, err error) {
+	defer r.done(ctx, "Create", time.Now(), &err)
+	return r.next.Create(ctx, user)
+}
+
+func (r *instrumentedUsers) SetRole(ctx context.Context, id string, role string) (user Domain.User, err error) {
+	defer r.done(ctx, "SetRole", time.Now(), &err)
+	return r.next.SetRole(ctx, id, role)
+}
+
+func (r *instrumentedUsers) List(ctx context.Context, filter Domain.UserFilter) (page Domain.UserPage, err error) {
+	defer r.done(ctx, "List", time.Now(), &err)
+	return r.next.List(ctx, filter)
+}
+
+func (r *instrumentedUsers) SetDisabled(ctx context.Context, id string, disabled bool) (user Domain.User, err error) {
+	defer r.done(ctx, "SetDisabled", time.Now(), &err)
+	return r.next.SetDisabled(ctx, id, disabled)
+}
+
+func (r *instrumentedUsers) SetVerified(ctx context.Context, id string, verified bool) (user Domain.User, err error) {
+	defer r.done(ctx, "SetVerified", time.Now(), &err)
+	return r.next.SetVerified(ctx, id, verified)
+}
+
+func (r *instrumentedUsers) SetPassword(ctx context.Context, id string, hash string) (user Domain.User, err error) {
+	defer r.done(ctx, "SetPassword", time.Now(), &err)
+	return r.next.SetPassword(ctx, id, hash)
+}
+
+func (r *instrumentedUsers) NextTokenGeneration(ctx context.Context, id string) (user Domain.User, err error) {
+	defer r.done(ctx, "NextTokenGeneration", time.Now(), &err)
+	return r.next.NextTokenGeneration(ctx, id)
+}
+
+func (r *instrumentedUsers) Delete(ctx context.Context, id string) (err error) {
+	defer r.done(ctx, "Delete", time.Now(), &err)
+	return r.next.Delete(ctx, id)
+}
+
+type instrumentedTokens struct {
+	next Domain.ITokenRevocationStore
+	instrument
+}
+
+func (r *instrumentedTokens) Revoke(ctx context.Context, id string, expiresAt time.Time) (err error) {
+	defer r.done(ctx, "Revoke", time.Now(), &err)
+	return r.next.Revoke(ctx, id, expiresAt)
+}
+
+func (r *instrumentedTokens) RevokeOnce(ctx context.Context, id string, expiresAt time.Time) (err error) {
+	defer r.done(ctx, "RevokeOnce", time.Now(), &err)
+	return r.next.RevokeOnce(ctx, id, expiresAt)
+}
+
+func (r *instrumentedTokens) IsRevoked(ctx context.Context, id string) (revoked bool, err error) {
+	defer r.done(ctx, "IsRevoked", time.Now(), &err)
+	return r.next.IsRevoked(ctx, id)
+}
+
+type instrumentedAudit struct {
+	next Domain.IAuditRepository
+	instrument
+}
+
+func (r *instrumentedAudit) Record(ctx context.Context, entry Domain.AuditEntry) (err error) {
+	defer r.done(ctx, "Record", time.Now(), &err)
+	return r.next.Record(ctx, entry)
+}
+
+func (r *instrumentedAudit) List(ctx context.Context, filter Domain.AuditFilter) (entries []Domain.AuditEntry, err error) {
+	defer r.done(ctx, "List", time.Now(), &err)
+	return r.next.List(ctx, filter)
+}
+
+type instrumentedRoles struct {
+	next Domain.IRoleRepository
+	instrument
+}
+
+func (r *instrumentedRoles) List(ctx context.Context) (roles []Domain.Role, err error) {
+	defer r.done(ctx, "List", time.Now(), &err)
+	return r.next.List(ctx)
+}
+
+func (r *instrumentedRoles) FindByName(ctx context.Context, name string) (role Domain.Role, err error) {
+	defer r.done(ctx, "FindByName", time.Now(), &err)
+	return r.next.FindByName(ctx, name)
+}
+
+func (r *instrumentedRoles) Save(ctx context.Context, role Domain.Role) (err error) {
+	defer r.done(ctx, "Save", time.Now(), &err)
+	return r.next.Save(ctx, role)
+}
+
+func (r *instrumentedRoles) Delete(ctx context.Context, name string) (err error) {
+	defer r.done(ctx, "Delete", time.Now(), &err)
+	return r.next.Delete(ctx, name)
+}
+
+type instrumentedOneTimeTokens struct {
+	next Domain.IOneTimeTokenRepository
+	instrument
+}
+
+func (r *instrumentedOneTimeTokens) Save(ctx context.Context, token Domain.OneTimeToken) (err error) {
+	defer r.done(ctx, "Save", time.Now(), &err)
+	return r.next.Save(ctx, token)
+}
+
+func (r *instrumentedOneTimeTokens) Consume(ctx context.Context, purpose, hash string) (token Domain.OneTimeToken, err error) {
+	defer r.done(ctx, "Consume", time.Now(), &err)
+	return r.next.Consume(ctx, purpose, hash)
+}
+
+type instrumentedLoginAttempts struct {
+	next Domain.ILoginAttemptStore
+	instrument
+}
+
+func (r *instrumentedLoginAttempts) Get(ctx context.Context, key string) (attempts Domain.LoginAttempts, err error) {
+	defer r.done(ctx, "Get", time.Now(), &err)
+	return r.next.Get(ctx, key)
+}
+
+func (r *instrumentedLoginAttempts) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (attempts Domain.LoginAttempts, err error) {
+	defer r.done(ctx, "RecordFailure", time.Now(), &err)
+	return r.next.RecordFailure(ctx, key, at, window)
+}
+
+func (r *instrumentedLoginAttempts) Reset(ctx context.Context, key string) (err error) {
+	defer r.done(ctx, "Reset", time.Now(), &err)
+	return r.next.Reset(ctx, key)
+}
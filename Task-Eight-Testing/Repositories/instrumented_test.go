@@ -0,0 +1,73 @@
+package Repositories_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type operation struct {
+	repository, method string
+	err                error
+}
+
+type recordingMetrics struct {
+	operations []operation
+}
+
+func (m *recordingMetrics) ObserveOperation(repository, method string, took time.Duration, err error) {
+	m.operations = append(m.operations, operation{repository, method, err})
+}
+
+func TestInstrument_ReportsEachCall(t *testing.T) {
+	metrics := &recordingMetrics{}
+	stores := Repositories.Instrument(Repositories.Stores{
+		Tasks:         Repositories.NewMemoryTaskRepository(),
+		Roles:         Repositories.NewMemoryRoleRepository(),
+		LoginAttempts: Infrastructure.NewInMemoryLoginAttemptStore(),
+	}, metrics)
+
+	// The wrappers must not change what the repositories do.
+	testRoleRepository(t, stores.Roles)
+
+	metrics.operations = nil
+	_, err := stores.Tasks.GetByID(context.Background(), "64b7f0c2e4b0a1a2b3c4d5e6")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.NoError(t, stores.LoginAttempts.Reset(context.Background(), "ada@example.com"))
+
+	assert.Equal(t, []operation{
+		{"tasks", "GetByID", err},
+		{"login_attempts", "Reset", nil},
+	}, metrics.operations)
+}
+
+func TestInstrument_LogsWithTheCallersContext(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(Infrastructure.NewLogger(&buf, slog.LevelDebug))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	stores := Repositories.Instrument(Repositories.Stores{
+		Users: Repositories.NewSQLiteUserRepository(setupSQLiteDB(t)),
+	}, &recordingMetrics{})
+
+	ctx := Infrastructure.WithLogFields(context.Background(), slog.String("request_id", "r1"))
+	_, err := stores.Users.FindByID(ctx, "64b7f0c2e4b0a1a2b3c4d5e6")
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	var line map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &line)) {
+		assert.Equal(t, "repository call", line["msg"])
+		assert.Equal(t, "r1", line["request_id"])
+		assert.Equal(t, "users", line["repository"])
+		assert.Equal(t, "FindByID", line["method"])
+	}
+}
@@ -0,0 +1,100 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type loginAttemptRepository struct {
+	attemptCollection *mongo.Collection
+	timeouts          Timeouts
+}
+
+// NewLoginAttemptRepository stores failed login counts in Mongo so every
+// instance behind a load balancer sees the same counts.
+func NewLoginAttemptRepository(ctx context.Context, db *MongoDatabase) (Domain.ILoginAttemptStore, error) {
+	collection := db.Collection(db.collections.LoginAttempts)
+	if err := ensureLoginAttemptIndexes(ctx, collection); err != nil {
+		return nil, err
+	}
+	return &loginAttemptRepository{attemptCollection: collection, timeouts: db.timeouts}, nil
+}
+
+func NewLoginAttemptRepositoryWithCollection(collection *mongo.Collection) Domain.ILoginAttemptStore {
+	_ = ensureLoginAttemptIndexes(context.Background(), collection)
+	return &loginAttemptRepository{attemptCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// ensureLoginAttemptIndexes lets Mongo drop counts once their window has
+// passed.
+func ensureLoginAttemptIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+type loginAttemptDocument struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// Get checks expiry itself; the TTL monitor only runs once a minute.
+func (r *loginAttemptRepository) Get(ctx context.Context, key string) (Domain.LoginAttempts, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var doc loginAttemptDocument
+	err := r.attemptCollection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Domain.LoginAttempts{}, nil
+	}
+	if err != nil {
+		return Domain.LoginAttempts{}, err
+	}
+	return Domain.LoginAttempts{Failures: doc.Failures, LastFailure: doc.LastFailure}, nil
+}
+
+// RecordFailure increments the count in a single pipeline update, starting
+// over when the stored count has expired, so concurrent failures are all
+// counted.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (Domain.LoginAttempts, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$expires_at", at}},
+				bson.M{"$add": bson.A{"$failures", 1}},
+				1,
+			}},
+			"last_failure": at,
+			"expires_at":   at.Add(window),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc loginAttemptDocument
+	if err := r.attemptCollection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc); err != nil {
+		return Domain.LoginAttempts{}, err
+	}
+	return Domain.LoginAttempts{Failures: doc.Failures, LastFailure: doc.LastFailure}, nil
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.attemptCollection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,54 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryAuditRepository appends entries in the order they are recorded.
+type memoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []Domain.AuditEntry
+}
+
+func NewMemoryAuditRepository() Domain.IAuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) Record(ctx context.Context, entry Domain.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = primitive.NewObjectID().Hex()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func matchesAuditFilter(entry Domain.AuditEntry, filter Domain.AuditFilter) bool {
+	switch {
+	case filter.Actor != "" && entry.Actor != filter.Actor,
+		filter.Action != "" && entry.Action != filter.Action,
+		filter.Entity != "" && entry.Entity != filter.Entity,
+		filter.EntityID != "" && entry.EntityID != filter.EntityID,
+		!filter.Since.IsZero() && entry.Timestamp.Before(filter.Since),
+		!filter.Until.IsZero() && !entry.Timestamp.Before(filter.Until):
+		return false
+	}
+	return true
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, filter Domain.AuditFilter) ([]Domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []Domain.AuditEntry{}
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		if matchesAuditFilter(r.entries[i], filter) {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,66 @@
+package Repositories_test
+
+import (
+	"context"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAuditRepository checks behaviour every IAuditRepository must share.
+func testAuditRepository(t *testing.T, repo Domain.IAuditRepository) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Domain.AuditEntry{
+		{Actor: "owner@example.com", Action: Domain.AuditTaskCreate, Entity: Domain.AuditEntityTask, EntityID: "t1", Timestamp: base,
+			Changes: map[string]Domain.FieldChange{"title": {After: "Write report"}}},
+		{Actor: "owner@example.com", Action: Domain.AuditTaskUpdate, Entity: Domain.AuditEntityTask, EntityID: "t1", Timestamp: base.Add(time.Minute),
+			Changes: map[string]Domain.FieldChange{"status": {Before: "todo", After: "done"}}},
+		{Actor: "admin@example.com", Action: Domain.AuditUserPromote, Entity: Domain.AuditEntityUser, EntityID: "u1", Timestamp: base.Add(2 * time.Minute),
+			Changes: map[string]Domain.FieldChange{"role": {Before: "user", After: "admin"}}},
+	}
+	for _, entry := range entries {
+		assert.NoError(t, repo.Record(ctx, entry))
+	}
+
+	all, err := repo.List(ctx, Domain.AuditFilter{Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, all, 3) {
+		assert.Equal(t, Domain.AuditUserPromote, all[0].Action)
+		assert.Equal(t, Domain.AuditTaskCreate, all[2].Action)
+		assert.NotEmpty(t, all[0].ID)
+		assert.Equal(t, entries[2].Changes, all[0].Changes)
+		assert.True(t, entries[2].Timestamp.Equal(all[0].Timestamp))
+	}
+
+	byActor, err := repo.List(ctx, Domain.AuditFilter{Actor: "owner@example.com", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, byActor, 2)
+
+	byEntity, err := repo.List(ctx, Domain.AuditFilter{Entity: Domain.AuditEntityTask, EntityID: "t1", Action: Domain.AuditTaskUpdate, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, byEntity, 1)
+
+	window, err := repo.List(ctx, Domain.AuditFilter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute), Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, window, 1) {
+		assert.Equal(t, Domain.AuditTaskUpdate, window[0].Action)
+	}
+
+	limited, err := repo.List(ctx, Domain.AuditFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, limited, 2)
+}
+
+func TestMemoryAuditRepository(t *testing.T) {
+	testAuditRepository(t, Repositories.NewMemoryAuditRepository())
+}
+
+func TestSQLiteAuditRepository(t *testing.T) {
+	testAuditRepository(t, Repositories.NewSQLiteAuditRepository(setupSQLiteDB(t)))
+}
@@ -0,0 +1,44 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"time"
+)
+
+type memoryOneTimeTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]Domain.OneTimeToken
+}
+
+func NewMemoryOneTimeTokenRepository() Domain.IOneTimeTokenRepository {
+	return &memoryOneTimeTokenRepository{tokens: make(map[string]Domain.OneTimeToken)}
+}
+
+// Save also drops expired tokens so unused ones do not pile up.
+func (r *memoryOneTimeTokenRepository) Save(ctx context.Context, token Domain.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for hash, existing := range r.tokens {
+		if existing.Expired(now) || (existing.UserID == token.UserID && existing.Purpose == token.Purpose) {
+			delete(r.tokens, hash)
+		}
+	}
+	r.tokens[token.Hash] = token
+	return nil
+}
+
+func (r *memoryOneTimeTokenRepository) Consume(ctx context.Context, purpose, hash string) (Domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[hash]
+	if !ok || token.Purpose != purpose {
+		return Domain.OneTimeToken{}, Domain.ErrTokenNotFound
+	}
+	delete(r.tokens, hash)
+	return token, nil
+}
@@ -0,0 +1,51 @@
+package Repositories_test
+
+import (
+	"context"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testOneTimeTokenRepository checks behaviour every IOneTimeTokenRepository
+// must share.
+func testOneTimeTokenRepository(t *testing.T, repo Domain.IOneTimeTokenRepository) {
+	t.Helper()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+
+	first := Domain.OneTimeToken{Hash: "first", UserID: "u1", Purpose: Domain.TokenPurposePasswordReset, ExpiresAt: expiresAt}
+	second := Domain.OneTimeToken{Hash: "second", UserID: "u1", Purpose: Domain.TokenPurposePasswordReset, ExpiresAt: expiresAt}
+	other := Domain.OneTimeToken{Hash: "other", UserID: "u2", Purpose: Domain.TokenPurposePasswordReset, ExpiresAt: expiresAt}
+	assert.NoError(t, repo.Save(ctx, first))
+	assert.NoError(t, repo.Save(ctx, other))
+	assert.NoError(t, repo.Save(ctx, second))
+
+	_, err := repo.Consume(ctx, Domain.TokenPurposePasswordReset, "first")
+	assert.ErrorIs(t, err, Domain.ErrTokenNotFound, "a newer token replaces the user's earlier one")
+
+	_, err = repo.Consume(ctx, "another_purpose", "second")
+	assert.ErrorIs(t, err, Domain.ErrTokenNotFound)
+
+	consumed, err := repo.Consume(ctx, Domain.TokenPurposePasswordReset, "second")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", consumed.UserID)
+	assert.True(t, expiresAt.Equal(consumed.ExpiresAt))
+
+	_, err = repo.Consume(ctx, Domain.TokenPurposePasswordReset, "second")
+	assert.ErrorIs(t, err, Domain.ErrTokenNotFound, "tokens can be used once")
+
+	_, err = repo.Consume(ctx, Domain.TokenPurposePasswordReset, "other")
+	assert.NoError(t, err)
+}
+
+func TestMemoryOneTimeTokenRepository(t *testing.T) {
+	testOneTimeTokenRepository(t, Repositories.NewMemoryOneTimeTokenRepository())
+}
+
+func TestSQLiteOneTimeTokenRepository(t *testing.T) {
+	testOneTimeTokenRepository(t, Repositories.NewSQLiteOneTimeTokenRepository(setupSQLiteDB(t)))
+}
@@ -0,0 +1,66 @@
+package Repositories
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"task-manager/Domain"
+)
+
+type memoryRoleRepository struct {
+	mu    sync.RWMutex
+	roles map[string]Domain.Role
+}
+
+func NewMemoryRoleRepository() Domain.IRoleRepository {
+	return &memoryRoleRepository{roles: make(map[string]Domain.Role)}
+}
+
+func (r *memoryRoleRepository) List(ctx context.Context) ([]Domain.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]Domain.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *memoryRoleRepository) FindByName(ctx context.Context, name string) (Domain.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return Domain.Role{}, Domain.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// Save copies the permissions so later changes to the caller's slice do not
+// reach the stored role.
+func (r *memoryRoleRepository) Save(ctx context.Context, role Domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role.Permissions = slices.Clone(role.Permissions)
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	r.roles[role.Name] = role
+	return nil
+}
+
+func (r *memoryRoleRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[name]; !ok {
+		return Domain.ErrRoleNotFound
+	}
+	delete(r.roles, name)
+	return nil
+}
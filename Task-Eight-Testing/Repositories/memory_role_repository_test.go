@@ -0,0 +1,52 @@
+package Repositories_test
+
+import (
+	"context"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRoleRepository checks behaviour every IRoleRepository must share.
+func testRoleRepository(t *testing.T, repo Domain.IRoleRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := repo.FindByName(ctx, Domain.RoleViewer)
+	assert.ErrorIs(t, err, Domain.ErrRoleNotFound)
+
+	assert.NoError(t, repo.Save(ctx, Domain.Role{Name: Domain.RoleViewer, Permissions: []string{Domain.PermTasksRead}}))
+	assert.NoError(t, repo.Save(ctx, Domain.Role{Name: "auditor", Permissions: []string{Domain.PermAuditRead}}))
+	assert.NoError(t, repo.Save(ctx, Domain.Role{Name: Domain.RoleViewer, Permissions: []string{Domain.PermTasksRead, Domain.PermAuditRead}}))
+
+	viewer, err := repo.FindByName(ctx, Domain.RoleViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{Domain.PermTasksRead, Domain.PermAuditRead}, viewer.Permissions)
+
+	roles, err := repo.List(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, roles, 2) {
+		assert.Equal(t, "auditor", roles[0].Name)
+		assert.Equal(t, Domain.RoleViewer, roles[1].Name)
+	}
+
+	assert.NoError(t, repo.Save(ctx, Domain.Role{Name: "empty"}))
+	empty, err := repo.FindByName(ctx, "empty")
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Permissions)
+
+	assert.NoError(t, repo.Delete(ctx, "auditor"))
+	assert.ErrorIs(t, repo.Delete(ctx, "auditor"), Domain.ErrRoleNotFound)
+	_, err = repo.FindByName(ctx, "auditor")
+	assert.ErrorIs(t, err, Domain.ErrRoleNotFound)
+}
+
+func TestMemoryRoleRepository(t *testing.T) {
+	testRoleRepository(t, Repositories.NewMemoryRoleRepository())
+}
+
+func TestSQLiteRoleRepository(t *testing.T) {
+	testRoleRepository(t, Repositories.NewSQLiteRoleRepository(setupSQLiteDB(t)))
+}
@@ -0,0 +1,235 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryTaskRepository keeps tasks in process memory. IDs are ObjectID hex
+// strings so callers see the same behaviour as with the Mongo backend.
+type memoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]Domain.Task
+}
+
+func NewMemoryTaskRepository() Domain.ITaskRepository {
+	return &memoryTaskRepository{tasks: make(map[string]Domain.Task)}
+}
+
+func (r *memoryTaskRepository) Create(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task.ID = primitive.NewObjectID().Hex()
+	task.Version = 1
+	task.UpdatedAt = time.Now().UTC()
+	r.tasks[task.ID] = task
+	return task, nil
+}
+
+func (r *memoryTaskRepository) GetByID(ctx context.Context, id string) (Domain.Task, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok || !task.DeletedAt.IsZero() {
+		return Domain.Task{}, Domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// compareTasks orders two tasks by the given sort field and breaks ties on
+// ID, mirroring the compound sort the Mongo backend uses.
+func compareTasks(a, b Domain.Task, field string) int {
+	switch field {
+	case Domain.SortByDueDate:
+		if c := a.DueDate.Compare(b.DueDate); c != 0 {
+			return c
+		}
+	case Domain.SortByTitle:
+		if c := strings.Compare(a.Title, b.Title); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+func matchesTaskFilter(task Domain.Task, filter Domain.TaskFilter) bool {
+	if !task.DeletedAt.IsZero() {
+		return false
+	}
+	if filter.Owner != "" && task.Owner != filter.Owner {
+		return false
+	}
+	if filter.Status != "" && task.Status != filter.Status {
+		return false
+	}
+	if !filter.DueBefore.IsZero() && !task.DueDate.Before(filter.DueBefore) {
+		return false
+	}
+	if !filter.DueAfter.IsZero() && task.DueDate.Before(filter.DueAfter) {
+		return false
+	}
+	return true
+}
+
+func (r *memoryTaskRepository) List(ctx context.Context, filter Domain.TaskFilter) (Domain.TaskPage, error) {
+	field, desc, err := Domain.ParseTaskSort(filter.Sort)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultTaskPageSize
+	}
+
+	var after *Domain.Task
+	if filter.Cursor != "" {
+		last, err := decodeListCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return Domain.TaskPage{}, err
+		}
+		after = &Domain.Task{ID: last.ID, Title: last.Title, DueDate: last.DueDate}
+	}
+
+	direction := 1
+	if desc {
+		direction = -1
+	}
+
+	r.mu.RLock()
+	tasks := []Domain.Task{}
+	for _, task := range r.tasks {
+		if !matchesTaskFilter(task, filter) {
+			continue
+		}
+		if after != nil && direction*compareTasks(task, *after, field) <= 0 {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return direction*compareTasks(tasks[i], tasks[j], field) < 0
+	})
+
+	page := Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > filter.Limit {
+		page.Tasks = tasks[:filter.Limit]
+		page.NextCursor = newListCursor(filter.Sort, page.Tasks[filter.Limit-1]).encode()
+	}
+	return page, nil
+}
+
+func (r *memoryTaskRepository) Update(ctx context.Context, id string, updatedTask Domain.Task) (Domain.Task, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tasks[id]
+	if !ok || !existing.DeletedAt.IsZero() {
+		return Domain.Task{}, Domain.ErrTaskNotFound
+	}
+	if existing.Version != updatedTask.Version {
+		return Domain.Task{}, Domain.ErrVersionConflict
+	}
+
+	existing.Title = updatedTask.Title
+	existing.Description = updatedTask.Description
+	existing.DueDate = updatedTask.DueDate
+	existing.Status = updatedTask.Status
+	existing.Version++
+	existing.UpdatedAt = time.Now().UTC()
+	r.tasks[id] = existing
+
+	updatedTask.ID = id
+	updatedTask.Version = existing.Version
+	updatedTask.UpdatedAt = existing.UpdatedAt
+	return updatedTask, nil
+}
+
+func (r *memoryTaskRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || !task.DeletedAt.IsZero() {
+		return Domain.ErrTaskNotFound
+	}
+	task.DeletedAt = time.Now().UTC()
+	task.UpdatedAt = task.DeletedAt
+	task.Version++
+	r.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) ListTrash(ctx context.Context, owner string) ([]Domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []Domain.Task{}
+	for _, task := range r.tasks {
+		if !task.DeletedAt.IsZero() && (owner == "" || task.Owner == owner) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if c := tasks[i].DeletedAt.Compare(tasks[j].DeletedAt); c != 0 {
+			return c > 0
+		}
+		return tasks[i].ID > tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (r *memoryTaskRepository) Restore(ctx context.Context, id string, owner string) (Domain.Task, time.Time, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt.IsZero() || (owner != "" && task.Owner != owner) {
+		return Domain.Task{}, time.Time{}, Domain.ErrTaskNotFound
+	}
+	trashedAt := task.DeletedAt
+	task.DeletedAt = time.Time{}
+	task.UpdatedAt = time.Now().UTC()
+	task.Version++
+	r.tasks[id] = task
+	return task, trashedAt, nil
+}
+
+func (r *memoryTaskRepository) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []string
+	for id, task := range r.tasks {
+		if !task.DeletedAt.IsZero() && task.DeletedAt.Before(before) {
+			delete(r.tasks, id)
+			purged = append(purged, id)
+		}
+	}
+	sort.Strings(purged)
+	return purged, nil
+}
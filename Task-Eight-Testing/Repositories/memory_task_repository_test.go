@@ -0,0 +1,183 @@
+package Repositories_test
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTaskRepository_CreateAndGet(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	task := createSampleTask()
+
+	created, err := repo.Create(context.Background(), task)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	fetched, err := repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created, fetched)
+}
+
+func TestMemoryTaskRepository_NotFoundAndInvalidID(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	fakeID := "507f1f77bcf86cd799439011"
+
+	_, err := repo.GetByID(context.Background(), fakeID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	_, err = repo.Update(context.Background(), fakeID, createSampleTask())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	err = repo.Delete(context.Background(), fakeID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	_, err = repo.GetByID(context.Background(), "invalid-id")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrInvalidID)
+}
+
+func TestMemoryTaskRepository_UpdateKeepsOwner(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	task := createSampleTask()
+	task.Owner = "owner@example.com"
+	created, _ := repo.Create(context.Background(), task)
+
+	_, err := repo.Update(context.Background(), created.ID, Domain.Task{Title: "Updated", Status: "completed", Version: created.Version})
+	assert.NoError(t, err)
+
+	fetched, _ := repo.GetByID(context.Background(), created.ID)
+	assert.Equal(t, "Updated", fetched.Title)
+	assert.Equal(t, task.Owner, fetched.Owner)
+}
+
+func TestMemoryTaskRepository_UpdateRejectsStaleVersion(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	created, _ := repo.Create(context.Background(), createSampleTask())
+
+	updated, err := repo.Update(context.Background(), created.ID, Domain.Task{Title: "First", Version: created.Version})
+	assert.NoError(t, err)
+	assert.Equal(t, created.Version+1, updated.Version)
+
+	_, err = repo.Update(context.Background(), created.ID, Domain.Task{Title: "Second", Version: created.Version})
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+}
+
+func TestMemoryTaskRepository_DeleteRemovesTask(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	created, _ := repo.Create(context.Background(), createSampleTask())
+
+	assert.NoError(t, repo.Delete(context.Background(), created.ID))
+
+	assert.Empty(t, listAll(t, repo))
+}
+
+func TestMemoryTaskRepository_List_PaginatesWithCursor(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		task := createSampleTask()
+		task.DueDate = base.Add(time.Duration(i%3) * time.Hour)
+		_, err := repo.Create(context.Background(), task)
+		assert.NoError(t, err)
+	}
+
+	filter := Domain.TaskFilter{Sort: "-due_date", Limit: 2}
+	var seen []Domain.Task
+	for {
+		page, err := repo.List(context.Background(), filter)
+		assert.NoError(t, err)
+		seen = append(seen, page.Tasks...)
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	assert.Len(t, seen, 5)
+	ids := map[string]bool{}
+	for i, task := range seen {
+		ids[task.ID] = true
+		if i > 0 {
+			assert.False(t, task.DueDate.After(seen[i-1].DueDate), "tasks should be ordered by due date descending")
+		}
+	}
+	assert.Len(t, ids, 5, "no task should appear on two pages")
+}
+
+func TestMemoryTaskRepository_ConcurrentCreates(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = repo.Create(context.Background(), createSampleTask())
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, listAll(t, repo), 50)
+}
+
+func TestMemoryTaskRepository_TrashAndRestore(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	task := createSampleTask()
+	task.Owner = "owner@example.com"
+	created, _ := repo.Create(context.Background(), task)
+
+	assert.NoError(t, repo.Delete(context.Background(), created.ID))
+	assert.ErrorIs(t, repo.Delete(context.Background(), created.ID), Domain.ErrTaskNotFound)
+
+	_, err := repo.GetByID(context.Background(), created.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	page, _ := repo.List(context.Background(), Domain.TaskFilter{})
+	assert.Empty(t, page.Tasks)
+
+	trash, err := repo.ListTrash(context.Background(), "owner@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+	trash, _ = repo.ListTrash(context.Background(), "someone@example.com")
+	assert.Empty(t, trash)
+
+	_, _, err = repo.Restore(context.Background(), created.ID, "someone@example.com")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	restored, trashedAt, err := repo.Restore(context.Background(), created.ID, "owner@example.com")
+	assert.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.WithinDuration(t, time.Now(), trashedAt, time.Minute)
+	assert.Equal(t, created.Version+2, restored.Version)
+
+	fetched, err := repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Title, fetched.Title)
+}
+
+func TestMemoryTaskRepository_PurgeTrash(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	trashed, _ := repo.Create(context.Background(), createSampleTask())
+	live, _ := repo.Create(context.Background(), createSampleTask())
+	assert.NoError(t, repo.Delete(context.Background(), trashed.ID))
+
+	purged, err := repo.PurgeTrash(context.Background(), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = repo.PurgeTrash(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{trashed.ID}, purged)
+
+	_, _, err = repo.Restore(context.Background(), trashed.ID, "")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = repo.GetByID(context.Background(), live.ID)
+	assert.NoError(t, err)
+}
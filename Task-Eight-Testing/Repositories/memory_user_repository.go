@@ -0,0 +1,237 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[string]Domain.User
+	byEmail map[string]string
+}
+
+func NewMemoryUserRepository() Domain.IUserRepository {
+	return &memoryUserRepository{
+		users:   make(map[string]Domain.User),
+		byEmail: make(map[string]string),
+	}
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (Domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[email]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return r.users[id], nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id string) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Create checks the email and assigns the role under one lock, so concurrent
+// registrations cannot both become the first admin or share an address.
+func (r *memoryUserRepository) Create(ctx context.Context, user Domain.User) (Domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byEmail[user.Email]; exists {
+		return Domain.User{}, Domain.ErrEmailTaken
+	}
+
+	if len(r.users) == 0 {
+		user.Role = Domain.RoleAdmin
+	} else {
+		user.Role = Domain.DefaultRole
+	}
+
+	user.ID = primitive.NewObjectID().Hex()
+	r.users[user.ID] = user
+	r.byEmail[user.Email] = user.ID
+	return user, nil
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, id string, role string) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	if role != Domain.RoleAdmin && r.isLastAdmin(user) {
+		return Domain.User{}, Domain.ErrLastAdmin
+	}
+	user.Role = role
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultUserPageSize
+	}
+	after := ""
+	if filter.Cursor != "" {
+		email, err := decodeUserCursor(filter.Cursor)
+		if err != nil {
+			return Domain.UserPage{}, err
+		}
+		after = email
+	}
+	search := strings.ToLower(filter.Search)
+
+	r.mu.RLock()
+	users := []Domain.User{}
+	for _, user := range r.users {
+		if after != "" && user.Email <= after {
+			continue
+		}
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(user.Email), search) {
+			continue
+		}
+		users = append(users, user)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Email < users[j].Email })
+
+	page := Domain.UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users = users[:filter.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *memoryUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	if disabled && r.isLastAdmin(user) {
+		return Domain.User{}, Domain.ErrLastAdmin
+	}
+	user.Disabled = disabled
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) SetVerified(ctx context.Context, id string, verified bool) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	user.Verified = verified
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) SetPassword(ctx context.Context, id string, hash string) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	user.Password = hash
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) NextTokenGeneration(ctx context.Context, id string) (Domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	user.TokenGeneration++
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return Domain.ErrUserNotFound
+	}
+	if r.isLastAdmin(user) {
+		return Domain.ErrLastAdmin
+	}
+	delete(r.users, id)
+	delete(r.byEmail, user.Email)
+	return nil
+}
+
+// isLastAdmin reports whether user is the only enabled admin. r.mu must be
+// held for writing.
+func (r *memoryUserRepository) isLastAdmin(user Domain.User) bool {
+	if user.Role != Domain.RoleAdmin || user.Disabled {
+		return false
+	}
+	for id, other := range r.users {
+		if id != user.ID && other.Role == Domain.RoleAdmin && !other.Disabled {
+			return false
+		}
+	}
+	return true
+}
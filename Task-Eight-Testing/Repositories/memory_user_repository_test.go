@@ -0,0 +1,187 @@
+package Repositories_test
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryUserRepository_Create_AssignsRolesCorrectly(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+
+	created1, err := repo.Create(context.Background(), createTestUser("admin@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", created1.Role)
+
+	created2, err := repo.Create(context.Background(), createTestUser("user@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.DefaultRole, created2.Role)
+}
+
+func TestMemoryUserRepository_Create_DuplicateEmail(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+	user := createTestUser("duplicate@example.com")
+
+	_, err := repo.Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	_, err = repo.Create(context.Background(), user)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrEmailTaken)
+}
+
+func TestMemoryUserRepository_FindByEmail(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+	created, _ := repo.Create(context.Background(), createTestUser("findme@example.com"))
+
+	fetched, err := repo.FindByEmail(context.Background(), "findme@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, fetched.ID)
+
+	_, err = repo.FindByEmail(context.Background(), "nonexistent@example.com")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestMemoryUserRepository_SetRole(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+	_, _ = repo.Create(context.Background(), createTestUser("first@example.com"))
+	second, _ := repo.Create(context.Background(), createTestUser("second@example.com"))
+
+	updated, err := repo.SetRole(context.Background(), second.ID, Domain.RoleManager)
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.RoleManager, updated.Role)
+
+	_, err = repo.SetRole(context.Background(), "not-a-valid-hex", Domain.RoleManager)
+	assert.Error(t, err)
+}
+
+func TestMemoryUserRepository_ConcurrentRegistrationsHaveOneAdmin(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+
+	var wg sync.WaitGroup
+	roles := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := repo.Create(context.Background(), createTestUser(string(rune('a'+i))+"@example.com"))
+			if err == nil {
+				roles <- user.Role
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(roles)
+
+	admins := 0
+	for role := range roles {
+		if role == "admin" {
+			admins++
+		}
+	}
+	assert.Equal(t, 1, admins)
+}
+
+// testUserManagement checks the listing and account management every
+// IUserRepository must share.
+func testUserManagement(t *testing.T, repo Domain.IUserRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	admin, _ := repo.Create(ctx, createTestUser("admin@example.com"))
+	for _, email := range []string{"carol@example.com", "Bob@Example.com", "dave@other.org", "under_score@example.com"} {
+		_, err := repo.Create(ctx, createTestUser(email))
+		assert.NoError(t, err)
+	}
+
+	first, err := repo.List(ctx, Domain.UserFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob@Example.com", "admin@example.com"}, userEmails(first.Users))
+	assert.NotEmpty(t, first.NextCursor)
+
+	second, err := repo.List(ctx, Domain.UserFilter{Limit: 2, Cursor: first.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"carol@example.com", "dave@other.org"}, userEmails(second.Users))
+
+	last, err := repo.List(ctx, Domain.UserFilter{Limit: 2, Cursor: second.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"under_score@example.com"}, userEmails(last.Users))
+	assert.Empty(t, last.NextCursor)
+
+	found, err := repo.List(ctx, Domain.UserFilter{Search: "EXAMPLE", Role: Domain.DefaultRole, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob@Example.com", "carol@example.com", "under_score@example.com"}, userEmails(found.Users))
+
+	wildcard, err := repo.List(ctx, Domain.UserFilter{Search: "_", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"under_score@example.com"}, userEmails(wildcard.Users))
+
+	_, err = repo.List(ctx, Domain.UserFilter{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
+
+	_, err = repo.SetDisabled(ctx, admin.ID, true)
+	assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+	_, err = repo.SetRole(ctx, admin.ID, Domain.DefaultRole)
+	assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+	assert.ErrorIs(t, repo.Delete(ctx, admin.ID), Domain.ErrLastAdmin)
+	fetched, _ := repo.FindByID(ctx, admin.ID)
+	assert.Equal(t, Domain.RoleAdmin, fetched.Role)
+	assert.False(t, fetched.Disabled)
+
+	carol, _ := repo.FindByEmail(ctx, "carol@example.com")
+	_, err = repo.SetRole(ctx, carol.ID, Domain.RoleAdmin)
+	assert.NoError(t, err)
+
+	disabled, err := repo.SetDisabled(ctx, admin.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, disabled.Disabled)
+	fetched, _ = repo.FindByEmail(ctx, admin.Email)
+	assert.True(t, fetched.Disabled)
+
+	verified, err := repo.SetVerified(ctx, admin.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, verified.Verified)
+	fetched, _ = repo.FindByID(ctx, admin.ID)
+	assert.True(t, fetched.Verified)
+	assert.True(t, fetched.Disabled, "setting one flag keeps the others")
+
+	updated, err := repo.SetPassword(ctx, admin.ID, "new-hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-hash", updated.Password)
+	fetched, _ = repo.FindByID(ctx, admin.ID)
+	assert.Equal(t, "new-hash", fetched.Password)
+
+	updated, err = repo.NextTokenGeneration(ctx, admin.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated.TokenGeneration)
+	_, _ = repo.NextTokenGeneration(ctx, admin.ID)
+	fetched, _ = repo.FindByID(ctx, admin.ID)
+	assert.Equal(t, 2, fetched.TokenGeneration)
+
+	assert.NoError(t, repo.Delete(ctx, admin.ID))
+	_, err = repo.FindByID(ctx, admin.ID)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, admin.ID), Domain.ErrUserNotFound)
+	_, err = repo.SetDisabled(ctx, admin.ID, false)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	_, err = repo.Create(ctx, createTestUser(admin.Email))
+	assert.NoError(t, err, "a deleted user's email can be registered again")
+}
+
+func userEmails(users []Domain.User) []string {
+	emails := []string{}
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+	return emails
+}
+
+func TestMemoryUserRepository_Management(t *testing.T) {
+	testUserManagement(t, Repositories.NewMemoryUserRepository())
+}
@@ -0,0 +1,142 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// maxConnectBackoff caps the wait between connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
+// Collections names the Mongo collection behind each repository.
+type Collections struct {
+	Tasks         string
+	Users         string
+	Roles         string
+	Audit         string
+	RevokedTokens string
+	OneTimeTokens string
+	LoginAttempts string
+}
+
+func DefaultCollections() Collections {
+	return Collections{
+		Tasks:         "tasks",
+		Users:         "user",
+		Roles:         "roles",
+		Audit:         "audit_log",
+		RevokedTokens: "revoked_tokens",
+		OneTimeTokens: "one_time_tokens",
+		LoginAttempts: "login_attempts",
+	}
+}
+
+// MongoOptions configures the connection the Mongo repositories share.
+type MongoOptions struct {
+	URI         string
+	Database    string
+	Collections Collections
+	Timeouts    Timeouts
+
+	// MaxPoolSize and MinPoolSize bound the open connections per server;
+	// idle connections above the minimum close after MaxConnIdleTime.
+	MaxPoolSize     uint64
+	MinPoolSize     uint64
+	MaxConnIdleTime time.Duration
+	// ServerSelectionTimeout is how long an operation waits for a usable
+	// server before failing.
+	ServerSelectionTimeout time.Duration
+
+	// ConnectAttempts is how often ConnectMongo tries to reach the server,
+	// waiting ConnectBackoff after the first failure and doubling the wait
+	// after each further one.
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+func DefaultMongoOptions() MongoOptions {
+	return MongoOptions{
+		Database:               "task_db",
+		Collections:            DefaultCollections(),
+		Timeouts:               DefaultTimeouts(),
+		MaxPoolSize:            100,
+		MaxConnIdleTime:        5 * time.Minute,
+		ServerSelectionTimeout: 5 * time.Second,
+		ConnectAttempts:        5,
+		ConnectBackoff:         time.Second,
+	}
+}
+
+// MongoDatabase owns the single pooled client behind every Mongo
+// repository and hands out their collections.
+type MongoDatabase struct {
+	client      *mongo.Client
+	database    *mongo.Database
+	collections Collections
+	timeouts    Timeouts
+}
+
+// ConnectMongo connects to the server and waits until it answers, retrying
+// with backoff so the service can start alongside its database. It gives
+// up after opts.ConnectAttempts tries or when ctx is cancelled.
+func ConnectMongo(ctx context.Context, opts MongoOptions) (*MongoDatabase, error) {
+	clientOpts := options.Client().
+		ApplyURI(opts.URI).
+		SetMaxPoolSize(opts.MaxPoolSize).
+		SetMinPoolSize(opts.MinPoolSize).
+		SetMaxConnIdleTime(opts.MaxConnIdleTime).
+		SetServerSelectionTimeout(opts.ServerSelectionTimeout).
+		SetMonitor(commandMonitor())
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := max(opts.ConnectAttempts, 1)
+	backoff := opts.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = client.Ping(ctx, readpref.Primary())
+		if err == nil {
+			break
+		}
+		if attempt == attempts {
+			_ = client.Disconnect(context.Background())
+			return nil, fmt.Errorf("mongo unreachable after %d attempts: %w", attempts, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = client.Disconnect(context.Background())
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	return &MongoDatabase{
+		client:      client,
+		database:    client.Database(opts.Database),
+		collections: opts.Collections,
+		timeouts:    opts.Timeouts,
+	}, nil
+}
+
+func (d *MongoDatabase) Collection(name string) *mongo.Collection {
+	return d.database.Collection(name)
+}
+
+// Ping checks that the primary answers.
+func (d *MongoDatabase) Ping(ctx context.Context) error {
+	return d.client.Ping(ctx, readpref.Primary())
+}
+
+// Disconnect closes the pool once in-flight operations have finished or
+// ctx expires. The repositories stop working afterwards.
+func (d *MongoDatabase) Disconnect(ctx context.Context) error {
+	return d.client.Disconnect(ctx)
+}
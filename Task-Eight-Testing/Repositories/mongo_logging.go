@@ -0,0 +1,32 @@
+package Repositories
+
+import (
+	"context"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// commandMonitor logs every command the driver sends. The driver hands it
+// the context of the operation, so each line carries the fields of the
+// request that caused it. Command bodies are never logged: they hold
+// password hashes and token IDs.
+func commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			slog.DebugContext(ctx, "mongo command",
+				"command", e.CommandName,
+				"database", e.DatabaseName,
+				"duration", e.Duration,
+			)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			slog.WarnContext(ctx, "mongo command failed",
+				"command", e.CommandName,
+				"database", e.DatabaseName,
+				"duration", e.Duration,
+				"error", e.Failure,
+			)
+		},
+	}
+}
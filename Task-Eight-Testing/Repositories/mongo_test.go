@@ -0,0 +1,53 @@
+package Repositories_test
+
+import (
+	"context"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func unreachableMongo() Repositories.MongoOptions {
+	opts := Repositories.DefaultMongoOptions()
+	opts.URI = "mongodb://127.0.0.1:1/?connectTimeoutMS=50"
+	opts.ServerSelectionTimeout = 50 * time.Millisecond
+	opts.ConnectBackoff = 10 * time.Millisecond
+	return opts
+}
+
+func TestConnectMongo_GivesUpAfterAttempts(t *testing.T) {
+	opts := unreachableMongo()
+	opts.ConnectAttempts = 3
+
+	start := time.Now()
+	db, err := Repositories.ConnectMongo(context.Background(), opts)
+
+	assert.Nil(t, db)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "after 3 attempts")
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "waits 10ms, then 20ms between attempts")
+}
+
+func TestConnectMongo_StopsWhenCancelled(t *testing.T) {
+	opts := unreachableMongo()
+	opts.ConnectAttempts = 100
+	opts.ConnectBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := Repositories.ConnectMongo(ctx, opts)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestConnectMongo_RejectsBadURI(t *testing.T) {
+	opts := Repositories.DefaultMongoOptions()
+	opts.URI = "not-a-mongo-uri"
+
+	_, err := Repositories.ConnectMongo(context.Background(), opts)
+
+	assert.Error(t, err)
+}
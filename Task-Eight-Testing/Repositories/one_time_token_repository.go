@@ -0,0 +1,79 @@
+package Repositories
+
+import (
+	"context"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type oneTimeTokenRepository struct {
+	tokenCollection *mongo.Collection
+	timeouts        Timeouts
+}
+
+func NewOneTimeTokenRepository(ctx context.Context, db *MongoDatabase) (Domain.IOneTimeTokenRepository, error) {
+	collection := db.Collection(db.collections.OneTimeTokens)
+	if err := ensureOneTimeTokenIndexes(ctx, collection); err != nil {
+		return nil, err
+	}
+	return &oneTimeTokenRepository{tokenCollection: collection, timeouts: db.timeouts}, nil
+}
+
+func NewOneTimeTokenRepositoryWithCollection(collection *mongo.Collection) Domain.IOneTimeTokenRepository {
+	_ = ensureOneTimeTokenIndexes(context.Background(), collection)
+	return &oneTimeTokenRepository{tokenCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// ensureOneTimeTokenIndexes lets Mongo drop expired tokens and covers the
+// lookup Save uses to replace a user's earlier token.
+func ensureOneTimeTokenIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+	})
+	return err
+}
+
+type oneTimeTokenDocument struct {
+	Hash      string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	Purpose   string    `bson:"purpose"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func (r *oneTimeTokenRepository) Save(ctx context.Context, token Domain.OneTimeToken) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := r.tokenCollection.DeleteMany(ctx, bson.M{"user_id": token.UserID, "purpose": token.Purpose}); err != nil {
+		return err
+	}
+	_, err := r.tokenCollection.InsertOne(ctx, oneTimeTokenDocument{
+		Hash:      token.Hash,
+		UserID:    token.UserID,
+		Purpose:   token.Purpose,
+		ExpiresAt: token.ExpiresAt,
+	})
+	return err
+}
+
+func (r *oneTimeTokenRepository) Consume(ctx context.Context, purpose, hash string) (Domain.OneTimeToken, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	var doc oneTimeTokenDocument
+	err := r.tokenCollection.FindOneAndDelete(ctx, bson.M{"_id": hash, "purpose": purpose}).Decode(&doc)
+	if err != nil {
+		return Domain.OneTimeToken{}, notFound(err, Domain.ErrTokenNotFound)
+	}
+	return Domain.OneTimeToken{
+		Hash:      doc.Hash,
+		UserID:    doc.UserID,
+		Purpose:   doc.Purpose,
+		ExpiresAt: doc.ExpiresAt,
+	}, nil
+}
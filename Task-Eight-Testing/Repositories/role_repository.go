@@ -0,0 +1,100 @@
+package Repositories
+
+import (
+	"context"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyUserRole is the role every non-admin held before named roles.
+const legacyUserRole = "user"
+
+type roleRepository struct {
+	roleCollection *mongo.Collection
+	timeouts       Timeouts
+}
+
+// NewRoleRepository needs no setup: roles are keyed by name, which the _id
+// index already keeps unique.
+func NewRoleRepository(db *MongoDatabase) Domain.IRoleRepository {
+	return &roleRepository{roleCollection: db.Collection(db.collections.Roles), timeouts: db.timeouts}
+}
+
+func NewRoleRepositoryWithCollection(collection *mongo.Collection) Domain.IRoleRepository {
+	return &roleRepository{roleCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// roleDocument is keyed by the role name, which keeps names unique.
+type roleDocument struct {
+	Name        string   `bson:"_id"`
+	Permissions []string `bson:"permissions"`
+}
+
+func (d roleDocument) role() Domain.Role {
+	permissions := d.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return Domain.Role{Name: d.Name, Permissions: permissions}
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]Domain.Role, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	cursor, err := r.roleCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []Domain.Role{}
+	for cursor.Next(ctx) {
+		var doc roleDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		roles = append(roles, doc.role())
+	}
+	return roles, cursor.Err()
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (Domain.Role, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var doc roleDocument
+	if err := r.roleCollection.FindOne(ctx, bson.M{"_id": name}).Decode(&doc); err != nil {
+		return Domain.Role{}, notFound(err, Domain.ErrRoleNotFound)
+	}
+	return doc.role(), nil
+}
+
+func (r *roleRepository) Save(ctx context.Context, role Domain.Role) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.roleCollection.ReplaceOne(ctx,
+		bson.M{"_id": role.Name},
+		roleDocument{Name: role.Name, Permissions: role.Permissions},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	res, err := r.roleCollection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return Domain.ErrRoleNotFound
+	}
+	return nil
+}
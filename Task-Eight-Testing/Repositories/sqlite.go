@@ -0,0 +1,105 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+    id          TEXT PRIMARY KEY,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL,
+    due_date    INTEGER NOT NULL,
+    status      TEXT NOT NULL,
+    owner       TEXT NOT NULL DEFAULT '',
+    version     INTEGER NOT NULL DEFAULT 0,
+    updated_at  INTEGER NOT NULL DEFAULT 0,
+    deleted_at  INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS tasks_due_date ON tasks (due_date, id);
+CREATE INDEX IF NOT EXISTS tasks_title ON tasks (title, id);
+CREATE INDEX IF NOT EXISTS tasks_status ON tasks (status, due_date, id);
+CREATE INDEX IF NOT EXISTS tasks_owner ON tasks (owner, status, due_date, id);
+CREATE INDEX IF NOT EXISTS tasks_deleted_at ON tasks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id               TEXT PRIMARY KEY,
+    email            TEXT NOT NULL UNIQUE,
+    password         TEXT NOT NULL,
+    role             TEXT NOT NULL,
+    disabled         INTEGER NOT NULL DEFAULT 0,
+    verified         INTEGER NOT NULL DEFAULT 0,
+    token_generation INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        TEXT PRIMARY KEY,
+    permissions TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id         TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    hash       TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    purpose    TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS one_time_tokens_user ON one_time_tokens (user_id, purpose);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id        TEXT PRIMARY KEY,
+    actor     TEXT NOT NULL,
+    action    TEXT NOT NULL,
+    entity    TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    changes   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_timestamp ON audit_log (timestamp);
+CREATE INDEX IF NOT EXISTS audit_log_actor ON audit_log (actor, timestamp);
+CREATE INDEX IF NOT EXISTS audit_log_entity ON audit_log (entity, entity_id, timestamp);
+`
+
+// SQLiteDatabase is the database file behind every SQLite repository,
+// together with the timeouts that bound their operations.
+type SQLiteDatabase struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+// OpenSQLite opens the database file at path, creating it and the schema if
+// they do not exist yet. SQLite allows a single writer, so the pool is
+// limited to one connection to avoid "database is locked" errors.
+func OpenSQLite(path string, timeouts Timeouts) (*SQLiteDatabase, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteDatabase{db: db, timeouts: timeouts}, nil
+}
+
+func (d *SQLiteDatabase) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *SQLiteDatabase) Close() error {
+	return d.db.Close()
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
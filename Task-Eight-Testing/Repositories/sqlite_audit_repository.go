@@ -0,0 +1,98 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type sqliteAuditRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteAuditRepository(db *SQLiteDatabase) Domain.IAuditRepository {
+	return &sqliteAuditRepository{db: db.db, timeouts: db.timeouts}
+}
+
+const auditColumns = "id, actor, action, entity, entity_id, timestamp, changes"
+
+// Record stores the field changes as a JSON object in the changes column.
+func (r *sqliteAuditRepository) Record(ctx context.Context, entry Domain.AuditEntry) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO audit_log ("+auditColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		primitive.NewObjectID().Hex(), entry.Actor, entry.Action, entry.Entity, entry.EntityID, entry.Timestamp.UnixNano(), string(changes),
+	)
+	return err
+}
+
+func (r *sqliteAuditRepository) List(ctx context.Context, filter Domain.AuditFilter) ([]Domain.AuditEntry, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var where []string
+	var args []interface{}
+	for _, condition := range []struct {
+		column string
+		value  string
+	}{
+		{"actor", filter.Actor},
+		{"action", filter.Action},
+		{"entity", filter.Entity},
+		{"entity_id", filter.EntityID},
+	} {
+		if condition.value != "" {
+			where = append(where, condition.column+" = ?")
+			args = append(args, condition.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "timestamp < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Domain.AuditEntry{}
+	for rows.Next() {
+		var entry Domain.AuditEntry
+		var timestamp int64
+		var changes string
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityID, &timestamp, &changes); err != nil {
+			return nil, err
+		}
+		entry.Timestamp = time.Unix(0, timestamp).UTC()
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
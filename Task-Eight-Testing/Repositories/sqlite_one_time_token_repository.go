@@ -0,0 +1,66 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"task-manager/Domain"
+	"time"
+)
+
+type sqliteOneTimeTokenRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteOneTimeTokenRepository(db *SQLiteDatabase) Domain.IOneTimeTokenRepository {
+	return &sqliteOneTimeTokenRepository{db: db.db, timeouts: db.timeouts}
+}
+
+// Save also drops expired tokens; SQLite has no TTL index to do it.
+func (r *sqliteOneTimeTokenRepository) Save(ctx context.Context, token Domain.OneTimeToken) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM one_time_tokens WHERE expires_at <= ? OR (user_id = ? AND purpose = ?)",
+		time.Now().UnixNano(), token.UserID, token.Purpose,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO one_time_tokens (hash, user_id, purpose, expires_at) VALUES (?, ?, ?, ?)",
+		token.Hash, token.UserID, token.Purpose, token.ExpiresAt.UnixNano(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Consume deletes and reads the token in one statement, so concurrent
+// callers cannot both use it.
+func (r *sqliteOneTimeTokenRepository) Consume(ctx context.Context, purpose, hash string) (Domain.OneTimeToken, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	var token Domain.OneTimeToken
+	var expiresAt int64
+	err := r.db.QueryRowContext(ctx,
+		"DELETE FROM one_time_tokens WHERE hash = ? AND purpose = ? RETURNING hash, user_id, purpose, expires_at",
+		hash, purpose,
+	).Scan(&token.Hash, &token.UserID, &token.Purpose, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.OneTimeToken{}, Domain.ErrTokenNotFound
+	}
+	if err != nil {
+		return Domain.OneTimeToken{}, err
+	}
+	token.ExpiresAt = time.Unix(0, expiresAt)
+	return token, nil
+}
@@ -0,0 +1,95 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"task-manager/Domain"
+)
+
+type sqliteRoleRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteRoleRepository(db *SQLiteDatabase) Domain.IRoleRepository {
+	return &sqliteRoleRepository{db: db.db, timeouts: db.timeouts}
+}
+
+// scanRole decodes the permissions column, which holds a JSON array.
+func scanRole(row rowScanner) (Domain.Role, error) {
+	var role Domain.Role
+	var permissions string
+	err := row.Scan(&role.Name, &permissions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.Role{}, Domain.ErrRoleNotFound
+	}
+	if err != nil {
+		return Domain.Role{}, err
+	}
+	if err := json.Unmarshal([]byte(permissions), &role.Permissions); err != nil {
+		return Domain.Role{}, err
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	return role, nil
+}
+
+func (r *sqliteRoleRepository) List(ctx context.Context) ([]Domain.Role, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT name, permissions FROM roles ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []Domain.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *sqliteRoleRepository) FindByName(ctx context.Context, name string) (Domain.Role, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	return scanRole(r.db.QueryRowContext(ctx, "SELECT name, permissions FROM roles WHERE name = ?", name))
+}
+
+func (r *sqliteRoleRepository) Save(ctx context.Context, role Domain.Role) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO roles (name, permissions) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET permissions = excluded.permissions",
+		role.Name, string(permissions),
+	)
+	return err
+}
+
+func (r *sqliteRoleRepository) Delete(ctx context.Context, name string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Domain.ErrRoleNotFound
+	}
+	return nil
+}
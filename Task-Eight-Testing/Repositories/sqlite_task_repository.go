@@ -0,0 +1,326 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type sqliteTaskRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteTaskRepository(db *SQLiteDatabase) Domain.ITaskRepository {
+	return &sqliteTaskRepository{db: db.db, timeouts: db.timeouts}
+}
+
+// sqliteSortColumns maps the public sort fields onto table columns.
+var sqliteSortColumns = map[string]string{
+	Domain.SortByCreated: "id",
+	Domain.SortByDueDate: "due_date",
+	Domain.SortByTitle:   "title",
+}
+
+// deleted_at is 0 for live tasks and the trashing time for trashed ones.
+const taskColumns = "id, title, description, due_date, status, owner, version, updated_at, deleted_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (Domain.Task, error) {
+	var task Domain.Task
+	var due, updated, deleted int64
+	if err := row.Scan(&task.ID, &task.Title, &task.Description, &due, &task.Status, &task.Owner, &task.Version, &updated, &deleted); err != nil {
+		return Domain.Task{}, err
+	}
+	task.DueDate = time.Unix(0, due).UTC()
+	if updated != 0 {
+		task.UpdatedAt = time.Unix(0, updated).UTC()
+	}
+	if deleted != 0 {
+		task.DeletedAt = time.Unix(0, deleted).UTC()
+	}
+	return task, nil
+}
+
+func (r *sqliteTaskRepository) queryTasks(ctx context.Context, query string, args ...interface{}) ([]Domain.Task, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *sqliteTaskRepository) Create(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	task.ID = primitive.NewObjectID().Hex()
+	task.Version = 1
+	task.UpdatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO tasks ("+taskColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0)",
+		task.ID, task.Title, task.Description, task.DueDate.UnixNano(), task.Status, task.Owner, task.Version, task.UpdatedAt.UnixNano(),
+	)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	return task, nil
+}
+
+func (r *sqliteTaskRepository) GetByID(ctx context.Context, id string) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, err
+	}
+
+	row := r.db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ? AND deleted_at = 0", id)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.Task{}, Domain.ErrTaskNotFound
+	}
+	return task, err
+}
+
+func (r *sqliteTaskRepository) List(ctx context.Context, filter Domain.TaskFilter) (Domain.TaskPage, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	field, desc, err := Domain.ParseTaskSort(filter.Sort)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultTaskPageSize
+	}
+
+	where := []string{"deleted_at = 0"}
+	var args []interface{}
+	if filter.Owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, filter.Owner)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.DueBefore.IsZero() {
+		where = append(where, "due_date < ?")
+		args = append(args, filter.DueBefore.UnixNano())
+	}
+	if !filter.DueAfter.IsZero() {
+		where = append(where, "due_date >= ?")
+		args = append(args, filter.DueAfter.UnixNano())
+	}
+
+	column := sqliteSortColumns[field]
+	order, cmp := "ASC", ">"
+	if desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		last, err := decodeListCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return Domain.TaskPage{}, err
+		}
+		if column == "id" {
+			where = append(where, "id "+cmp+" ?")
+			args = append(args, last.ID)
+		} else {
+			var lastValue interface{} = last.Title
+			if field == Domain.SortByDueDate {
+				lastValue = last.DueDate.UnixNano()
+			}
+			where = append(where, "("+column+" "+cmp+" ? OR ("+column+" = ? AND id "+cmp+" ?))")
+			args = append(args, lastValue, lastValue, last.ID)
+		}
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY " + column + " " + order
+	if column != "id" {
+		query += ", id " + order
+	}
+	query += " LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	tasks, err := r.queryTasks(ctx, query, args...)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if tasks == nil {
+		tasks = []Domain.Task{}
+	}
+
+	page := Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > filter.Limit {
+		page.Tasks = tasks[:filter.Limit]
+		page.NextCursor = newListCursor(filter.Sort, page.Tasks[filter.Limit-1]).encode()
+	}
+	return page, nil
+}
+
+func (r *sqliteTaskRepository) Update(ctx context.Context, id string, updatedTask Domain.Task) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, err
+	}
+
+	updatedAt := time.Now().UTC()
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE tasks SET title = ?, description = ?, due_date = ?, status = ?, version = version + 1, updated_at = ? WHERE id = ? AND deleted_at = 0 AND version = ?",
+		updatedTask.Title, updatedTask.Description, updatedTask.DueDate.UnixNano(), updatedTask.Status, updatedAt.UnixNano(), id, updatedTask.Version,
+	)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE id = ? AND deleted_at = 0", id).Scan(&exists); err != nil {
+			return Domain.Task{}, err
+		}
+		if exists == 0 {
+			return Domain.Task{}, Domain.ErrTaskNotFound
+		}
+		return Domain.Task{}, Domain.ErrVersionConflict
+	}
+
+	updatedTask.ID = id
+	updatedTask.Version++
+	updatedTask.UpdatedAt = updatedAt
+	return updatedTask, nil
+}
+
+func (r *sqliteTaskRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().UnixNano()
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE tasks SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at = 0",
+		now, now, id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Domain.ErrTaskNotFound
+	}
+	return nil
+}
+
+func (r *sqliteTaskRepository) ListTrash(ctx context.Context, owner string) ([]Domain.Task, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE deleted_at != 0"
+	var args []interface{}
+	if owner != "" {
+		query += " AND owner = ?"
+		args = append(args, owner)
+	}
+	tasks, err := r.queryTasks(ctx, query+" ORDER BY deleted_at DESC, id DESC", args...)
+	if tasks == nil {
+		tasks = []Domain.Task{}
+	}
+	return tasks, err
+}
+
+// Restore reads the deletion time and clears it in one transaction, so the
+// time it reports is the one it cleared.
+func (r *sqliteTaskRepository) Restore(ctx context.Context, id string, owner string) (Domain.Task, time.Time, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT deleted_at FROM tasks WHERE id = ? AND deleted_at != 0"
+	args := []interface{}{id}
+	if owner != "" {
+		query += " AND owner = ?"
+		args = append(args, owner)
+	}
+	var deleted int64
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.Task{}, time.Time{}, Domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE tasks SET deleted_at = 0, updated_at = ?, version = version + 1 WHERE id = ?",
+		time.Now().UTC().UnixNano(), id,
+	); err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+	task, err := scanTask(tx.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+	if err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+	return task, time.Unix(0, deleted).UTC(), nil
+}
+
+func (r *sqliteTaskRepository) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "DELETE FROM tasks WHERE deleted_at != 0 AND deleted_at < ? RETURNING id", before.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purged []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		purged = append(purged, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(purged)
+	return purged, nil
+}
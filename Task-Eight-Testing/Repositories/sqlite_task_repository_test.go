@@ -0,0 +1,259 @@
+package Repositories_test
+
+import (
+	"context"
+	"path/filepath"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSQLiteDB(t *testing.T) *Repositories.SQLiteDatabase {
+	t.Helper()
+
+	db, err := Repositories.OpenSQLite(filepath.Join(t.TempDir(), "test.db"), Repositories.DefaultTimeouts())
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteTaskRepository_Create_Success(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+	task := createSampleTask()
+
+	created, err := repo.Create(context.Background(), task)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, task.Title, created.Title)
+	assert.Equal(t, task.Description, created.Description)
+	assert.Equal(t, task.Status, created.Status)
+	assert.True(t, task.DueDate.Equal(created.DueDate))
+
+	fetched, err := repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, fetched.ID)
+	assert.True(t, task.DueDate.Equal(fetched.DueDate))
+}
+
+func TestSQLiteTaskRepository_GetByID_NotFound(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	_, err := repo.GetByID(context.Background(), "507f1f77bcf86cd799439011")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestSQLiteTaskRepository_GetByID_InvalidID(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	_, err := repo.GetByID(context.Background(), "invalid-id")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrInvalidID)
+}
+
+func TestSQLiteTaskRepository_List_ReturnsEveryTask(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	task1 := createSampleTask()
+	task2 := Domain.Task{Title: "Another Task", Status: "completed", DueDate: time.Now()}
+	_, _ = repo.Create(context.Background(), task1)
+	_, _ = repo.Create(context.Background(), task2)
+
+	tasks := listAll(t, repo)
+	assert.Len(t, tasks, 2)
+
+	titles := []string{tasks[0].Title, tasks[1].Title}
+	assert.Contains(t, titles, task1.Title)
+	assert.Contains(t, titles, task2.Title)
+}
+
+func TestSQLiteTaskRepository_List_PaginatesWithCursor(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		task := createSampleTask()
+		task.DueDate = base.Add(time.Duration(i%3) * time.Hour)
+		_, err := repo.Create(context.Background(), task)
+		assert.NoError(t, err)
+	}
+
+	filter := Domain.TaskFilter{Sort: "-due_date", Limit: 2}
+	seen := map[string]bool{}
+	var last time.Time
+	for {
+		page, err := repo.List(context.Background(), filter)
+		assert.NoError(t, err)
+		for _, task := range page.Tasks {
+			if !last.IsZero() {
+				assert.False(t, task.DueDate.After(last))
+			}
+			last = task.DueDate
+			seen[task.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Len(t, seen, 5)
+}
+
+func TestSQLiteTaskRepository_List_Filters(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	early := createSampleTask()
+	early.Owner = "me@example.com"
+	late := createSampleTask()
+	late.Owner = "me@example.com"
+	late.DueDate = early.DueDate.Add(48 * time.Hour)
+	other := createSampleTask()
+	other.Owner = "them@example.com"
+
+	_, _ = repo.Create(context.Background(), early)
+	_, _ = repo.Create(context.Background(), late)
+	_, _ = repo.Create(context.Background(), other)
+
+	page, err := repo.List(context.Background(), Domain.TaskFilter{
+		Owner:     "me@example.com",
+		Status:    "pending",
+		DueBefore: early.DueDate.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestSQLiteTaskRepository_Update_Success(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	created, _ := repo.Create(context.Background(), createSampleTask())
+	update := Domain.Task{
+		Title:       "Updated Task",
+		Description: "Updated Desc",
+		Status:      "completed",
+		DueDate:     time.Now(),
+		Version:     created.Version,
+	}
+
+	updatedTask, err := repo.Update(context.Background(), created.ID, update)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, updatedTask.ID)
+	assert.Equal(t, update.Title, updatedTask.Title)
+	assert.Equal(t, created.Version+1, updatedTask.Version)
+
+	fetched, _ := repo.GetByID(context.Background(), created.ID)
+	assert.Equal(t, update.Title, fetched.Title)
+	assert.Equal(t, update.Status, fetched.Status)
+	assert.Equal(t, updatedTask.Version, fetched.Version)
+	assert.False(t, fetched.UpdatedAt.IsZero())
+}
+
+func TestSQLiteTaskRepository_Update_StaleVersion(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	created, _ := repo.Create(context.Background(), createSampleTask())
+	assert.Equal(t, int64(1), created.Version)
+
+	first := created
+	first.Title = "First writer"
+	updated, err := repo.Update(context.Background(), created.ID, first)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	second := created
+	second.Title = "Second writer"
+	_, err = repo.Update(context.Background(), created.ID, second)
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+
+	fetched, _ := repo.GetByID(context.Background(), created.ID)
+	assert.Equal(t, "First writer", fetched.Title)
+	assert.Equal(t, int64(2), fetched.Version)
+}
+
+func TestSQLiteTaskRepository_Update_NotFound(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	_, err := repo.Update(context.Background(), "507f1f77bcf86cd799439011", createSampleTask())
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestSQLiteTaskRepository_Delete_Success(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	created, _ := repo.Create(context.Background(), createSampleTask())
+
+	err := repo.Delete(context.Background(), created.ID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetByID(context.Background(), created.ID)
+	assert.Error(t, err)
+}
+
+func TestSQLiteTaskRepository_Delete_NotFound(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+
+	err := repo.Delete(context.Background(), "507f1f77bcf86cd799439011")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestSQLiteTaskRepository_TrashAndRestore(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+	task := createSampleTask()
+	task.Owner = "owner@example.com"
+	created, _ := repo.Create(context.Background(), task)
+
+	assert.NoError(t, repo.Delete(context.Background(), created.ID))
+
+	_, err := repo.GetByID(context.Background(), created.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = repo.Update(context.Background(), created.ID, Domain.Task{Title: "Edited", Version: created.Version + 1})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.Empty(t, listAll(t, repo))
+
+	trash, err := repo.ListTrash(context.Background(), "owner@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+	trash, _ = repo.ListTrash(context.Background(), "someone@example.com")
+	assert.Empty(t, trash)
+
+	_, _, err = repo.Restore(context.Background(), created.ID, "someone@example.com")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	restored, trashedAt, err := repo.Restore(context.Background(), created.ID, "owner@example.com")
+	assert.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.WithinDuration(t, time.Now(), trashedAt, time.Minute)
+	assert.Equal(t, created.Version+2, restored.Version)
+
+	_, err = repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+}
+
+func TestSQLiteTaskRepository_PurgeTrash(t *testing.T) {
+	repo := Repositories.NewSQLiteTaskRepository(setupSQLiteDB(t))
+	trashed, _ := repo.Create(context.Background(), createSampleTask())
+	live, _ := repo.Create(context.Background(), createSampleTask())
+	assert.NoError(t, repo.Delete(context.Background(), trashed.ID))
+
+	purged, err := repo.PurgeTrash(context.Background(), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = repo.PurgeTrash(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{trashed.ID}, purged)
+
+	trash, _ := repo.ListTrash(context.Background(), "")
+	assert.Empty(t, trash)
+	_, err = repo.GetByID(context.Background(), live.ID)
+	assert.NoError(t, err)
+}
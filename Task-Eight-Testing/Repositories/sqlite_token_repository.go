@@ -0,0 +1,64 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"task-manager/Domain"
+	"time"
+)
+
+type sqliteTokenRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteTokenRepository(db *SQLiteDatabase) Domain.ITokenRevocationStore {
+	return &sqliteTokenRepository{db: db.db, timeouts: db.timeouts}
+}
+
+// Revoke also drops expired revocations; SQLite has no TTL index to do it.
+func (r *sqliteTokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if err := r.prune(ctx); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO revoked_tokens (id, expires_at) VALUES (?, ?) ON CONFLICT(id) DO UPDATE SET expires_at = excluded.expires_at",
+		id, expiresAt.Unix(),
+	)
+	return err
+}
+
+// RevokeOnce relies on the primary key: of concurrent inserts for one token
+// only the first succeeds.
+func (r *sqliteTokenRepository) RevokeOnce(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if err := r.prune(ctx); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO revoked_tokens (id, expires_at) VALUES (?, ?)", id, expiresAt.Unix())
+	if isUniqueViolation(err) {
+		return Domain.ErrTokenRevoked
+	}
+	return err
+}
+
+func (r *sqliteTokenRepository) prune(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now().Unix())
+	return err
+}
+
+func (r *sqliteTokenRepository) IsRevoked(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM revoked_tokens WHERE id = ?", id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,282 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type sqliteUserRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSQLiteUserRepository(db *SQLiteDatabase) Domain.IUserRepository {
+	return &sqliteUserRepository{db: db.db, timeouts: db.timeouts}
+}
+
+const userColumns = "id, email, password, role, disabled, verified, token_generation"
+
+func scanUser(row rowScanner) (Domain.User, error) {
+	var user Domain.User
+	err := row.Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.Disabled, &user.Verified, &user.TokenGeneration)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *sqliteUserRepository) FindByEmail(ctx context.Context, email string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email))
+}
+
+func (r *sqliteUserRepository) FindByID(ctx context.Context, id string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+// Create counts existing users and inserts inside one transaction so the
+// first-user-is-admin rule holds; the unique index guards the email.
+func (r *sqliteUserRepository) Create(ctx context.Context, user Domain.User) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return Domain.User{}, fmt.Errorf("failed to check user count: %w", err)
+	}
+
+	if count == 0 {
+		user.Role = Domain.RoleAdmin
+	} else {
+		user.Role = Domain.DefaultRole
+	}
+	user.ID = primitive.NewObjectID().Hex()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, 0)",
+		user.ID, user.Email, user.Password, user.Role, user.Disabled, user.Verified,
+	)
+	if isUniqueViolation(err) {
+		return Domain.User{}, Domain.ErrEmailTaken
+	}
+	if err != nil {
+		return Domain.User{}, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Domain.User{}, err
+	}
+	return user, nil
+}
+
+func (r *sqliteUserRepository) SetRole(ctx context.Context, id string, role string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	query := "UPDATE users SET role = ? WHERE id = ?"
+	if role != Domain.RoleAdmin {
+		query += " AND " + notLastAdmin
+	}
+	res, err := r.db.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if err := r.checkChanged(ctx, res, id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+// notLastAdmin limits an UPDATE or DELETE of users to rows that are not the
+// only enabled admin. Being part of the statement, the check and the write
+// happen atomically.
+const notLastAdmin = `(role != '` + Domain.RoleAdmin + `' OR disabled != 0 OR EXISTS (
+	SELECT 1 FROM users AS other
+	WHERE other.id != users.id AND other.role = '` + Domain.RoleAdmin + `' AND other.disabled = 0))`
+
+// checkChanged explains a write guarded by notLastAdmin that changed no
+// row: either the user does not exist or it is the last admin.
+func (r *sqliteUserRepository) checkChanged(ctx context.Context, res sql.Result, id string) error {
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	var exists int
+	err := r.db.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return Domain.ErrLastAdmin
+}
+
+// likePattern escapes the LIKE wildcards in s and matches it anywhere.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+func (r *sqliteUserRepository) List(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultUserPageSize
+	}
+
+	var where []string
+	var args []any
+	if filter.Cursor != "" {
+		after, err := decodeUserCursor(filter.Cursor)
+		if err != nil {
+			return Domain.UserPage{}, err
+		}
+		where = append(where, "email > ?")
+		args = append(args, after)
+	}
+	if filter.Role != "" {
+		where = append(where, "role = ?")
+		args = append(args, filter.Role)
+	}
+	if filter.Search != "" {
+		// LIKE ignores ASCII case in SQLite.
+		where = append(where, `email LIKE ? ESCAPE '\'`)
+		args = append(args, likePattern(filter.Search))
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY email LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Domain.UserPage{}, err
+	}
+	defer rows.Close()
+
+	users := []Domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return Domain.UserPage{}, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return Domain.UserPage{}, err
+	}
+
+	page := Domain.UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users = users[:filter.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *sqliteUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	query := "UPDATE users SET disabled = ? WHERE id = ?"
+	if disabled {
+		query += " AND " + notLastAdmin
+	}
+	res, err := r.db.ExecContext(ctx, query, disabled, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if err := r.checkChanged(ctx, res, id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (r *sqliteUserRepository) SetVerified(ctx context.Context, id string, verified bool) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE users SET verified = ? WHERE id = ?", verified, id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (r *sqliteUserRepository) SetPassword(ctx context.Context, id string, hash string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", hash, id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (r *sqliteUserRepository) NextTokenGeneration(ctx context.Context, id string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return Domain.User{}, err
+	}
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE users SET token_generation = token_generation + 1 WHERE id = ?", id); err != nil {
+		return Domain.User{}, err
+	}
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (r *sqliteUserRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ? AND "+notLastAdmin, id)
+	if err != nil {
+		return err
+	}
+	return r.checkChanged(ctx, res, id)
+}
@@ -0,0 +1,128 @@
+package Repositories_test
+
+import (
+	"context"
+	"sync"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteUserRepository_Create_AssignsRolesCorrectly(t *testing.T) {
+	repo := Repositories.NewSQLiteUserRepository(setupSQLiteDB(t))
+
+	created1, err := repo.Create(context.Background(), createTestUser("admin@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", created1.Role)
+
+	created2, err := repo.Create(context.Background(), createTestUser("user@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.DefaultRole, created2.Role)
+}
+
+func TestSQLiteUserRepository_Create_DuplicateEmail(t *testing.T) {
+	repo := Repositories.NewSQLiteUserRepository(setupSQLiteDB(t))
+	user := createTestUser("duplicate@example.com")
+
+	_, err := repo.Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	_, err = repo.Create(context.Background(), user)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrEmailTaken)
+}
+
+func TestSQLiteUserRepository_FindByEmail(t *testing.T) {
+	repo := Repositories.NewSQLiteUserRepository(setupSQLiteDB(t))
+	created, _ := repo.Create(context.Background(), createTestUser("findme@example.com"))
+
+	fetched, err := repo.FindByEmail(context.Background(), "findme@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, fetched.ID)
+
+	_, err = repo.FindByEmail(context.Background(), "nonexistent@example.com")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestSQLiteUserRepository_SetRole(t *testing.T) {
+	repo := Repositories.NewSQLiteUserRepository(setupSQLiteDB(t))
+	_, _ = repo.Create(context.Background(), createTestUser("first@example.com"))
+	second, _ := repo.Create(context.Background(), createTestUser("second@example.com"))
+
+	updated, err := repo.SetRole(context.Background(), second.ID, Domain.RoleManager)
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.RoleManager, updated.Role)
+
+	_, err = repo.SetRole(context.Background(), "not-a-valid-hex", Domain.RoleManager)
+	assert.Error(t, err)
+}
+
+func TestSQLiteTokenRepository_RevokeAndCheck(t *testing.T) {
+	store := Repositories.NewSQLiteTokenRepository(setupSQLiteDB(t))
+
+	revoked, err := store.IsRevoked(context.Background(), "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, store.Revoke(context.Background(), "jti", time.Now().Add(time.Hour)))
+	assert.NoError(t, store.Revoke(context.Background(), "jti", time.Now().Add(2*time.Hour)))
+
+	revoked, err = store.IsRevoked(context.Background(), "jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestSQLiteTokenRepository_RevokeOnce(t *testing.T) {
+	store := Repositories.NewSQLiteTokenRepository(setupSQLiteDB(t))
+
+	assert.NoError(t, store.RevokeOnce(context.Background(), "jti", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, store.RevokeOnce(context.Background(), "jti", time.Now().Add(time.Hour)), Domain.ErrTokenRevoked)
+
+	// A token revoked by logout cannot be used once afterwards either.
+	assert.NoError(t, store.Revoke(context.Background(), "other", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, store.RevokeOnce(context.Background(), "other", time.Now().Add(time.Hour)), Domain.ErrTokenRevoked)
+}
+
+func TestSQLiteUserRepository_Management(t *testing.T) {
+	testUserManagement(t, Repositories.NewSQLiteUserRepository(setupSQLiteDB(t)))
+}
+
+func TestSQLiteUserRepository_ConcurrentDemotionsKeepAnAdmin(t *testing.T) {
+	repo := Repositories.NewSQLiteUserRepository(setupSQLiteDB(t))
+	ctx := context.Background()
+
+	first, _ := repo.Create(ctx, createTestUser("first@example.com"))
+	second, _ := repo.Create(ctx, createTestUser("second@example.com"))
+	_, err := repo.SetRole(ctx, second.ID, Domain.RoleAdmin)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, id := range []string{first.ID, second.ID} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			_, err := repo.SetRole(ctx, id, Domain.DefaultRole)
+			errs <- err
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+
+	refused := 0
+	for err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+			refused++
+		}
+	}
+	assert.Equal(t, 1, refused)
+
+	admins, err := repo.List(ctx, Domain.UserFilter{Role: Domain.RoleAdmin, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, admins.Users, 1)
+}
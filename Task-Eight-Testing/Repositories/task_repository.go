@@ -1,167 +1,414 @@
 package Repositories
 
 import (
-    "context"
-    "errors"
-    "os"
-    "task-manager/Domain"
-    "time"
-
-    "go.mongodb.org/mongo-driver/bson"
-    "go.mongodb.org/mongo-driver/bson/primitive"
-    "go.mongodb.org/mongo-driver/mongo"
-    "go.mongodb.org/mongo-driver/mongo/options"
+	"context"
+	"errors"
+	"slices"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type taskRepository struct {
-    taskCollection *mongo.Collection
+	taskCollection *mongo.Collection
+	timeouts       Timeouts
 }
 
-//real constructor 
-func NewTaskRepository() Domain.ITaskRepository {
-    uri := os.Getenv("MONGODB_URI")
-    client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
-    if err != nil {
-        panic(err)
-    }
-    collection := client.Database("task_db").Collection("tasks")
-    return &taskRepository{taskCollection: collection}
+// real constructor
+func NewTaskRepository(ctx context.Context, db *MongoDatabase) (Domain.ITaskRepository, error) {
+	collection := db.Collection(db.collections.Tasks)
+	if err := ensureTaskIndexes(ctx, collection); err != nil {
+		return nil, err
+	}
+	return &taskRepository{taskCollection: collection, timeouts: db.timeouts}, nil
 }
 
-//test constructor inject scollection for memongo
+// test constructor inject scollection for memongo
 func NewTaskRepositoryWithCollection(collection *mongo.Collection) Domain.ITaskRepository {
-    return &taskRepository{taskCollection: collection}
+	_ = ensureTaskIndexes(context.Background(), collection)
+	return &taskRepository{taskCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// notTrashed and trashed match the deleted_at field of live and trashed
+// tasks; restoring a task removes the field.
+var (
+	notTrashed = bson.M{"$exists": false}
+	trashed    = bson.M{"$exists": true}
+)
+
+// mongoSortKeys maps the public sort fields onto document fields.
+var mongoSortKeys = map[string]string{
+	Domain.SortByCreated: "_id",
+	Domain.SortByDueDate: "due_date",
+	Domain.SortByTitle:   "title",
+}
+
+// ensureTaskIndexes creates the indexes the task queries need. Every list
+// is scoped to live or trashed tasks and, except for admins, to an owner,
+// then sorted by one key with _id as the tie-breaker; status and due date
+// filters are applied to the documents that index range yields. The
+// deleted_at and _id index also serves the trash purge. Each index slows
+// every task write, so add one only for a new query shape.
+func ensureTaskIndexes(ctx context.Context, collection *mongo.Collection) error {
+	var models []mongo.IndexModel
+	for _, scope := range []bson.D{
+		{{Key: "owner", Value: 1}, {Key: "deleted_at", Value: 1}},
+		{{Key: "deleted_at", Value: 1}},
+	} {
+		for _, sortKey := range []string{"_id", "due_date", "title"} {
+			keys := append(slices.Clone(scope), bson.E{Key: sortKey, Value: 1})
+			if sortKey != "_id" {
+				keys = append(keys, bson.E{Key: "_id", Value: 1})
+			}
+			models = append(models, mongo.IndexModel{Keys: keys})
+		}
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func decodeTask(doc bson.M) Domain.Task {
+	task := Domain.Task{
+		ID:          doc["_id"].(primitive.ObjectID).Hex(),
+		Title:       doc["title"].(string),
+		Description: doc["description"].(string),
+		Status:      doc["status"].(string),
+	}
+	if owner, ok := doc["owner"].(string); ok {
+		task.Owner = owner
+	}
+	switch version := doc["version"].(type) {
+	case int64:
+		task.Version = version
+	case int32:
+		task.Version = int64(version)
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		task.UpdatedAt = updatedAt.Time().UTC()
+	}
+	if deletedAt, ok := doc["deleted_at"].(primitive.DateTime); ok {
+		task.DeletedAt = deletedAt.Time().UTC()
+	}
+
+	if dueDate, ok := doc["due_date"].(primitive.DateTime); ok {
+		task.DueDate = dueDate.Time()
+	} else if dueDate, ok := doc["due_date"].(time.Time); ok {
+		task.DueDate = dueDate
+	}
+	return task
+}
+
+func (r *taskRepository) Create(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	doc := bson.M{
+		"title":       task.Title,
+		"description": task.Description,
+		"due_date":    task.DueDate,
+		"status":      task.Status,
+		"owner":       task.Owner,
+		"version":     int64(1),
+		"updated_at":  time.Now().UTC(),
+	}
+
+	res, err := r.taskCollection.InsertOne(ctx, doc)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return Domain.Task{}, errors.New("failed to convert inserted ID to ObjectID")
+	}
+
+	task.ID = oid.Hex()
+	task.Version = doc["version"].(int64)
+	task.UpdatedAt = doc["updated_at"].(time.Time)
+	return task, nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, id string) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	filter := bson.M{"_id": objectID, "deleted_at": notTrashed}
+	var doc bson.M
+	err = r.taskCollection.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		return Domain.Task{}, notFound(err, Domain.ErrTaskNotFound)
+	}
+
+	return decodeTask(doc), nil
+}
+
+func (r *taskRepository) List(ctx context.Context, filter Domain.TaskFilter) (Domain.TaskPage, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	field, desc, err := Domain.ParseTaskSort(filter.Sort)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultTaskPageSize
+	}
+
+	query := bson.M{"deleted_at": notTrashed}
+	if filter.Owner != "" {
+		query["owner"] = filter.Owner
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	due := bson.M{}
+	if !filter.DueBefore.IsZero() {
+		due["$lt"] = filter.DueBefore
+	}
+	if !filter.DueAfter.IsZero() {
+		due["$gte"] = filter.DueAfter
+	}
+	if len(due) > 0 {
+		query["due_date"] = due
+	}
+
+	sortKey := mongoSortKeys[field]
+	order, cmp := 1, "$gt"
+	if desc {
+		order, cmp = -1, "$lt"
+	}
+
+	if filter.Cursor != "" {
+		last, err := decodeListCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return Domain.TaskPage{}, err
+		}
+		lastID, err := primitive.ObjectIDFromHex(last.ID)
+		if err != nil {
+			return Domain.TaskPage{}, Domain.ErrInvalidCursor
+		}
+
+		after := bson.M{"_id": bson.M{cmp: lastID}}
+		if sortKey != "_id" {
+			var lastValue interface{} = last.Title
+			if field == Domain.SortByDueDate {
+				lastValue = last.DueDate
+			}
+			after = bson.M{"$or": bson.A{
+				bson.M{sortKey: bson.M{cmp: lastValue}},
+				bson.M{sortKey: lastValue, "_id": bson.M{cmp: lastID}},
+			}}
+		}
+		query = bson.M{"$and": bson.A{query, after}}
+	}
+
+	sort := bson.D{{Key: sortKey, Value: order}}
+	if sortKey != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: order})
+	}
+	opts := options.Find().SetSort(sort).SetLimit(int64(filter.Limit + 1))
+
+	cursor, err := r.taskCollection.Find(ctx, query, opts)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []Domain.Task{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return Domain.TaskPage{}, err
+		}
+		tasks = append(tasks, decodeTask(doc))
+	}
+	if err := cursor.Err(); err != nil {
+		return Domain.TaskPage{}, err
+	}
+
+	page := Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > filter.Limit {
+		page.Tasks = tasks[:filter.Limit]
+		page.NextCursor = newListCursor(filter.Sort, page.Tasks[filter.Limit-1]).encode()
+	}
+	return page, nil
 }
 
-func (r *taskRepository) Create(task Domain.Task) (Domain.Task, error) {
-   
-    doc := bson.M{
-        "title":       task.Title,
-        "description": task.Description,
-        "due_date":    task.DueDate,
-        "status":      task.Status,
-    }
-
-    res, err := r.taskCollection.InsertOne(context.TODO(), doc)
-    if err != nil {
-        return Domain.Task{}, err
-    }
-
-    oid, ok := res.InsertedID.(primitive.ObjectID)
-    if !ok {
-        return Domain.Task{}, errors.New("failed to convert inserted ID to ObjectID")
-    }
-
-    task.ID = oid.Hex()
-    return task, nil
+func (r *taskRepository) Update(ctx context.Context, id string, updatedTask Domain.Task) (Domain.Task, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	// Documents written before versioning have no version field and read as
+	// version 0.
+	filter := bson.M{"_id": objectID, "deleted_at": notTrashed, "version": updatedTask.Version}
+	if updatedTask.Version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	updatedAt := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{
+			"title":       updatedTask.Title,
+			"description": updatedTask.Description,
+			"due_date":    updatedTask.DueDate,
+			"status":      updatedTask.Status,
+			"version":     updatedTask.Version + 1,
+			"updated_at":  updatedAt,
+		},
+	}
+
+	res, err := r.taskCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	if res.MatchedCount == 0 {
+		count, err := r.taskCollection.CountDocuments(ctx, bson.M{"_id": objectID, "deleted_at": notTrashed})
+		if err != nil {
+			return Domain.Task{}, err
+		}
+		if count == 0 {
+			return Domain.Task{}, Domain.ErrTaskNotFound
+		}
+		return Domain.Task{}, Domain.ErrVersionConflict
+	}
+
+	updatedTask.ID = id
+	updatedTask.Version++
+	updatedTask.UpdatedAt = updatedAt
+	return updatedTask, nil
 }
 
-func (r *taskRepository) GetByID(id string) (Domain.Task, error) {
-    objectID, err := primitive.ObjectIDFromHex(id)
-    if err != nil {
-        return Domain.Task{}, err
-    }
-
-    filter := bson.M{"_id": objectID}
-    var doc bson.M
-    err = r.taskCollection.FindOne(context.TODO(), filter).Decode(&doc)
-    if err != nil {
-        return Domain.Task{}, err
-    }
-
-    task := Domain.Task{
-        ID:          id,
-        Title:       doc["title"].(string),
-        Description: doc["description"].(string),
-        Status:      doc["status"].(string),
-    }
-
-    if dueDate, ok := doc["due_date"].(primitive.DateTime); ok {
-        task.DueDate = dueDate.Time()
-    } else if dueDate, ok := doc["due_date"].(time.Time); ok {
-        task.DueDate = dueDate
-    }
-
-    return task, nil
+func (r *taskRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	filter := bson.M{"_id": objectID, "deleted_at": notTrashed}
+	update := bson.M{
+		"$set": bson.M{"deleted_at": now, "updated_at": now},
+		"$inc": bson.M{"version": 1},
+	}
+	res, err := r.taskCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return Domain.ErrTaskNotFound
+	}
+	return nil
 }
 
-func (r *taskRepository) GetAll() ([]Domain.Task, error) {
-    cursor, err := r.taskCollection.Find(context.TODO(), bson.D{})
-    if err != nil {
-        return nil, err
-    }
-    defer cursor.Close(context.TODO())
-
-    var tasks []Domain.Task
-    for cursor.Next(context.TODO()) {
-        var doc bson.M
-        err = cursor.Decode(&doc)
-        if err != nil {
-            return nil, err
-        }
-
-        id := doc["_id"].(primitive.ObjectID).Hex()
-        task := Domain.Task{
-            ID:          id,
-            Title:       doc["title"].(string),
-            Description: doc["description"].(string),
-            Status:      doc["status"].(string),
-        }
-
-        if dueDate, ok := doc["due_date"].(primitive.DateTime); ok {
-            task.DueDate = dueDate.Time()
-        } else if dueDate, ok := doc["due_date"].(time.Time); ok {
-            task.DueDate = dueDate
-        }
-
-        tasks = append(tasks, task)
-    }
-
-    return tasks, nil
+func (r *taskRepository) ListTrash(ctx context.Context, owner string) ([]Domain.Task, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	query := bson.M{"deleted_at": trashed}
+	if owner != "" {
+		query["owner"] = owner
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.taskCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []Domain.Task{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, decodeTask(doc))
+	}
+	return tasks, cursor.Err()
 }
 
-func (r *taskRepository) Update(id string, updatedTask Domain.Task) (Domain.Task, error) {
-    objectID, err := primitive.ObjectIDFromHex(id)
-    if err != nil {
-        return Domain.Task{}, err
-    }
-
-    filter := bson.M{"_id": objectID}
-    update := bson.M{
-        "$set": bson.M{
-            "title":       updatedTask.Title,
-            "description": updatedTask.Description,
-            "due_date":    updatedTask.DueDate,
-            "status":      updatedTask.Status,
-        },
-    }
-
-    res, err := r.taskCollection.UpdateOne(context.TODO(), filter, update)
-    if err != nil {
-        return Domain.Task{}, err
-    }
-
-    if res.MatchedCount == 0 {
-        return Domain.Task{}, mongo.ErrNoDocuments
-    }
-
-    updatedTask.ID = id
-    return updatedTask, nil
+// Restore reads the task as it was in the trash, to learn when it was
+// trashed, and applies the update to that copy; Mongo keeps times to the
+// millisecond, so updated_at is truncated to match what is stored.
+func (r *taskRepository) Restore(ctx context.Context, id string, owner string) (Domain.Task, time.Time, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.Task{}, time.Time{}, err
+	}
+
+	filter := bson.M{"_id": objectID, "deleted_at": trashed}
+	if owner != "" {
+		filter["owner"] = owner
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": now},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var doc bson.M
+	err = r.taskCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return Domain.Task{}, time.Time{}, notFound(err, Domain.ErrTaskNotFound)
+	}
+	task := decodeTask(doc)
+	trashedAt := task.DeletedAt
+	task.DeletedAt = time.Time{}
+	task.UpdatedAt = now
+	task.Version++
+	return task, trashedAt, nil
 }
 
-func (r *taskRepository) Delete(id string) error {
-    objectID, err := primitive.ObjectIDFromHex(id)
-    if err != nil {
-        return err
-    }
-
-    filter := bson.M{"_id": objectID}
-    res, err := r.taskCollection.DeleteOne(context.TODO(), filter)
-    if err != nil {
-        return err
-    }
-    if res.DeletedCount == 0 {
-        return mongo.ErrNoDocuments
-    }
-    return nil
+// PurgeTrash deletes the trashed tasks one by one, so the IDs it returns are
+// exactly those it removed even if one is restored meanwhile.
+func (r *taskRepository) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	filter := bson.M{"deleted_at": bson.M{"$lt": before}}
+	cursor, err := r.taskCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	for _, candidate := range candidates {
+		res, err := r.taskCollection.DeleteOne(ctx, bson.M{"_id": candidate.ID, "deleted_at": bson.M{"$lt": before}})
+		if err != nil {
+			return purged, err
+		}
+		if res.DeletedCount == 1 {
+			purged = append(purged, candidate.ID.Hex())
+		}
+	}
+	return purged, nil
 }
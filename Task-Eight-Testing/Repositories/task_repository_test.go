@@ -1,198 +1,353 @@
 package Repositories_test
 
 import (
-    "context"
-    "testing"
-    "time"
-    "task-manager/Domain"
-    "task-manager/Repositories"
-
-    "github.com/stretchr/testify/assert"
-    "github.com/tryvium-travels/memongo"
-    "go.mongodb.org/mongo-driver/mongo"
-    "go.mongodb.org/mongo-driver/mongo/options"
+	"context"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tryvium-travels/memongo"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-//setup inmemory mongodb
+// setup inmemory mongodb
 func setupTestDB(t *testing.T) (*mongo.Collection, func()) {
-    t.Helper()
+	t.Helper()
 
-    mongoServer, err := memongo.Start("4.0.5")
-    if err != nil {
-        t.Fatalf("Failed to start memongo: %v", err)
-    }
+	mongoServer, err := memongo.Start("4.0.5")
+	if err != nil {
+		t.Fatalf("Failed to start memongo: %v", err)
+	}
 
-    client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoServer.URI()))
-    if err != nil {
-        t.Fatalf("Failed to connect memongo: %v", err)
-    }
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoServer.URI()))
+	if err != nil {
+		t.Fatalf("Failed to connect memongo: %v", err)
+	}
 
-    collection := client.Database("test_task_db").Collection("tasks")
+	collection := client.Database("test_task_db").Collection("tasks")
 
-    cleanup := func() {
-        _ = client.Disconnect(context.TODO())
-        mongoServer.Stop()
-    }
+	cleanup := func() {
+		_ = client.Disconnect(context.TODO())
+		mongoServer.Stop()
+	}
 
-    return collection, cleanup
+	return collection, cleanup
 }
 
 func createSampleTask() Domain.Task {
-    return Domain.Task{
-        Title:       "First Task",
-        Description: "this is a task description",
-        DueDate:     time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
-        Status:      "pending",
-    }
+	return Domain.Task{
+		Title:       "First Task",
+		Description: "this is a task description",
+		DueDate:     time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Status:      "pending",
+	}
 }
 
-func TestTaskRepository_Create_Success(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
-
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
-    task := createSampleTask()
-
-    created, err := repo.Create(task)
-    assert.NoError(t, err)
-    assert.NotEmpty(t, created.ID)
-    assert.Equal(t, task.Title, created.Title)
-    assert.Equal(t, task.Description, created.Description)
-    assert.Equal(t, task.Status, created.Status)
-    assert.True(t, task.DueDate.Equal(created.DueDate))
+// listAll returns every live task in the repository.
+func listAll(t *testing.T, repo Domain.ITaskRepository) []Domain.Task {
+	t.Helper()
+	page, err := repo.List(context.Background(), Domain.TaskFilter{Limit: Domain.MaxTaskPageSize})
+	assert.NoError(t, err)
+	return page.Tasks
+}
 
-    fetched, err := repo.GetByID(created.ID)
-    assert.NoError(t, err)
-    assert.Equal(t, created.ID, fetched.ID)
+func TestTaskRepository_Create_Success(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	task := createSampleTask()
+
+	created, err := repo.Create(context.Background(), task)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, task.Title, created.Title)
+	assert.Equal(t, task.Description, created.Description)
+	assert.Equal(t, task.Status, created.Status)
+	assert.True(t, task.DueDate.Equal(created.DueDate))
+
+	fetched, err := repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, fetched.ID)
 }
 
 func TestTaskRepository_GetByID_Success(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    task := createSampleTask()
-    created, _ := repo.Create(task)
+	task := createSampleTask()
+	created, _ := repo.Create(context.Background(), task)
 
-    fetched, err := repo.GetByID(created.ID)
-    assert.NoError(t, err)
-    assert.Equal(t, created.ID, fetched.ID)
-    assert.Equal(t, created.Title, fetched.Title)
+	fetched, err := repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, fetched.ID)
+	assert.Equal(t, created.Title, fetched.Title)
 }
 
 func TestTaskRepository_GetByID_NotFound(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    fakeID := "507f1f77bcf86cd799439011" 
-    _, err := repo.GetByID(fakeID)
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "no documents")
+	fakeID := "507f1f77bcf86cd799439011"
+	_, err := repo.GetByID(context.Background(), fakeID)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
 }
 
 func TestTaskRepository_GetByID_InvalidID(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    _, err := repo.GetByID("invalid-id")
-    assert.Error(t, err)
+	_, err := repo.GetByID(context.Background(), "invalid-id")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrInvalidID)
 }
 
-func TestTaskRepository_GetAll_Success(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+func TestTaskRepository_List_ReturnsEveryTask(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    task1 := createSampleTask()
-    task2 := Domain.Task{
-        Title:  "Another Task",
-        Status: "completed",
-        DueDate: time.Now(),
-    }
+	task1 := createSampleTask()
+	task2 := Domain.Task{
+		Title:   "Another Task",
+		Status:  "completed",
+		DueDate: time.Now(),
+	}
 
-    _, _ = repo.Create(task1)
-    _, _ = repo.Create(task2)
+	_, _ = repo.Create(context.Background(), task1)
+	_, _ = repo.Create(context.Background(), task2)
 
-    tasks, err := repo.GetAll()
-    assert.NoError(t, err)
-    assert.Len(t, tasks, 2)
+	tasks := listAll(t, repo)
+	assert.Len(t, tasks, 2)
 
-    titles := []string{tasks[0].Title, tasks[1].Title}
-    assert.Contains(t, titles, task1.Title)
-    assert.Contains(t, titles, task2.Title)
+	titles := []string{tasks[0].Title, tasks[1].Title}
+	assert.Contains(t, titles, task1.Title)
+	assert.Contains(t, titles, task2.Title)
+}
+
+func TestTaskRepository_List_PaginatesWithCursor(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		task := createSampleTask()
+		task.DueDate = base.Add(time.Duration(i) * time.Hour)
+		_, err := repo.Create(context.Background(), task)
+		assert.NoError(t, err)
+	}
+
+	filter := Domain.TaskFilter{Sort: "-due_date", Limit: 2}
+	var seen []time.Time
+	for {
+		page, err := repo.List(context.Background(), filter)
+		assert.NoError(t, err)
+		for _, task := range page.Tasks {
+			seen = append(seen, task.DueDate)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	assert.Len(t, seen, 5)
+	for i := 1; i < len(seen); i++ {
+		assert.True(t, seen[i].Before(seen[i-1]), "tasks should be ordered by due date descending")
+	}
+}
+
+func TestTaskRepository_List_Filters(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	early := createSampleTask()
+	late := createSampleTask()
+	late.DueDate = early.DueDate.Add(48 * time.Hour)
+	done := createSampleTask()
+	done.Status = "done"
+
+	_, _ = repo.Create(context.Background(), early)
+	_, _ = repo.Create(context.Background(), late)
+	_, _ = repo.Create(context.Background(), done)
+
+	page, err := repo.List(context.Background(), Domain.TaskFilter{Status: "pending", DueBefore: early.DueDate.Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestTaskRepository_List_ByOwner(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	mine := createSampleTask()
+	mine.Owner = "me@example.com"
+	theirs := createSampleTask()
+	theirs.Owner = "them@example.com"
+	_, _ = repo.Create(context.Background(), mine)
+	_, _ = repo.Create(context.Background(), theirs)
+
+	page, err := repo.List(context.Background(), Domain.TaskFilter{Owner: mine.Owner})
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Equal(t, mine.Owner, page.Tasks[0].Owner)
+}
+
+func TestTaskRepository_List_RejectsForeignCursor(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	_, _ = repo.Create(context.Background(), createSampleTask())
+	_, _ = repo.Create(context.Background(), createSampleTask())
+
+	page, err := repo.List(context.Background(), Domain.TaskFilter{Sort: "title", Limit: 1})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, page.NextCursor)
+
+	_, err = repo.List(context.Background(), Domain.TaskFilter{Sort: "due_date", Limit: 1, Cursor: page.NextCursor})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
 }
 
 func TestTaskRepository_Update_Success(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	original := createSampleTask()
+	created, _ := repo.Create(context.Background(), original)
+
+	update := Domain.Task{
+		Title:       "Updated Task",
+		Description: "Updated Desc",
+		Status:      "completed",
+		DueDate:     time.Now(),
+		Version:     created.Version,
+	}
+
+	updatedTask, err := repo.Update(context.Background(), created.ID, update)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, updatedTask.ID)
+	assert.Equal(t, update.Title, updatedTask.Title)
+	assert.Equal(t, created.Version+1, updatedTask.Version)
+
+	fetched, _ := repo.GetByID(context.Background(), created.ID)
+	assert.Equal(t, update.Title, fetched.Title)
+	assert.Equal(t, update.Status, fetched.Status)
+	assert.Equal(t, updatedTask.Version, fetched.Version)
+}
+
+func TestTaskRepository_Update_StaleVersion(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    original := createSampleTask()
-    created, _ := repo.Create(original)
+	created, _ := repo.Create(context.Background(), createSampleTask())
 
-    update := Domain.Task{
-        Title:       "Updated Task",
-        Description: "Updated Desc",
-        Status:      "completed",
-        DueDate:     time.Now(),
-    }
+	first := created
+	first.Title = "First writer"
+	_, err := repo.Update(context.Background(), created.ID, first)
+	assert.NoError(t, err)
 
-    updatedTask, err := repo.Update(created.ID, update)
-    assert.NoError(t, err)
-    assert.Equal(t, created.ID, updatedTask.ID)
-    assert.Equal(t, update.Title, updatedTask.Title)
+	second := created
+	second.Title = "Second writer"
+	_, err = repo.Update(context.Background(), created.ID, second)
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
 
-    fetched, _ := repo.GetByID(created.ID)
-    assert.Equal(t, update.Title, fetched.Title)
-    assert.Equal(t, update.Status, fetched.Status)
+	fetched, _ := repo.GetByID(context.Background(), created.ID)
+	assert.Equal(t, "First writer", fetched.Title)
 }
 
 func TestTaskRepository_Update_NotFound(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    update := createSampleTask()
-    fakeID := "507f1f77bcf86cd799439011"
+	update := createSampleTask()
+	fakeID := "507f1f77bcf86cd799439011"
 
-    _, err := repo.Update(fakeID, update)
-    assert.Error(t, err)
-    assert.Equal(t, mongo.ErrNoDocuments, err)
+	_, err := repo.Update(context.Background(), fakeID, update)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
 }
 
 func TestTaskRepository_Delete_Success(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
 
-    task := createSampleTask()
-    created, _ := repo.Create(task)
+	task := createSampleTask()
+	created, _ := repo.Create(context.Background(), task)
 
-    err := repo.Delete(created.ID)
-    assert.NoError(t, err)
+	err := repo.Delete(context.Background(), created.ID)
+	assert.NoError(t, err)
 
-    _, err = repo.GetByID(created.ID)
-    assert.Error(t, err)
+	_, err = repo.GetByID(context.Background(), created.ID)
+	assert.Error(t, err)
 }
 
 func TestTaskRepository_Delete_NotFound(t *testing.T) {
-    collection, cleanup := setupTestDB(t)
-    defer cleanup()
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	fakeID := "507f1f77bcf86cd799439011"
+
+	err := repo.Delete(context.Background(), fakeID)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestTaskRepository_TrashRestoreAndPurge(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := Repositories.NewTaskRepositoryWithCollection(collection)
+
+	task := createSampleTask()
+	task.Owner = "owner@example.com"
+	created, _ := repo.Create(context.Background(), task)
+
+	assert.NoError(t, repo.Delete(context.Background(), created.ID))
+	_, err := repo.GetByID(context.Background(), created.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
 
-    repo := Repositories.NewTaskRepositoryWithCollection(collection)
+	trash, err := repo.ListTrash(context.Background(), "owner@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
 
-    fakeID := "507f1f77bcf86cd799439011"
+	_, _, err = repo.Restore(context.Background(), created.ID, "someone@example.com")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	restored, trashedAt, err := repo.Restore(context.Background(), created.ID, "owner@example.com")
+	assert.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.WithinDuration(t, time.Now(), trashedAt, time.Minute)
 
-    err := repo.Delete(fakeID)
-    assert.Error(t, err)
-    assert.Equal(t, mongo.ErrNoDocuments, err)
+	assert.NoError(t, repo.Delete(context.Background(), created.ID))
+	purged, err := repo.PurgeTrash(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created.ID}, purged)
 }
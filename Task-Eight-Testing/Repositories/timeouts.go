@@ -0,0 +1,26 @@
+package Repositories
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bounds how long a single database operation may run. The deadline
+// is applied on top of the caller's context, so a cancelled request still
+// stops the operation early.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{Read: 5 * time.Second, Write: 10 * time.Second}
+}
+
+func (t Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Read)
+}
+
+func (t Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Write)
+}
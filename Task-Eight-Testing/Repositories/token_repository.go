@@ -0,0 +1,75 @@
+package Repositories
+
+import (
+	"context"
+	"task-manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type tokenRepository struct {
+	tokenCollection *mongo.Collection
+	timeouts        Timeouts
+}
+
+func NewTokenRepository(ctx context.Context, db *MongoDatabase) (Domain.ITokenRevocationStore, error) {
+	collection := db.Collection(db.collections.RevokedTokens)
+	if err := ensureTokenIndexes(ctx, collection); err != nil {
+		return nil, err
+	}
+	return &tokenRepository{tokenCollection: collection, timeouts: db.timeouts}, nil
+}
+
+func NewTokenRepositoryWithCollection(collection *mongo.Collection) Domain.ITokenRevocationStore {
+	_ = ensureTokenIndexes(context.Background(), collection)
+	return &tokenRepository{tokenCollection: collection, timeouts: DefaultTimeouts()}
+}
+
+// ensureTokenIndexes lets Mongo drop revocations once the token has expired.
+func ensureTokenIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *tokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.tokenCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// RevokeOnce relies on the unique _id: of concurrent inserts for one token
+// only the first succeeds.
+func (r *tokenRepository) RevokeOnce(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.tokenCollection.InsertOne(ctx, bson.M{"_id": id, "expires_at": expiresAt})
+	if mongo.IsDuplicateKeyError(err) {
+		return Domain.ErrTokenRevoked
+	}
+	return err
+}
+
+func (r *tokenRepository) IsRevoked(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	count, err := r.tokenCollection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -1,119 +1,365 @@
 package Repositories
 
 import (
-    "context"
-    "errors"
-    "os"
-    "task-manager/Domain"
-
-    "go.mongodb.org/mongo-driver/bson"
-    "go.mongodb.org/mongo-driver/bson/primitive"
-    "go.mongodb.org/mongo-driver/mongo"
-    "go.mongodb.org/mongo-driver/mongo/options"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"task-manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+type userRepository struct {
+	userCollection *mongo.Collection
+	timeouts       Timeouts
+}
+
+func NewUserRepository(ctx context.Context, db *MongoDatabase) (Domain.IUserRepository, error) {
+	userCollection := db.Collection(db.collections.Users)
+	if err := ensureUserIndexes(ctx, userCollection); err != nil {
+		return nil, err
+	}
+	if err := migrateLegacyRoles(ctx, userCollection); err != nil {
+		return nil, err
+	}
+	return &userRepository{userCollection: userCollection, timeouts: db.timeouts}, nil
+}
 
+// ensureUserIndexes makes email unique, so two registrations racing past
+// Create's lookup cannot both succeed.
+func ensureUserIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
 
-type userRepository struct {
-    userCollection *mongo.Collection
-}
-
-func NewUserRepository() Domain.IUserRepository {
-    uri := os.Getenv("MONGODB_URI")
-    client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
-    if err != nil {
-        panic(err)
-    }
-
-    userCollection := client.Database("task_db").Collection("user")
-    return &userRepository{userCollection: userCollection}
-}
-
-
-func (r *userRepository) FindByEmail(email string) (Domain.User, error) {
-    var result bson.M
-    err := r.userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&result)
-    if err != nil {
-        return Domain.User{}, err
-    }
-
-    id, ok := result["_id"].(primitive.ObjectID)
-    if !ok {
-        return Domain.User{}, errors.New("invalid ID type")
-    }
-
-    return Domain.User{
-        ID:       id.Hex(),
-        Email:    result["email"].(string),
-        Password: result["password"].(string),
-        Role:     result["role"].(string),
-    }, nil
-}
-
-func (r *userRepository) Create(user Domain.User) (Domain.User, error) {
-    _, err := r.FindByEmail(user.Email)
-    if err == nil {
-        return Domain.User{}, errors.New("email already registered")
-    }
-
-    count, err := r.userCollection.CountDocuments(context.TODO(), bson.D{})
-    if err != nil {
-        return Domain.User{}, errors.New("failed to check user count")
-    }
-
-    if count == 0 {
-        user.Role = "admin"
-    } else {
-        user.Role = "user"
-    }
-
-    userObjectID := primitive.NewObjectID()
-    user.ID = userObjectID.Hex()
-
-    doc := bson.M{
-        "_id":      userObjectID,
-        "email":    user.Email,
-        "password": user.Password,
-        "role":     user.Role,
-    }
-
-    _, err = r.userCollection.InsertOne(context.TODO(), doc)
-    if err != nil {
-        return Domain.User{}, errors.New("failed to insert user")
-    }
-
-    return user, nil
-}
-
-func (r *userRepository) Promote(id string) (Domain.User, error) {
-    objectID, err := primitive.ObjectIDFromHex(id)
-    if err != nil {
-        return Domain.User{}, err
-    }
-
-    filter := bson.M{"_id": objectID}
-    update := bson.M{"$set": bson.M{"role": "admin"}}
-
-    _, err = r.userCollection.UpdateOne(context.TODO(), filter, update)
-    if err != nil {
-        return Domain.User{}, err
-    }
-
-    var updated bson.M
-    err = r.userCollection.FindOne(context.TODO(), filter).Decode(&updated)
-    if err != nil {
-        return Domain.User{}, err
-    }
-
-    oid, ok := updated["_id"].(primitive.ObjectID)
-    if !ok {
-        return Domain.User{}, errors.New("invalid ID type after update")
-    }
-
-    return Domain.User{
-        ID:       oid.Hex(),
-        Email:    updated["email"].(string),
-        Password: updated["password"].(string),
-        Role:     updated["role"].(string),
-    }, nil
+// migrateLegacyRoles renames the "user" role every non-admin held before
+// named roles to its successor, Domain.DefaultRole.
+func migrateLegacyRoles(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"role": legacyUserRole},
+		bson.M{"$set": bson.M{"role": Domain.DefaultRole}},
+	)
+	if err != nil {
+		return fmt.Errorf("migrate legacy roles: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (Domain.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id string) (Domain.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+func (r *userRepository) findOne(ctx context.Context, filter bson.M) (Domain.User, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var result bson.M
+	err := r.userCollection.FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		return Domain.User{}, notFound(err, Domain.ErrUserNotFound)
+	}
+	return userFromDocument(result)
+}
+
+// userFromDocument converts a stored user. Users stored before accounts
+// could be disabled have no disabled field and are enabled; those stored
+// before email verification have no verified field and are verified.
+func userFromDocument(doc bson.M) (Domain.User, error) {
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if !ok {
+		return Domain.User{}, errors.New("invalid ID type")
+	}
+	disabled, _ := doc["disabled"].(bool)
+	verified, ok := doc["verified"].(bool)
+	if !ok {
+		verified = true
+	}
+	generation, _ := doc["token_generation"].(int32)
+
+	return Domain.User{
+		ID:              id.Hex(),
+		Email:           doc["email"].(string),
+		Password:        doc["password"].(string),
+		Role:            doc["role"].(string),
+		Disabled:        disabled,
+		Verified:        verified,
+		TokenGeneration: int(generation),
+	}, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user Domain.User) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.FindByEmail(ctx, user.Email)
+	if err == nil {
+		return Domain.User{}, Domain.ErrEmailTaken
+	}
+	if !errors.Is(err, Domain.ErrUserNotFound) {
+		return Domain.User{}, err
+	}
+
+	count, err := r.userCollection.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return Domain.User{}, fmt.Errorf("failed to check user count: %w", err)
+	}
+
+	if count == 0 {
+		user.Role = Domain.RoleAdmin
+	} else {
+		user.Role = Domain.DefaultRole
+	}
+
+	userObjectID := primitive.NewObjectID()
+	user.ID = userObjectID.Hex()
+
+	doc := bson.M{
+		"_id":      userObjectID,
+		"email":    user.Email,
+		"password": user.Password,
+		"role":     user.Role,
+		"disabled": user.Disabled,
+		"verified": user.Verified,
+	}
+
+	_, err = r.userCollection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return Domain.User{}, Domain.ErrEmailTaken
+	}
+	if err != nil {
+		return Domain.User{}, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) SetRole(ctx context.Context, id string, role string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+
+	update := bson.M{"$set": bson.M{"role": role}}
+	if role == Domain.RoleAdmin {
+		return r.updateOne(ctx, bson.M{"_id": objectID}, update)
+	}
+	return r.updateAdmin(ctx, objectID, update)
+}
+
+// enabledAdmins matches the users that keep the admin role usable.
+var enabledAdmins = bson.M{"role": Domain.RoleAdmin, "disabled": bson.M{"$ne": true}}
+
+// updateAdmin applies an update that may take an enabled admin out of that
+// role. A standalone server has no multi-document transactions, so the
+// update is written first and undone when it left no enabled admin. Two
+// concurrent removals of the last two admins then both see none left and
+// both undo, which keeps an admin rather than losing every one.
+func (r *userRepository) updateAdmin(ctx context.Context, objectID primitive.ObjectID, update bson.M) (Domain.User, error) {
+	filter := bson.M{"_id": objectID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var doc bson.M
+	err := r.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	if err != nil {
+		return Domain.User{}, err
+	}
+	before, err := userFromDocument(doc)
+	if err != nil {
+		return Domain.User{}, err
+	}
+
+	if err := r.keepAnAdmin(ctx, before, func() error {
+		_, err := r.userCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"role": before.Role, "disabled": before.Disabled}})
+		return err
+	}); err != nil {
+		return Domain.User{}, err
+	}
+	return r.findOne(ctx, filter)
+}
+
+// keepAnAdmin runs undo and reports ErrLastAdmin when before was an enabled
+// admin and no enabled admin is left after the write that replaced it.
+func (r *userRepository) keepAnAdmin(ctx context.Context, before Domain.User, undo func() error) error {
+	if before.Role != Domain.RoleAdmin || before.Disabled {
+		return nil
+	}
+	count, err := r.userCollection.CountDocuments(ctx, enabledAdmins)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := undo(); err != nil {
+		return fmt.Errorf("restore last admin %s: %w", before.ID, err)
+	}
+	return Domain.ErrLastAdmin
+}
+
+// updateOne applies update to the user matching filter and returns the
+// updated user.
+func (r *userRepository) updateOne(ctx context.Context, filter, update bson.M) (Domain.User, error) {
+	res, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if res.MatchedCount == 0 {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return r.findOne(ctx, filter)
+}
+
+func (r *userRepository) List(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultUserPageSize
+	}
+
+	query := bson.M{}
+	email := bson.M{}
+	if filter.Cursor != "" {
+		after, err := decodeUserCursor(filter.Cursor)
+		if err != nil {
+			return Domain.UserPage{}, err
+		}
+		email["$gt"] = after
+	}
+	if filter.Search != "" {
+		email["$regex"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+	}
+	if len(email) > 0 {
+		query["email"] = email
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "email", Value: 1}}).
+		SetLimit(int64(filter.Limit + 1))
+	cursor, err := r.userCollection.Find(ctx, query, opts)
+	if err != nil {
+		return Domain.UserPage{}, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []Domain.User{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return Domain.UserPage{}, err
+		}
+		user, err := userFromDocument(doc)
+		if err != nil {
+			return Domain.UserPage{}, err
+		}
+		users = append(users, user)
+	}
+	if err := cursor.Err(); err != nil {
+		return Domain.UserPage{}, err
+	}
+
+	page := Domain.UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users = users[:filter.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	update := bson.M{"$set": bson.M{"disabled": disabled}}
+	if !disabled {
+		return r.updateOne(ctx, bson.M{"_id": objectID}, update)
+	}
+	return r.updateAdmin(ctx, objectID, update)
+}
+
+func (r *userRepository) SetVerified(ctx context.Context, id string, verified bool) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	return r.updateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"verified": verified}})
+}
+
+func (r *userRepository) SetPassword(ctx context.Context, id string, hash string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	return r.updateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"password": hash}})
+}
+
+func (r *userRepository) NextTokenGeneration(ctx context.Context, id string) (Domain.User, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	return r.updateOne(ctx, bson.M{"_id": objectID}, bson.M{"$inc": bson.M{"token_generation": int32(1)}})
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	var doc bson.M
+	err = r.userCollection.FindOneAndDelete(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	before, err := userFromDocument(doc)
+	if err != nil {
+		return err
+	}
+	return r.keepAnAdmin(ctx, before, func() error {
+		_, err := r.userCollection.InsertOne(ctx, doc)
+		return err
+	})
 }
@@ -0,0 +1,43 @@
+package Usecases
+
+import (
+	"context"
+	"log/slog"
+	"task-manager/Domain"
+	"time"
+)
+
+// recordAudit stores entry in audit, which may be nil to disable auditing.
+// The change entry describes has already been made, so a failure to record
+// it is logged rather than returned.
+func recordAudit(ctx context.Context, audit Domain.IAuditRepository, entry Domain.AuditEntry) {
+	if audit == nil {
+		return
+	}
+	entry.Timestamp = time.Now().UTC()
+	if err := audit.Record(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit entry",
+			"action", entry.Action, "entity", entry.Entity, "entity_id", entry.EntityID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "audit entry recorded",
+		"action", entry.Action, "entity", entry.Entity, "entity_id", entry.EntityID, "actor_email", entry.Actor)
+}
+
+type AuditUsecase struct {
+	AuditRepo Domain.IAuditRepository
+}
+
+func NewAuditUsecase(repo Domain.IAuditRepository) *AuditUsecase {
+	return &AuditUsecase{AuditRepo: repo}
+}
+
+func (u *AuditUsecase) ListEntries(ctx context.Context, filter Domain.AuditFilter) ([]Domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultAuditPageSize
+	}
+	if filter.Limit > Domain.MaxAuditPageSize {
+		filter.Limit = Domain.MaxAuditPageSize
+	}
+	return u.AuditRepo.List(ctx, filter)
+}
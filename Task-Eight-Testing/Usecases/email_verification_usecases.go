@@ -0,0 +1,90 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"task-manager/Domain"
+)
+
+var errVerificationNotConfigured = errors.New("email verification is not configured")
+
+func (u *UserUsecase) sendVerification(ctx context.Context, user Domain.User) error {
+	if u.Verification == nil || u.Mailer == nil {
+		return errVerificationNotConfigured
+	}
+	token, err := u.Verification.Generate(user)
+	if err != nil {
+		return err
+	}
+
+	action := "use this token to verify it:\n\n" + token
+	if u.VerifyURL != "" {
+		action = "open this link to verify it:\n\n" + u.VerifyURL + "?token=" + url.QueryEscape(token)
+	}
+	return u.Mailer.Send(ctx, Domain.MailMessage{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Welcome! %s was used to create an account. If that was you, %s", user.Email, action),
+	})
+}
+
+// ResendVerification mails a new verification link to the account with the
+// given email. Like RequestPasswordReset, it does not reveal whether there
+// is such an account; verified and disabled accounts get no mail.
+func (u *UserUsecase) ResendVerification(ctx context.Context, email string) error {
+	if u.Verification == nil || u.Mailer == nil {
+		return errVerificationNotConfigured
+	}
+
+	user, err := u.findByLoginEmail(ctx, email)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Verified || user.Disabled {
+		return nil
+	}
+	if err := u.sendVerification(ctx, user); err != nil {
+		return fmt.Errorf("failed to send verification mail: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the account a verification link was sent for as
+// verified. Links stay valid until they expire, so following one twice is
+// not an error. A link sent to an address the account no longer has is
+// refused.
+func (u *UserUsecase) VerifyEmail(ctx context.Context, token string) (Domain.User, error) {
+	if u.Verification == nil {
+		return Domain.User{}, Domain.ErrInvalidVerifyToken
+	}
+	claims, err := u.Verification.Validate(token)
+	if err != nil {
+		return Domain.User{}, Domain.ErrInvalidVerifyToken
+	}
+
+	user, err := u.UserRepo.FindByID(ctx, claims.UserID)
+	if errors.Is(err, Domain.ErrUserNotFound) || errors.Is(err, Domain.ErrInvalidID) {
+		return Domain.User{}, Domain.ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if user.Email != claims.Email {
+		return Domain.User{}, Domain.ErrInvalidVerifyToken
+	}
+	if user.Verified {
+		return user, nil
+	}
+
+	verified, err := u.UserRepo.SetVerified(ctx, user.ID, true)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	u.audit(ctx, user.Email, Domain.AuditUserVerify, user.ID, Domain.DiffUsers(user, verified))
+	return verified, nil
+}
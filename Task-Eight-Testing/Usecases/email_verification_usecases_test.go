@@ -0,0 +1,127 @@
+package Usecases_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"task-manager/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVerificationTokens issues readable, unsigned tokens.
+type fakeVerificationTokens struct{}
+
+func (fakeVerificationTokens) Generate(user Domain.User) (string, error) {
+	return "verify/" + user.ID + "/" + user.Email, nil
+}
+
+func (fakeVerificationTokens) Validate(token string) (Domain.VerificationClaims, error) {
+	parts := strings.Split(token, "/")
+	if len(parts) != 3 || parts[0] != "verify" {
+		return Domain.VerificationClaims{}, errors.New("bad token")
+	}
+	return Domain.VerificationClaims{UserID: parts[1], Email: parts[2]}, nil
+}
+
+func setupEmailVerification(t *testing.T) (*Usecases.UserUsecase, Domain.IUserRepository, *recordingMailer, *MockPasswordService) {
+	users := Repositories.NewMemoryUserRepository()
+	hasher := new(MockPasswordService)
+	mailer := &recordingMailer{}
+	usecase := Usecases.NewUserUsecase(users, hasher, new(MockJWTService))
+	usecase.Verification = fakeVerificationTokens{}
+	usecase.Mailer = mailer
+	usecase.VerifyURL = "https://tasks.example.com/verify"
+	return usecase, users, mailer, hasher
+}
+
+func TestRegister_SendsVerificationLink(t *testing.T) {
+	usecase, _, mailer, hasher := setupEmailVerification(t)
+	hasher.On("Hash", "Correct-Horse-9").Return("hash", nil)
+	ctx := context.Background()
+
+	created, err := usecase.Register(ctx, Domain.User{Email: "ada@example.com", Password: "Correct-Horse-9", Verified: true})
+
+	assert.NoError(t, err)
+	assert.False(t, created.Verified, "clients cannot register as verified")
+	if assert.Len(t, mailer.messages, 1) {
+		assert.Equal(t, "ada@example.com", mailer.messages[0].To)
+		assert.Contains(t, mailer.messages[0].Body, "https://tasks.example.com/verify?token=verify%2F"+created.ID)
+	}
+
+	verified, err := usecase.VerifyEmail(ctx, "verify/"+created.ID+"/ada@example.com")
+	assert.NoError(t, err)
+	assert.True(t, verified.Verified)
+
+	_, err = usecase.VerifyEmail(ctx, "verify/"+created.ID+"/ada@example.com")
+	assert.NoError(t, err, "following a link twice is fine")
+}
+
+func TestRegister_WithoutVerificationUsersAreVerified(t *testing.T) {
+	usecase, _, mailer, hasher := setupEmailVerification(t)
+	usecase.Verification = nil
+	hasher.On("Hash", "Correct-Horse-9").Return("hash", nil)
+
+	created, err := usecase.Register(context.Background(), Domain.User{Email: "ada@example.com", Password: "Correct-Horse-9"})
+
+	assert.NoError(t, err)
+	assert.True(t, created.Verified)
+	assert.Empty(t, mailer.messages)
+}
+
+func TestVerifyEmail_RejectsBadLinks(t *testing.T) {
+	usecase, users, _, _ := setupEmailVerification(t)
+	ctx := context.Background()
+	user, _ := users.Create(ctx, Domain.User{Email: "ada@example.com"})
+
+	for _, token := range []string{
+		"garbage",
+		"verify/" + user.ID + "/someone-else@example.com",
+		"verify/not-an-id/ada@example.com",
+		"verify/64b7f0c2a1b2c3d4e5f60718/ada@example.com",
+	} {
+		_, err := usecase.VerifyEmail(ctx, token)
+		assert.ErrorIs(t, err, Domain.ErrInvalidVerifyToken, token)
+	}
+	stored, _ := users.FindByID(ctx, user.ID)
+	assert.False(t, stored.Verified)
+}
+
+func TestLogin_UnverifiedAccess(t *testing.T) {
+	usecase, users, _, hasher := setupEmailVerification(t)
+	jwt := new(MockJWTService)
+	usecase.JWTService = jwt
+	ctx := context.Background()
+	user, _ := users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "hash"})
+	hasher.On("Compare", "secret", "hash").Return(true)
+	hasher.On("NeedsRehash", "hash").Return(false)
+	jwt.On("GenerateToken", user).Return("access", nil)
+	jwt.On("GenerateRefreshToken", user).Return("refresh", nil)
+
+	usecase.Unverified = Domain.UnverifiedBlocked
+	_, err := usecase.Login(ctx, "ada@example.com", "secret", "")
+	assert.ErrorIs(t, err, Domain.ErrEmailNotVerified)
+
+	usecase.Unverified = Domain.UnverifiedReadOnly
+	_, err = usecase.Login(ctx, "ada@example.com", "secret", "")
+	assert.NoError(t, err, "read-only users can log in; the middleware limits them")
+}
+
+func TestResendVerification_OnlyMailsUnverifiedAccounts(t *testing.T) {
+	usecase, users, mailer, _ := setupEmailVerification(t)
+	ctx := context.Background()
+	pending, _ := users.Create(ctx, Domain.User{Email: "pending@example.com"})
+	done, _ := users.Create(ctx, Domain.User{Email: "done@example.com"})
+	_, _ = users.SetVerified(ctx, done.ID, true)
+
+	assert.NoError(t, usecase.ResendVerification(ctx, "nobody@example.com"))
+	assert.NoError(t, usecase.ResendVerification(ctx, "done@example.com"))
+	assert.NoError(t, usecase.ResendVerification(ctx, "Pending@Example.com"))
+
+	if assert.Len(t, mailer.messages, 1) {
+		assert.Equal(t, pending.Email, mailer.messages[0].To)
+	}
+}
@@ -0,0 +1,92 @@
+package Usecases
+
+import (
+	"context"
+	"log/slog"
+	"task-manager/Domain"
+	"time"
+)
+
+func accountAttemptKey(email string) string {
+	return "account:" + Domain.NormalizeEmail(email)
+}
+
+func clientAttemptKey(clientIP string) string {
+	return "client:" + clientIP
+}
+
+// loginThrottle pairs a failure count key with the policy that applies to it.
+type loginThrottle struct {
+	key    string
+	policy Domain.LockoutPolicy
+}
+
+// loginThrottles lists the counts a login attempt is subject to. Unknown
+// accounts are throttled like real ones, so lockouts do not reveal which
+// emails are registered.
+func (u *UserUsecase) loginThrottles(email, clientIP string) []loginThrottle {
+	throttles := []loginThrottle{{key: accountAttemptKey(email), policy: u.LoginLimits.Account}}
+	if clientIP != "" {
+		throttles = append(throttles, loginThrottle{key: clientAttemptKey(clientIP), policy: u.LoginLimits.Client})
+	}
+	return throttles
+}
+
+// checkLoginAllowed refuses an attempt made before the backoff from earlier
+// failures has passed, saying how long the caller has left to wait.
+func (u *UserUsecase) checkLoginAllowed(ctx context.Context, throttles []loginThrottle) error {
+	var wait time.Duration
+	for _, throttle := range throttles {
+		attempts, err := u.LoginAttempts.Get(ctx, throttle.key)
+		if err != nil {
+			return err
+		}
+		until := attempts.LastFailure.Add(throttle.policy.Delay(attempts.Failures))
+		if remaining := time.Until(until); remaining > wait {
+			wait = remaining
+		}
+	}
+	if wait > 0 {
+		slog.WarnContext(ctx, "login refused while locked out", "retry_after", wait)
+		return Domain.TooManyAttempts(wait)
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt. The caller is already being
+// told the credentials are wrong, so a failure to count it is logged.
+func (u *UserUsecase) recordLoginFailure(ctx context.Context, throttles []loginThrottle) {
+	now := time.Now()
+	for _, throttle := range throttles {
+		if _, err := u.LoginAttempts.RecordFailure(ctx, throttle.key, now, u.LoginLimits.Window); err != nil {
+			slog.ErrorContext(ctx, "failed to record login failure", "key", throttle.key, "error", err)
+		}
+	}
+}
+
+// clearAccountFailures forgets the failed logins for an account. Counts for
+// client addresses are kept: one good password must not let an address go
+// on guessing other accounts.
+func (u *UserUsecase) clearAccountFailures(ctx context.Context, email string) {
+	if u.LoginAttempts == nil {
+		return
+	}
+	if err := u.LoginAttempts.Reset(ctx, accountAttemptKey(email)); err != nil {
+		slog.ErrorContext(ctx, "failed to clear login failures", "email", email, "error", err)
+	}
+}
+
+// UnlockUser lifts the lockout on an account before it expires.
+func (u *UserUsecase) UnlockUser(ctx context.Context, actor Domain.AuthClaims, id string) (Domain.User, error) {
+	user, err := u.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if u.LoginAttempts != nil {
+		if err := u.LoginAttempts.Reset(ctx, accountAttemptKey(user.Email)); err != nil {
+			return Domain.User{}, err
+		}
+	}
+	u.audit(ctx, actor.Email, Domain.AuditUserUnlock, id, map[string]Domain.FieldChange{})
+	return user, nil
+}
@@ -0,0 +1,138 @@
+package Usecases_test
+
+import (
+	"context"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
+	"task-manager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLoginLockout(t *testing.T) (*Usecases.UserUsecase, Domain.IUserRepository) {
+	users := Repositories.NewMemoryUserRepository()
+	hasher := new(MockPasswordService)
+	hasher.On("Compare", "Correct-Horse-9", "hash").Return(true)
+	hasher.On("Compare", mock.Anything, mock.Anything).Return(false)
+	hasher.On("NeedsRehash", "hash").Return(false)
+	jwt := new(MockJWTService)
+	jwt.On("GenerateToken", mock.Anything).Return("access", nil)
+	jwt.On("GenerateRefreshToken", mock.Anything).Return("refresh", nil)
+
+	usecase := Usecases.NewUserUsecase(users, hasher, jwt)
+	usecase.LoginAttempts = Infrastructure.NewInMemoryLoginAttemptStore()
+	usecase.LoginLimits = Domain.LoginLimits{
+		Account: Domain.LockoutPolicy{BackoffAfter: 2, BaseDelay: time.Minute, LockoutAfter: 4, LockoutDuration: time.Hour},
+		Client:  Domain.LockoutPolicy{BackoffAfter: 3, BaseDelay: time.Minute},
+		Window:  2 * time.Hour,
+	}
+	return usecase, users
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var domainErr *Domain.Error
+	if !assert.ErrorAs(t, err, &domainErr) {
+		return 0
+	}
+	return domainErr.RetryAfter
+}
+
+func TestLogin_BacksOffAfterRepeatedFailures(t *testing.T) {
+	usecase, users := setupLoginLockout(t)
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "hash", Verified: true})
+
+	for i := 0; i < 2; i++ {
+		_, err := usecase.Login(ctx, "ada@example.com", "wrong", "192.0.2.1")
+		assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	}
+
+	_, err := usecase.Login(ctx, "ADA@example.com", "Correct-Horse-9", "192.0.2.2")
+	assert.ErrorIs(t, err, Domain.ErrTooManyAttempts, "the right password is not checked while backing off")
+	wait := retryAfter(t, err)
+	assert.True(t, wait > 59*time.Second && wait <= time.Minute, wait)
+}
+
+func TestLogin_LockoutLiftedByAdmin(t *testing.T) {
+	usecase, users := setupLoginLockout(t)
+	usecase.LoginLimits.Account = Domain.LockoutPolicy{LockoutAfter: 3, LockoutDuration: time.Hour}
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase.Audit = audit
+	ctx := context.Background()
+	user, _ := users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "hash", Verified: true})
+
+	for i := 0; i < 3; i++ {
+		_, _ = usecase.Login(ctx, "ada@example.com", "wrong", "")
+	}
+	_, err := usecase.Login(ctx, "ada@example.com", "Correct-Horse-9", "")
+	assert.ErrorIs(t, err, Domain.ErrTooManyAttempts)
+	assert.True(t, retryAfter(t, err) > 59*time.Minute)
+
+	_, err = usecase.UnlockUser(ctx, admin, user.ID)
+	assert.NoError(t, err)
+	tokens, err := usecase.Login(ctx, "ada@example.com", "Correct-Horse-9", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "access", tokens.AccessToken)
+
+	entries, _ := audit.List(ctx, Domain.AuditFilter{Action: Domain.AuditUserUnlock, Limit: 10})
+	assert.Len(t, entries, 1)
+}
+
+func TestLogin_ThrottlesClientAcrossAccounts(t *testing.T) {
+	usecase, _ := setupLoginLockout(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := usecase.Login(ctx, email, "guess", "192.0.2.1")
+		assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	}
+
+	_, err := usecase.Login(ctx, "d@example.com", "guess", "192.0.2.1")
+	assert.ErrorIs(t, err, Domain.ErrTooManyAttempts)
+
+	_, err = usecase.Login(ctx, "d@example.com", "guess", "198.51.100.7")
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials, "other clients are not affected")
+}
+
+func TestLogin_SuccessClearsAccountFailures(t *testing.T) {
+	usecase, users := setupLoginLockout(t)
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "hash", Verified: true})
+
+	_, _ = usecase.Login(ctx, "ada@example.com", "wrong", "")
+	_, err := usecase.Login(ctx, "ada@example.com", "Correct-Horse-9", "")
+	assert.NoError(t, err)
+
+	_, _ = usecase.Login(ctx, "ada@example.com", "wrong", "")
+	_, err = usecase.Login(ctx, "ada@example.com", "Correct-Horse-9", "")
+	assert.NoError(t, err, "the earlier failure was forgotten")
+}
+
+type loginCounts struct {
+	succeeded int
+	failed    []string
+}
+
+func (c *loginCounts) LoginSucceeded()           { c.succeeded++ }
+func (c *loginCounts) LoginFailed(reason string) { c.failed = append(c.failed, reason) }
+
+func TestLogin_CountsOutcomes(t *testing.T) {
+	usecase, users := setupLoginLockout(t)
+	counts := &loginCounts{}
+	usecase.Metrics = counts
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "hash", Verified: true})
+
+	_, _ = usecase.Login(ctx, "ada@example.com", "Correct-Horse-9", "192.0.2.1")
+	for i := 0; i < 3; i++ {
+		_, _ = usecase.Login(ctx, "ada@example.com", "wrong", "192.0.2.1")
+	}
+
+	assert.Equal(t, 1, counts.succeeded)
+	assert.Equal(t, []string{"invalid_credentials", "invalid_credentials", "too_many_attempts"}, counts.failed)
+}
@@ -0,0 +1,136 @@
+package Usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"task-manager/Domain"
+	"time"
+)
+
+var errResetNotConfigured = errors.New("password reset is not configured")
+
+// newOneTimeToken returns a random secret for the user and the hash stored
+// in its place.
+func newOneTimeToken() (secret, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret = base64.RawURLEncoding.EncodeToString(raw)
+	return secret, hashOneTimeToken(secret), nil
+}
+
+func hashOneTimeToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestPasswordReset mails a reset token to the account with the given
+// email. It succeeds without sending anything for unknown or disabled
+// accounts, so callers cannot learn which addresses are registered.
+func (u *UserUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	if u.ResetTokens == nil || u.Mailer == nil {
+		return errResetNotConfigured
+	}
+
+	user, err := u.findByLoginEmail(ctx, email)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return nil
+	}
+
+	secret, hash, err := newOneTimeToken()
+	if err != nil {
+		return err
+	}
+	err = u.ResetTokens.Save(ctx, Domain.OneTimeToken{
+		Hash:      hash,
+		UserID:    user.ID,
+		Purpose:   Domain.TokenPurposePasswordReset,
+		ExpiresAt: time.Now().Add(u.ResetTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := u.Mailer.Send(ctx, u.resetMessage(user, secret)); err != nil {
+		return fmt.Errorf("failed to send password reset mail: %w", err)
+	}
+	return nil
+}
+
+func (u *UserUsecase) resetMessage(user Domain.User, secret string) Domain.MailMessage {
+	action := "use this token to choose a new password:\n\n" + secret
+	if u.ResetURL != "" {
+		action = "open this link to choose a new password:\n\n" + u.ResetURL + "?token=" + url.QueryEscape(secret)
+	}
+	return Domain.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for %s. If it was you, %s\n\n"+
+			"This expires in %s and works once. If you did not ask for it, you can ignore this message.",
+			user.Email, action, u.ResetTokenTTL),
+	}
+}
+
+// ResetPassword sets a new password for the account the token was issued
+// to and ends every session opened before. The password is checked first
+// so a rejected one does not use up the token.
+func (u *UserUsecase) ResetPassword(ctx context.Context, secret, password string) error {
+	if u.ResetTokens == nil {
+		return errResetNotConfigured
+	}
+	if problems := u.PasswordPolicy.Check(password); len(problems) > 0 {
+		return Domain.ValidationFailed(problems...)
+	}
+
+	token, err := u.ResetTokens.Consume(ctx, Domain.TokenPurposePasswordReset, hashOneTimeToken(secret))
+	if errors.Is(err, Domain.ErrTokenNotFound) {
+		return Domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if token.Expired(time.Now()) {
+		return Domain.ErrInvalidResetToken
+	}
+
+	user, err := u.UserRepo.FindByID(ctx, token.UserID)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return Domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return Domain.ErrUserDisabled
+	}
+
+	hashedPassword, err := u.PasswordHasher.Hash(password)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	// Tokens are revoked before the password changes, so a failure in
+	// between leaves the account signed out rather than the old sessions
+	// alive.
+	if _, err := u.UserRepo.NextTokenGeneration(ctx, user.ID); err != nil {
+		return err
+	}
+	if _, err := u.UserRepo.SetPassword(ctx, user.ID, hashedPassword); err != nil {
+		return err
+	}
+	u.audit(ctx, user.Email, Domain.AuditUserReset, user.ID, map[string]Domain.FieldChange{})
+	u.clearAccountFailures(ctx, user.Email)
+	return nil
+}
@@ -0,0 +1,163 @@
+package Usecases_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
+	"task-manager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMailer struct {
+	messages []Domain.MailMessage
+}
+
+func (m *recordingMailer) Send(ctx context.Context, message Domain.MailMessage) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+var resetLink = regexp.MustCompile(`https://tasks\.example\.com/reset\?token=(\S+)`)
+
+// resetTokenFrom pulls the token out of the link in a reset mail.
+func resetTokenFrom(t *testing.T, message Domain.MailMessage) string {
+	t.Helper()
+	match := resetLink.FindStringSubmatch(message.Body)
+	if match == nil {
+		t.Fatalf("no reset link in %q", message.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("bad reset link: %v", err)
+	}
+	return token
+}
+
+func setupPasswordReset(t *testing.T) (*Usecases.UserUsecase, Domain.IUserRepository, *recordingMailer, *MockPasswordService) {
+	users := Repositories.NewMemoryUserRepository()
+	hasher := new(MockPasswordService)
+	mailer := &recordingMailer{}
+	usecase := Usecases.NewUserUsecase(users, hasher, new(MockJWTService))
+	usecase.ResetTokens = Repositories.NewMemoryOneTimeTokenRepository()
+	usecase.Mailer = mailer
+	usecase.ResetURL = "https://tasks.example.com/reset"
+	return usecase, users, mailer, hasher
+}
+
+func TestPasswordReset_TokenWorksOnce(t *testing.T) {
+	usecase, users, mailer, hasher := setupPasswordReset(t)
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase.Audit = audit
+	ctx := context.Background()
+	user, _ := users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "old-hash"})
+	hasher.On("Hash", "Correct-Horse-9").Return("new-hash", nil)
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "Ada@Example.com"))
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	assert.Equal(t, "ada@example.com", mailer.messages[0].To)
+	token := resetTokenFrom(t, mailer.messages[0])
+
+	assert.NoError(t, usecase.ResetPassword(ctx, token, "Correct-Horse-9"))
+	updated, _ := users.FindByID(ctx, user.ID)
+	assert.Equal(t, "new-hash", updated.Password)
+
+	assert.ErrorIs(t, usecase.ResetPassword(ctx, token, "Correct-Horse-9"), Domain.ErrInvalidResetToken)
+
+	entries, _ := audit.List(ctx, Domain.AuditFilter{Action: Domain.AuditUserReset, Limit: 10})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, user.ID, entries[0].EntityID)
+		assert.Empty(t, entries[0].Changes)
+	}
+}
+
+func TestResetPassword_RevokesEarlierTokens(t *testing.T) {
+	usecase, users, mailer, hasher := setupPasswordReset(t)
+	ctx := context.Background()
+	user, _ := users.Create(ctx, Domain.User{Email: "ada@example.com", Password: "old-hash", Verified: true})
+	hasher.On("Hash", "Correct-Horse-9").Return("new-hash", nil)
+
+	keys, err := Infrastructure.NewKeySet(Infrastructure.NewHMACKey("default", []byte("test-secret-key-for-testing-1234")))
+	assert.NoError(t, err)
+	jwtService := Infrastructure.NewJWTServiceWithKeys(keys, Infrastructure.NewInMemoryRevocationStore())
+	usecase.JWTService = jwtService
+	refresh, err := jwtService.GenerateRefreshToken(user)
+	assert.NoError(t, err)
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, user.Email))
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	assert.NoError(t, usecase.ResetPassword(ctx, resetTokenFrom(t, mailer.messages[0]), "Correct-Horse-9"))
+
+	_, err = usecase.Refresh(ctx, refresh)
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefresh)
+
+	// Signing in again straight away, even within the same second, works.
+	user, _ = users.FindByID(ctx, user.ID)
+	refresh, err = jwtService.GenerateRefreshToken(user)
+	assert.NoError(t, err)
+	_, err = usecase.Refresh(ctx, refresh)
+	assert.NoError(t, err)
+}
+
+func TestRequestPasswordReset_SendsNothingForUnknownOrDisabledAccounts(t *testing.T) {
+	usecase, users, mailer, _ := setupPasswordReset(t)
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "admin@example.com"})
+	disabled, _ := users.Create(ctx, Domain.User{Email: "gone@example.com"})
+	_, _ = users.SetDisabled(ctx, disabled.ID, true)
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "gone@example.com"))
+	assert.Empty(t, mailer.messages)
+}
+
+func TestResetPassword_RejectsExpiredToken(t *testing.T) {
+	usecase, users, mailer, _ := setupPasswordReset(t)
+	usecase.ResetTokenTTL = -time.Minute
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com"})
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "ada@example.com"))
+	token := resetTokenFrom(t, mailer.messages[0])
+
+	assert.ErrorIs(t, usecase.ResetPassword(ctx, token, "Correct-Horse-9"), Domain.ErrInvalidResetToken)
+}
+
+func TestResetPassword_WeakPasswordKeepsToken(t *testing.T) {
+	usecase, users, mailer, hasher := setupPasswordReset(t)
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com"})
+	hasher.On("Hash", "Correct-Horse-9").Return("new-hash", nil)
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "ada@example.com"))
+	token := resetTokenFrom(t, mailer.messages[0])
+
+	err := usecase.ResetPassword(ctx, token, "weak")
+	assert.ErrorIs(t, err, Domain.ErrValidationFailed)
+	hasher.AssertNotCalled(t, "Hash", "weak")
+
+	assert.NoError(t, usecase.ResetPassword(ctx, token, "Correct-Horse-9"))
+}
+
+func TestRequestPasswordReset_ReplacesEarlierToken(t *testing.T) {
+	usecase, users, mailer, hasher := setupPasswordReset(t)
+	ctx := context.Background()
+	_, _ = users.Create(ctx, Domain.User{Email: "ada@example.com"})
+	hasher.On("Hash", "Correct-Horse-9").Return("new-hash", nil)
+
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "ada@example.com"))
+	assert.NoError(t, usecase.RequestPasswordReset(ctx, "ada@example.com"))
+	first, second := resetTokenFrom(t, mailer.messages[0]), resetTokenFrom(t, mailer.messages[1])
+
+	assert.ErrorIs(t, usecase.ResetPassword(ctx, first, "Correct-Horse-9"), Domain.ErrInvalidResetToken)
+	assert.NoError(t, usecase.ResetPassword(ctx, second, "Correct-Horse-9"))
+}
@@ -0,0 +1,144 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"task-manager/Domain"
+)
+
+type RoleUsecase struct {
+	RoleRepo Domain.IRoleRepository
+	UserRepo Domain.IUserRepository
+	// Audit records role changes and assignments; nil disables auditing.
+	Audit Domain.IAuditRepository
+}
+
+func NewRoleUsecase(roles Domain.IRoleRepository, users Domain.IUserRepository) *RoleUsecase {
+	return &RoleUsecase{RoleRepo: roles, UserRepo: users}
+}
+
+// SeedDefaults stores each of Domain.DefaultRoles that is not stored yet.
+// Roles already stored keep their permissions, so edits survive restarts.
+func (u *RoleUsecase) SeedDefaults(ctx context.Context) error {
+	for _, role := range Domain.DefaultRoles() {
+		_, err := u.RoleRepo.FindByName(ctx, role.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, Domain.ErrRoleNotFound) {
+			return err
+		}
+		if err := u.RoleRepo.Save(ctx, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *RoleUsecase) ListRoles(ctx context.Context) ([]Domain.Role, error) {
+	return u.RoleRepo.List(ctx)
+}
+
+// SaveRole creates role or replaces its permissions, which must all be held
+// by the actor: otherwise they could grant themselves more through a role
+// they hold. The admin role cannot be changed.
+func (u *RoleUsecase) SaveRole(ctx context.Context, actor Domain.AuthClaims, role Domain.Role) (Domain.Role, error) {
+	if role.Name == Domain.RoleAdmin {
+		return Domain.Role{}, Domain.ErrProtectedRole
+	}
+	if err := role.Validate(); err != nil {
+		return Domain.Role{}, err
+	}
+	if !actor.CanGrant(role) {
+		return Domain.Role{}, Domain.ErrRoleNotGrantable
+	}
+	role.Permissions = slices.Compact(slices.Sorted(slices.Values(role.Permissions)))
+
+	before, err := u.RoleRepo.FindByName(ctx, role.Name)
+	if err != nil && !errors.Is(err, Domain.ErrRoleNotFound) {
+		return Domain.Role{}, err
+	}
+	if err := u.RoleRepo.Save(ctx, role); err != nil {
+		return Domain.Role{}, err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditRoleSave, Domain.AuditEntityRole, role.Name, Domain.DiffRoles(before, role))
+	return role, nil
+}
+
+// DeleteRole removes a role. Users who hold it keep its name but lose its
+// permissions. The admin role and the role given to new users cannot be
+// deleted.
+func (u *RoleUsecase) DeleteRole(ctx context.Context, actor Domain.AuthClaims, name string) error {
+	if name == Domain.RoleAdmin || name == Domain.DefaultRole {
+		return Domain.ErrProtectedRole
+	}
+	before, err := u.RoleRepo.FindByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := u.RoleRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditRoleDelete, Domain.AuditEntityRole, name, Domain.DiffRoles(before, Domain.Role{Name: name}))
+	return nil
+}
+
+// AssignRole gives the user the named role, which must exist and grant
+// nothing the actor does not hold.
+func (u *RoleUsecase) AssignRole(ctx context.Context, actor Domain.AuthClaims, userID, role string) (Domain.User, error) {
+	granted, err := u.RoleRepo.FindByName(ctx, role)
+	if errors.Is(err, Domain.ErrRoleNotFound) {
+		return Domain.User{}, fmt.Errorf("%w: unknown role %q", Domain.ErrInvalidRole, role)
+	} else if err != nil {
+		return Domain.User{}, err
+	}
+	if !actor.CanGrant(granted) {
+		return Domain.User{}, Domain.ErrRoleNotGrantable
+	}
+
+	before, err := u.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	updated, err := u.UserRepo.SetRole(ctx, userID, role)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditUserRole, Domain.AuditEntityUser, userID, Domain.DiffUsers(before, updated))
+	return updated, nil
+}
+
+// findRole returns the named role with the permissions it grants. Admin
+// holds every permission whatever is stored, and a role that does not exist
+// grants nothing. Without a repository, Domain.DefaultRoles apply.
+func findRole(ctx context.Context, roles Domain.IRoleRepository, name string) (Domain.Role, error) {
+	if name == Domain.RoleAdmin {
+		return Domain.Role{Name: name, Permissions: Domain.Permissions}, nil
+	}
+	if roles == nil {
+		for _, role := range Domain.DefaultRoles() {
+			if role.Name == name {
+				return role, nil
+			}
+		}
+		return Domain.Role{Name: name}, nil
+	}
+
+	role, err := roles.FindByName(ctx, name)
+	if errors.Is(err, Domain.ErrRoleNotFound) {
+		return Domain.Role{Name: name}, nil
+	}
+	return role, err
+}
+
+func (u *RoleUsecase) audit(ctx context.Context, actor, action, entity, id string, changes map[string]Domain.FieldChange) {
+	recordAudit(ctx, u.Audit, Domain.AuditEntry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   entity,
+		EntityID: id,
+		Changes:  changes,
+	})
+}
@@ -0,0 +1,94 @@
+package Usecases_test
+
+import (
+	"context"
+	"task-manager/Domain"
+	"task-manager/Repositories"
+	"task-manager/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSeedDefaults_KeepsEditedRoles(t *testing.T) {
+	roles := Repositories.NewMemoryRoleRepository()
+	usecase := Usecases.NewRoleUsecase(roles, Repositories.NewMemoryUserRepository())
+	ctx := context.Background()
+
+	_ = roles.Save(ctx, Domain.Role{Name: Domain.RoleViewer, Permissions: []string{}})
+	assert.NoError(t, usecase.SeedDefaults(ctx))
+
+	stored, err := usecase.ListRoles(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stored, len(Domain.DefaultRoles()))
+
+	viewer, _ := roles.FindByName(ctx, Domain.RoleViewer)
+	assert.Empty(t, viewer.Permissions)
+}
+
+func TestSaveRole_RecordsGrantsAndRevocations(t *testing.T) {
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewRoleUsecase(Repositories.NewMemoryRoleRepository(), Repositories.NewMemoryUserRepository())
+	usecase.Audit = audit
+	ctx := context.Background()
+	assert.NoError(t, usecase.SeedDefaults(ctx))
+
+	_, err := usecase.SaveRole(ctx, admin, Domain.Role{Name: Domain.RoleViewer, Permissions: []string{Domain.PermAuditRead}})
+	assert.NoError(t, err)
+
+	entries, _ := audit.List(ctx, Domain.AuditFilter{Limit: 10})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, Domain.AuditRoleSave, entries[0].Action)
+		assert.Equal(t, map[string]Domain.FieldChange{
+			Domain.PermAuditRead: {After: "granted"},
+			Domain.PermTasksRead: {Before: "granted", After: "revoked"},
+		}, entries[0].Changes)
+	}
+}
+
+func TestSaveRole_RejectsPermissionsBeyondTheActor(t *testing.T) {
+	roles := Repositories.NewMemoryRoleRepository()
+	usecase := Usecases.NewRoleUsecase(roles, Repositories.NewMemoryUserRepository())
+	ctx := context.Background()
+	assert.NoError(t, usecase.SeedDefaults(ctx))
+	roleManager := Domain.AuthClaims{UserID: "rm-id", Role: Domain.RoleMember, Permissions: []string{Domain.PermTasksRead, Domain.PermTasksWrite, Domain.PermTasksDelete, Domain.PermRolesManage}}
+
+	// Adding to their own role would hand them the permission.
+	_, err := usecase.SaveRole(ctx, roleManager, Domain.Role{Name: Domain.RoleMember, Permissions: []string{Domain.PermTasksRead, Domain.PermUsersPromote}})
+	assert.ErrorIs(t, err, Domain.ErrRoleNotGrantable)
+	member, _ := roles.FindByName(ctx, Domain.RoleMember)
+	assert.NotContains(t, member.Permissions, Domain.PermUsersPromote)
+
+	_, err = usecase.SaveRole(ctx, roleManager, Domain.Role{Name: "reader", Permissions: []string{Domain.PermTasksRead}})
+	assert.NoError(t, err)
+}
+
+func TestAssignRole_RejectsRolesBeyondTheActor(t *testing.T) {
+	users := new(MockUserRepository)
+	roles := Repositories.NewMemoryRoleRepository()
+	usecase := Usecases.NewRoleUsecase(roles, users)
+	assert.NoError(t, usecase.SeedDefaults(context.Background()))
+	promoter := Domain.AuthClaims{UserID: "promoter-id", Email: "promoter@example.com", Permissions: []string{Domain.PermTasksRead, Domain.PermTasksWrite, Domain.PermTasksDelete, Domain.PermUsersPromote}}
+
+	for _, role := range []string{Domain.RoleAdmin, Domain.RoleManager} {
+		_, err := usecase.AssignRole(context.Background(), promoter, "123", role)
+		assert.ErrorIs(t, err, Domain.ErrRoleNotGrantable, role)
+	}
+	users.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+
+	users.On("FindByID", "123").Return(Domain.User{ID: "123", Role: Domain.RoleViewer}, nil)
+	users.On("SetRole", "123", Domain.RoleMember).Return(Domain.User{ID: "123", Role: Domain.RoleMember}, nil)
+	_, err := usecase.AssignRole(context.Background(), promoter, "123", Domain.RoleMember)
+	assert.NoError(t, err)
+}
+
+func TestAssignRole_UnknownRole(t *testing.T) {
+	users := new(MockUserRepository)
+	usecase := Usecases.NewRoleUsecase(Repositories.NewMemoryRoleRepository(), users)
+
+	_, err := usecase.AssignRole(context.Background(), admin, "123", "overlord")
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidRole)
+	users.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+}
@@ -1,39 +1,198 @@
 package Usecases
 
 import (
+	"context"
 	"task-manager/Domain"
 	"time"
 )
 
 type TaskUsecase struct {
 	TaskRepo Domain.ITaskRepository
+	Workflow Domain.Workflow
+	// Audit records task changes; nil disables auditing.
+	Audit Domain.IAuditRepository
 }
 
 func NewTaskUsecase(repo Domain.ITaskRepository) *TaskUsecase {
 	return &TaskUsecase{
 		TaskRepo: repo,
+		Workflow: Domain.DefaultWorkflow(),
 	}
 }
 
-func (u *TaskUsecase) GetAllTasks() ([]Domain.Task, error) {
-	return u.TaskRepo.GetAll()
+// canAccess reports whether actor may see or change task. Holders of
+// tasks:all manage every task; everyone else only their own.
+func canAccess(actor Domain.AuthClaims, task Domain.Task) bool {
+	return actor.Can(Domain.PermTasksAll) || (actor.UserID != "" && task.Owner == actor.UserID)
 }
 
-func (u *TaskUsecase) GetTaskByID(id string) (Domain.Task, error) {
-	return u.TaskRepo.GetByID(id)
+// ownerScope is the owner repository queries are limited to for actor;
+// holders of tasks:all are not limited.
+func ownerScope(actor Domain.AuthClaims) string {
+	if actor.Can(Domain.PermTasksAll) {
+		return ""
+	}
+	return actor.UserID
+}
+
+func (u *TaskUsecase) ListTasks(ctx context.Context, actor Domain.AuthClaims, filter Domain.TaskFilter) (Domain.TaskPage, error) {
+	if _, _, err := Domain.ParseTaskSort(filter.Sort); err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultTaskPageSize
+	}
+	if filter.Limit > Domain.MaxTaskPageSize {
+		filter.Limit = Domain.MaxTaskPageSize
+	}
+	filter.Owner = ownerScope(actor)
+	return u.TaskRepo.List(ctx, filter)
 }
 
-func (u *TaskUsecase) CreateTask(task Domain.Task) (Domain.Task, error) {
+// GetTaskByID reports tasks owned by someone else as not found so callers
+// cannot probe for the existence of other users' tasks.
+func (u *TaskUsecase) GetTaskByID(ctx context.Context, actor Domain.AuthClaims, id string) (Domain.Task, error) {
+	task, err := u.TaskRepo.GetByID(ctx, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if !canAccess(actor, task) {
+		return Domain.Task{}, Domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (u *TaskUsecase) CreateTask(ctx context.Context, actor Domain.AuthClaims, task Domain.Task) (Domain.Task, error) {
 	if task.DueDate.IsZero() {
 		task.DueDate = time.Now().UTC()
 	}
-	return u.TaskRepo.Create(task)
+	if task.Status == "" {
+		task.Status = u.Workflow.Initial
+	}
+	if err := u.Workflow.CheckInitial(task.Status); err != nil {
+		return Domain.Task{}, err
+	}
+	task.Owner = actor.UserID
+	created, err := u.TaskRepo.Create(ctx, task)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditTaskCreate, created.ID, Domain.DiffTasks(Domain.Task{}, created))
+	return created, nil
+}
+
+// UpdateTask replaces a task. A task without a status keeps its current one;
+// otherwise it must be complete, as PatchTask's result must.
+func (u *TaskUsecase) UpdateTask(ctx context.Context, actor Domain.AuthClaims, id string, task Domain.Task) (Domain.Task, error) {
+	existing, err := u.GetTaskByID(ctx, actor, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if task.Status == "" {
+		task.Status = existing.Status
+	}
+	if err := task.Validate(); err != nil {
+		return Domain.Task{}, err
+	}
+	return u.update(ctx, actor, id, existing, task)
 }
 
-func (u *TaskUsecase) UpdateTask(id string, task Domain.Task) (Domain.Task, error) {
-	return u.TaskRepo.Update(id, task)
+// PatchTask changes only what patch changes. patch receives the stored task,
+// and its result is validated and written as an update of that version, so a
+// concurrent write in between is reported rather than overwritten.
+func (u *TaskUsecase) PatchTask(ctx context.Context, actor Domain.AuthClaims, id string, version int64, patch func(Domain.Task) (Domain.Task, error)) (Domain.Task, error) {
+	existing, err := u.GetTaskByID(ctx, actor, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if version != 0 && version != existing.Version {
+		return Domain.Task{}, Domain.ErrVersionConflict
+	}
+
+	patched, err := patch(existing)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if err := patched.Validate(); err != nil {
+		return Domain.Task{}, err
+	}
+	patched.Version = existing.Version
+	return u.update(ctx, actor, id, existing, patched)
+}
+
+func (u *TaskUsecase) update(ctx context.Context, actor Domain.AuthClaims, id string, existing, task Domain.Task) (Domain.Task, error) {
+	if task.Status == "" {
+		task.Status = existing.Status
+	}
+	if err := u.Workflow.CheckTransition(existing.Status, task.Status); err != nil {
+		return Domain.Task{}, err
+	}
+	// A zero version means the caller did not say which version it edited;
+	// the repository still guards against writes racing this one.
+	if task.Version == 0 {
+		task.Version = existing.Version
+	} else if task.Version != existing.Version {
+		return Domain.Task{}, Domain.ErrVersionConflict
+	}
+	task.Owner = existing.Owner
+	updated, err := u.TaskRepo.Update(ctx, id, task)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditTaskUpdate, id, Domain.DiffTasks(existing, updated))
+	return updated, nil
+}
+
+// DeleteTask moves a task to the trash, from where it can be restored until
+// it is purged.
+func (u *TaskUsecase) DeleteTask(ctx context.Context, actor Domain.AuthClaims, id string) error {
+	existing, err := u.GetTaskByID(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if err := u.TaskRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	trashed := existing
+	trashed.DeletedAt = time.Now().UTC()
+	u.audit(ctx, actor.Email, Domain.AuditTaskDelete, id, Domain.DiffTasks(existing, trashed))
+	return nil
+}
+
+func (u *TaskUsecase) ListTrash(ctx context.Context, actor Domain.AuthClaims) ([]Domain.Task, error) {
+	return u.TaskRepo.ListTrash(ctx, ownerScope(actor))
+}
+
+func (u *TaskUsecase) RestoreTask(ctx context.Context, actor Domain.AuthClaims, id string) (Domain.Task, error) {
+	restored, trashedAt, err := u.TaskRepo.Restore(ctx, id, ownerScope(actor))
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	trashed := restored
+	trashed.DeletedAt = trashedAt
+	u.audit(ctx, actor.Email, Domain.AuditTaskRestore, id, Domain.DiffTasks(trashed, restored))
+	return restored, nil
+}
+
+// PurgeTrash permanently removes tasks that have been in the trash for longer
+// than retention, recording each one it removes.
+func (u *TaskUsecase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().UTC().Add(-retention)
+	purged, err := u.TaskRepo.PurgeTrash(ctx, cutoff)
+	for _, id := range purged {
+		u.audit(ctx, Domain.AuditSystemActor, Domain.AuditTaskPurge, id, map[string]Domain.FieldChange{
+			"purged_before": {After: cutoff.Format(time.RFC3339)},
+		})
+	}
+	return int64(len(purged)), err
 }
 
-func (u *TaskUsecase) DeleteTask(id string) error {
-	return u.TaskRepo.Delete(id)
+func (u *TaskUsecase) audit(ctx context.Context, actor, action, id string, changes map[string]Domain.FieldChange) {
+	recordAudit(ctx, u.Audit, Domain.AuditEntry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   Domain.AuditEntityTask,
+		EntityID: id,
+		Changes:  changes,
+	})
 }
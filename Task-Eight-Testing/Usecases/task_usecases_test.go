@@ -1,60 +1,111 @@
 package Usecases_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"task-manager/Domain"
+	"task-manager/Repositories"
 	"task-manager/Usecases"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-// mock 
+// mock
 type MockTaskRepository struct {
 	mock.Mock
 }
 
-func (m *MockTaskRepository) GetAll() ([]Domain.Task, error) {
-	args := m.Called()
-	return args.Get(0).([]Domain.Task), args.Error(1)
+func (m *MockTaskRepository) List(ctx context.Context, filter Domain.TaskFilter) (Domain.TaskPage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(Domain.TaskPage), args.Error(1)
 }
 
-func (m *MockTaskRepository) GetByID(id string) (Domain.Task, error) {
+func (m *MockTaskRepository) GetByID(ctx context.Context, id string) (Domain.Task, error) {
 	args := m.Called(id)
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Create(task Domain.Task) (Domain.Task, error) {
+func (m *MockTaskRepository) Create(ctx context.Context, task Domain.Task) (Domain.Task, error) {
 	args := m.Called(task)
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Update(id string, task Domain.Task) (Domain.Task, error) {
+func (m *MockTaskRepository) Update(ctx context.Context, id string, task Domain.Task) (Domain.Task, error) {
 	args := m.Called(id, task)
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Delete(id string) error {
+func (m *MockTaskRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-// tests 
+func (m *MockTaskRepository) ListTrash(ctx context.Context, owner string) ([]Domain.Task, error) {
+	args := m.Called(owner)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) Restore(ctx context.Context, id string, owner string) (Domain.Task, time.Time, error) {
+	args := m.Called(id, owner)
+	return args.Get(0).(Domain.Task), args.Get(1).(time.Time), args.Error(2)
+}
+
+func (m *MockTaskRepository) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	args := m.Called(before)
+	purged, _ := args.Get(0).([]string)
+	return purged, args.Error(1)
+}
+
+var (
+	owner    = Domain.AuthClaims{UserID: "owner-id", Email: "owner@example.com", Role: "user"}
+	stranger = Domain.AuthClaims{UserID: "stranger-id", Email: "stranger@example.com", Role: "user"}
+	admin    = Domain.AuthClaims{UserID: "admin-id", Email: "admin@example.com", Role: "admin", Permissions: Domain.Permissions}
+
+	due = time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+)
+
+// tests
+
+func TestListTasks_DefaultsAndClampsLimit(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	expected := Domain.TaskPage{Tasks: []Domain.Task{{ID: "1"}}, NextCursor: "next"}
+	mockRepo.On("List", Domain.TaskFilter{Status: "done", Limit: Domain.DefaultTaskPageSize}).Return(expected, nil)
+	mockRepo.On("List", Domain.TaskFilter{Sort: "-due_date", Limit: Domain.MaxTaskPageSize}).Return(Domain.TaskPage{}, nil)
+
+	page, err := usecase.ListTasks(context.Background(), admin, Domain.TaskFilter{Status: "done"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+
+	_, err = usecase.ListTasks(context.Background(), admin, Domain.TaskFilter{Sort: "-due_date", Limit: 5000})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListTasks_InvalidSort(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	_, err := usecase.ListTasks(context.Background(), owner, Domain.TaskFilter{Sort: "priority"})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidSort)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything)
+}
 
-func TestGetAllTasks(t *testing.T) {
+func TestListTasks_ScopedToOwner(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
 	usecase := Usecases.NewTaskUsecase(mockRepo)
 
-	expected := []Domain.Task{{ID: "1", Title: "Test Task"}}
-	mockRepo.On("GetAll").Return(expected, nil)
+	mockRepo.On("List", Domain.TaskFilter{Owner: owner.UserID, Limit: Domain.DefaultTaskPageSize}).Return(Domain.TaskPage{}, nil)
 
-	tasks, err := usecase.GetAllTasks()
+	_, err := usecase.ListTasks(context.Background(), owner, Domain.TaskFilter{Owner: admin.UserID})
 
 	assert.NoError(t, err)
-	assert.Equal(t, expected, tasks)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -63,16 +114,30 @@ func TestGetTaskByID(t *testing.T) {
 	usecase := Usecases.NewTaskUsecase(mockRepo)
 
 	taskID := "42"
-	expected := Domain.Task{ID: taskID, Title: "Test Task"}
+	expected := Domain.Task{ID: taskID, Title: "Test Task", Owner: owner.UserID}
 	mockRepo.On("GetByID", taskID).Return(expected, nil)
 
-	task, err := usecase.GetTaskByID(taskID)
+	task, err := usecase.GetTaskByID(context.Background(), owner, taskID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, task)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetTaskByID_OtherOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	task := Domain.Task{ID: "42", Owner: owner.UserID}
+	mockRepo.On("GetByID", task.ID).Return(task, nil)
+
+	_, err := usecase.GetTaskByID(context.Background(), stranger, task.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	fetched, err := usecase.GetTaskByID(context.Background(), admin, task.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, task, fetched)
+}
 
 func TestCreateTask_WithExistingDueDate(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
@@ -80,12 +145,14 @@ func TestCreateTask_WithExistingDueDate(t *testing.T) {
 
 	dueDate := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
 	input := Domain.Task{Title: "Pre-scheduled", DueDate: dueDate}
-	mockRepo.On("Create", input).Return(input, nil)
+	stored := Domain.Task{Title: "Pre-scheduled", DueDate: dueDate, Status: Domain.StatusTodo, Owner: owner.UserID}
+	mockRepo.On("Create", stored).Return(stored, nil)
 
-	result, err := usecase.CreateTask(input)
+	result, err := usecase.CreateTask(context.Background(), owner, input)
 
 	assert.NoError(t, err)
 	assert.Equal(t, dueDate, result.DueDate)
+	assert.Equal(t, owner.UserID, result.Owner)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -94,38 +161,345 @@ func TestUpdateTask(t *testing.T) {
 	usecase := Usecases.NewTaskUsecase(mockRepo)
 
 	taskID := "123"
-	update := Domain.Task{Title: "Updated"}
+	update := Domain.Task{Title: "Updated", DueDate: due, Status: Domain.StatusTodo, Owner: owner.UserID}
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo}, nil)
 	mockRepo.On("Update", taskID, update).Return(update, nil)
 
-	result, err := usecase.UpdateTask(taskID, update)
+	result, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Updated", DueDate: due})
 
 	assert.NoError(t, err)
 	assert.Equal(t, update, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUpdateTask_OtherOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), stranger, taskID, Domain.Task{Title: "Hijacked", DueDate: due})
+
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
 func TestDeleteTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
 	usecase := Usecases.NewTaskUsecase(mockRepo)
 
 	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID}, nil)
 	mockRepo.On("Delete", taskID).Return(nil)
 
-	err := usecase.DeleteTask(taskID)
+	err := usecase.DeleteTask(context.Background(), owner, taskID)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestDeleteTask_OtherOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID}, nil)
+
+	err := usecase.DeleteTask(context.Background(), stranger, taskID)
+
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
 func TestGetTaskByID_Error(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
 	usecase := Usecases.NewTaskUsecase(mockRepo)
 
 	mockRepo.On("GetByID", "missing-id").Return(Domain.Task{}, errors.New("not found"))
 
-	_, err := usecase.GetTaskByID("missing-id")
+	_, err := usecase.GetTaskByID(context.Background(), owner, "missing-id")
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "not found")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCreateTask_UnknownStatus(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	_, err := usecase.CreateTask(context.Background(), owner, Domain.Task{Title: "Odd", Status: "someday"})
+
+	assert.ErrorIs(t, err, Domain.ErrUnknownStatus)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateTask_MustStartInCreatableStatus(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	_, err := usecase.CreateTask(context.Background(), owner, Domain.Task{Title: "Done already", Status: Domain.StatusDone})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidInitialStatus)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	usecase.Workflow.Creatable = []string{Domain.StatusInProgress}
+	mockRepo.On("Create", mock.Anything).Return(Domain.Task{ID: "1", Status: Domain.StatusInProgress}, nil)
+
+	_, err = usecase.CreateTask(context.Background(), owner, Domain.Task{Title: "Started", Status: Domain.StatusInProgress})
+
+	assert.NoError(t, err)
+}
+
+func TestUpdateTask_ValidatesBeforeWriting(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Title: "Title", DueDate: due, Owner: owner.UserID, Status: Domain.StatusTodo}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "No due date"})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidTask)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateTask_AllowedTransition(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	existing := Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo}
+	update := Domain.Task{Title: "Started", DueDate: due, Status: Domain.StatusInProgress, Owner: owner.UserID}
+	mockRepo.On("GetByID", taskID).Return(existing, nil)
+	mockRepo.On("Update", taskID, update).Return(update, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Started", DueDate: due, Status: Domain.StatusInProgress})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateTask_IllegalTransition(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Updated", DueDate: due, Status: Domain.StatusArchived})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidTransition)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateTask_CustomWorkflow(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase.Workflow = Domain.Workflow{
+		Initial:     "open",
+		Transitions: map[string][]string{"open": {"closed"}, "closed": {}},
+	}
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: "closed"}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Updated", DueDate: due, Status: "open"})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidTransition)
+}
+
+func TestUpdateTask_CarriesReadVersion(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo, Version: 4}, nil)
+	mockRepo.On("Update", taskID, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Version == 4
+	})).Return(Domain.Task{ID: taskID, Version: 5}, nil)
+
+	result, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Updated", DueDate: due})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), result.Version)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateTask_StaleVersion(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo, Version: 4}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, taskID, Domain.Task{Title: "Updated", DueDate: due, Version: 3})
+
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_ValidatesBeforeWriting(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	existing := Domain.Task{ID: taskID, Title: "Title", DueDate: time.Now(), Owner: owner.UserID, Status: Domain.StatusTodo, Version: 2}
+	mockRepo.On("GetByID", taskID).Return(existing, nil)
+
+	_, err := usecase.PatchTask(context.Background(), owner, taskID, 0, func(task Domain.Task) (Domain.Task, error) {
+		task.Title = " "
+		return task, nil
+	})
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidTask)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_WritesPatchedTaskAtReadVersion(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	existing := Domain.Task{ID: taskID, Title: "Title", Description: "Keep me", DueDate: time.Now(), Owner: owner.UserID, Status: Domain.StatusTodo, Version: 2}
+	mockRepo.On("GetByID", taskID).Return(existing, nil)
+	mockRepo.On("Update", taskID, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusInProgress && task.Description == "Keep me" && task.Version == 2
+	})).Return(existing, nil)
+
+	_, err := usecase.PatchTask(context.Background(), owner, taskID, 2, func(task Domain.Task) (Domain.Task, error) {
+		task.Status = Domain.StatusInProgress
+		return task, nil
+	})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchTask_StaleVersion(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	taskID := "123"
+	mockRepo.On("GetByID", taskID).Return(Domain.Task{ID: taskID, Owner: owner.UserID, Status: Domain.StatusTodo, Version: 2}, nil)
+
+	_, err := usecase.PatchTask(context.Background(), owner, taskID, 1, func(task Domain.Task) (Domain.Task, error) {
+		t.Fatal("patch must not run against a stale version")
+		return task, nil
+	})
+
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+}
+
+func TestListTrash_ScopedToOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	mockRepo.On("ListTrash", owner.UserID).Return([]Domain.Task{{ID: "1", Owner: owner.UserID}}, nil)
+	mockRepo.On("ListTrash", "").Return([]Domain.Task{{ID: "1"}, {ID: "2"}}, nil)
+
+	mine, err := usecase.ListTrash(context.Background(), owner)
+	assert.NoError(t, err)
+	assert.Len(t, mine, 1)
+
+	all, err := usecase.ListTrash(context.Background(), admin)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreTask_ScopedToOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+
+	mockRepo.On("Restore", "123", stranger.UserID).Return(Domain.Task{}, time.Time{}, Domain.ErrTaskNotFound)
+
+	_, err := usecase.RestoreTask(context.Background(), stranger, "123")
+
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurgeTrash_UsesRetentionCutoff(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase.Audit = audit
+
+	retention := 48 * time.Hour
+	mockRepo.On("PurgeTrash", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= retention && time.Since(before) < retention+time.Minute
+	})).Return([]string{"1", "2", "3"}, nil)
+
+	purged, err := usecase.PurgeTrash(context.Background(), retention)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+
+	entries, err := audit.List(context.Background(), Domain.AuditFilter{Limit: 10})
+	assert.NoError(t, err)
+	var ids []string
+	for _, entry := range entries {
+		assert.Equal(t, Domain.AuditTaskPurge, entry.Action)
+		assert.Equal(t, Domain.AuditSystemActor, entry.Actor)
+		assert.NotEmpty(t, entry.Changes["purged_before"].After)
+		ids = append(ids, entry.EntityID)
+	}
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, ids)
+}
+
+func TestTaskMutations_RecordAudit(t *testing.T) {
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository())
+	usecase.Audit = audit
+	ctx := context.Background()
+
+	created, err := usecase.CreateTask(ctx, owner, Domain.Task{Title: "Write report"})
+	assert.NoError(t, err)
+	_, err = usecase.UpdateTask(ctx, owner, created.ID, Domain.Task{Title: "Write final report", DueDate: due, Status: Domain.StatusInProgress})
+	assert.NoError(t, err)
+	assert.NoError(t, usecase.DeleteTask(ctx, admin, created.ID))
+	_, err = usecase.RestoreTask(ctx, owner, created.ID)
+	assert.NoError(t, err)
+
+	entries, err := audit.List(ctx, Domain.AuditFilter{EntityID: created.ID, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+
+	restored, deleted, updated, create := entries[0], entries[1], entries[2], entries[3]
+	assert.Equal(t, Domain.AuditTaskCreate, create.Action)
+	assert.Equal(t, owner.Email, create.Actor)
+	assert.Equal(t, Domain.FieldChange{After: "Write report"}, create.Changes["title"])
+
+	assert.Equal(t, Domain.AuditTaskUpdate, updated.Action)
+	assert.Equal(t, Domain.FieldChange{Before: "Write report", After: "Write final report"}, updated.Changes["title"])
+	assert.Equal(t, Domain.FieldChange{Before: Domain.StatusTodo, After: Domain.StatusInProgress}, updated.Changes["status"])
+	assert.NotContains(t, updated.Changes, "description")
+
+	assert.Equal(t, Domain.AuditTaskDelete, deleted.Action)
+	assert.Equal(t, admin.Email, deleted.Actor)
+	assert.Contains(t, deleted.Changes, "deleted_at")
+
+	assert.Equal(t, Domain.AuditTaskRestore, restored.Action)
+	assert.NotEmpty(t, restored.Changes["deleted_at"].Before)
+	assert.Empty(t, restored.Changes["deleted_at"].After)
+	assert.Len(t, restored.Changes, 1)
+}
+
+func TestTaskMutations_FailedWritesAreNotAudited(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase.Audit = audit
+
+	mockRepo.On("GetByID", "123").Return(Domain.Task{ID: "123", Owner: owner.UserID, Status: Domain.StatusTodo, Version: 2}, nil)
+	mockRepo.On("Update", "123", mock.Anything).Return(Domain.Task{}, Domain.ErrVersionConflict)
+
+	_, err := usecase.UpdateTask(context.Background(), owner, "123", Domain.Task{Title: "Updated", DueDate: due})
+
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	entries, _ := audit.List(context.Background(), Domain.AuditFilter{Limit: 10})
+	assert.Empty(t, entries)
+}
@@ -1,14 +1,45 @@
 package Usecases
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"task-manager/Domain"
+	"time"
 )
 
 type UserUsecase struct {
 	UserRepo       Domain.IUserRepository
 	PasswordHasher Domain.IPasswordService
 	JWTService     Domain.IJWTService
+	PasswordPolicy Domain.PasswordPolicy
+	// Audit records user changes; nil disables auditing.
+	Audit Domain.IAuditRepository
+	// Mailer sends password reset and verification mail.
+	Mailer Domain.IMailer
+	// ResetTokens must be set, along with Mailer, for password resets.
+	ResetTokens   Domain.IOneTimeTokenRepository
+	ResetTokenTTL time.Duration
+	// ResetURL is the page reset links point at; the token is added as its
+	// "token" query parameter. When empty, the mail carries the bare token.
+	ResetURL string
+	// Verification signs the links mailed to new users so they can verify
+	// their address; nil marks new users verified at once.
+	Verification Domain.IVerificationTokenService
+	// VerifyURL is the page verification links point at, like ResetURL.
+	VerifyURL string
+	// Unverified decides what users who have not verified their address
+	// may do.
+	Unverified Domain.UnverifiedAccess
+	// LoginAttempts counts failed logins so LoginLimits can slow down
+	// password guessing; nil turns the limits off.
+	LoginAttempts Domain.ILoginAttemptStore
+	LoginLimits   Domain.LoginLimits
+	// Metrics, when set, counts successful and failed logins.
+	Metrics Domain.ILoginMetrics
+	// RoleRepo resolves the role of a user being demoted, disabled or
+	// deleted; when nil, Domain.DefaultRoles apply.
+	RoleRepo Domain.IRoleRepository
 }
 
 func NewUserUsecase(repo Domain.IUserRepository, hasher Domain.IPasswordService, jwt Domain.IJWTService) *UserUsecase {
@@ -16,13 +47,34 @@ func NewUserUsecase(repo Domain.IUserRepository, hasher Domain.IPasswordService,
 		UserRepo:       repo,
 		PasswordHasher: hasher,
 		JWTService:     jwt,
+		PasswordPolicy: Domain.DefaultPasswordPolicy(),
+		ResetTokenTTL:  Domain.DefaultResetTokenTTL,
+		LoginLimits:    Domain.DefaultLoginLimits(),
 	}
 }
 
-func (u *UserUsecase) Register(user Domain.User) (Domain.User, error) {
-	_, err := u.UserRepo.FindByEmail(user.Email)
+// Register validates the email and password together so the caller learns
+// about every problem at once. The email is stored normalized. When
+// Verification is set, the new user is mailed a link to verify it.
+func (u *UserUsecase) Register(ctx context.Context, user Domain.User) (Domain.User, error) {
+	user.Email = Domain.NormalizeEmail(user.Email)
+	user.Disabled = false
+	user.Verified = u.Verification == nil
+	var problems []Domain.FieldError
+	if problem := Domain.ValidateEmail(user.Email); problem != nil {
+		problems = append(problems, *problem)
+	}
+	problems = append(problems, u.PasswordPolicy.Check(user.Password)...)
+	if len(problems) > 0 {
+		return Domain.User{}, Domain.ValidationFailed(problems...)
+	}
+
+	_, err := u.UserRepo.FindByEmail(ctx, user.Email)
 	if err == nil {
-		return Domain.User{}, errors.New("email already registered")
+		return Domain.User{}, Domain.ErrEmailTaken
+	}
+	if !errors.Is(err, Domain.ErrUserNotFound) {
+		return Domain.User{}, err
 	}
 
 	hashedPassword, err := u.PasswordHasher.Hash(user.Password)
@@ -31,31 +83,284 @@ func (u *UserUsecase) Register(user Domain.User) (Domain.User, error) {
 	}
 	user.Password = hashedPassword
 
-	createdUser, err := u.UserRepo.Create(user)
+	createdUser, err := u.UserRepo.Create(ctx, user)
 	if err != nil {
 		return Domain.User{}, err
 	}
+	u.audit(ctx, createdUser.Email, Domain.AuditUserCreate, createdUser.ID, Domain.DiffUsers(Domain.User{}, createdUser))
+
+	// The account exists either way; the user can ask for the mail again.
+	if !createdUser.Verified {
+		if err := u.sendVerification(ctx, createdUser); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification mail", "user_id", createdUser.ID, "error", err)
+		}
+	}
 	return createdUser, nil
 }
 
-func (u *UserUsecase) Login(email, password string) (string, error) {
-	user, err := u.UserRepo.FindByEmail(email)
+// findByLoginEmail looks email up normalized and, failing that, as typed:
+// accounts registered before emails were normalized keep their original case.
+func (u *UserUsecase) findByLoginEmail(ctx context.Context, email string) (Domain.User, error) {
+	normalized := Domain.NormalizeEmail(email)
+	user, err := u.UserRepo.FindByEmail(ctx, normalized)
+	if errors.Is(err, Domain.ErrUserNotFound) && normalized != email {
+		return u.UserRepo.FindByEmail(ctx, email)
+	}
+	return user, err
+}
+
+// checkCanSignIn refuses tokens to disabled users and, when Unverified
+// blocks them, to users who have not verified their address.
+func (u *UserUsecase) checkCanSignIn(user Domain.User) error {
+	if user.Disabled {
+		return Domain.ErrUserDisabled
+	}
+	if !user.Verified && u.Unverified == Domain.UnverifiedBlocked {
+		return Domain.ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (u *UserUsecase) issueTokens(user Domain.User) (Domain.TokenPair, error) {
+	access, err := u.JWTService.GenerateToken(user)
+	if err != nil {
+		return Domain.TokenPair{}, errors.New("failed to generate token")
+	}
+	refresh, err := u.JWTService.GenerateRefreshToken(user)
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		return Domain.TokenPair{}, errors.New("failed to generate token")
+	}
+	return Domain.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// Login exchanges credentials for a token pair. When LoginAttempts is set,
+// failures are counted per account and per clientIP, and attempts made too
+// soon after repeated failures are refused without checking the password.
+func (u *UserUsecase) Login(ctx context.Context, email, password, clientIP string) (Domain.TokenPair, error) {
+	pair, err := u.login(ctx, email, password, clientIP)
+	if u.Metrics != nil {
+		var domainErr *Domain.Error
+		switch {
+		case err == nil:
+			u.Metrics.LoginSucceeded()
+		case errors.As(err, &domainErr):
+			u.Metrics.LoginFailed(domainErr.Code)
+		default:
+			u.Metrics.LoginFailed("internal_error")
+		}
+	}
+	return pair, err
+}
+
+func (u *UserUsecase) login(ctx context.Context, email, password, clientIP string) (Domain.TokenPair, error) {
+	var throttles []loginThrottle
+	if u.LoginAttempts != nil {
+		throttles = u.loginThrottles(email, clientIP)
+		if err := u.checkLoginAllowed(ctx, throttles); err != nil {
+			return Domain.TokenPair{}, err
+		}
+	}
+
+	user, err := u.findByLoginEmail(ctx, email)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		slog.WarnContext(ctx, "login failed", "email", email, "reason", "unknown account")
+		u.recordLoginFailure(ctx, throttles)
+		return Domain.TokenPair{}, Domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return Domain.TokenPair{}, err
 	}
 
 	if !u.PasswordHasher.Compare(password, user.Password) {
-		return "", errors.New("invalid credentials")
+		slog.WarnContext(ctx, "login failed", "email", email, "reason", "wrong password")
+		u.recordLoginFailure(ctx, throttles)
+		return Domain.TokenPair{}, Domain.ErrInvalidCredentials
+	}
+	u.clearAccountFailures(ctx, email)
+	// Checked after the password so only the account holder learns the
+	// state of the account.
+	if err := u.checkCanSignIn(user); err != nil {
+		slog.WarnContext(ctx, "login refused", "email", user.Email, "reason", err)
+		return Domain.TokenPair{}, err
+	}
+	u.rehashIfOutdated(ctx, user, password)
+	slog.InfoContext(ctx, "login succeeded", "email", user.Email)
+
+	return u.issueTokens(user)
+}
+
+// rehashIfOutdated replaces a hash made under an older hashing policy while
+// the plain password is at hand. The login goes ahead if it fails; the next
+// one will try again.
+func (u *UserUsecase) rehashIfOutdated(ctx context.Context, user Domain.User, password string) {
+	if !u.PasswordHasher.NeedsRehash(user.Password) {
+		return
+	}
+	hashed, err := u.PasswordHasher.Hash(password)
+	if err == nil {
+		_, err = u.UserRepo.SetPassword(ctx, user.ID, hashed)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to upgrade password hash", "user_id", user.ID, "error", err)
+	}
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// refresh token is revoked so each one can be used only once.
+func (u *UserUsecase) Refresh(ctx context.Context, refreshToken string) (Domain.TokenPair, error) {
+	claims, err := u.JWTService.ValidateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return Domain.TokenPair{}, Domain.ErrInvalidRefresh
+	}
+
+	user, err := u.UserRepo.FindByID(ctx, claims.UserID)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return Domain.TokenPair{}, Domain.ErrInvalidRefresh
+	}
+	if err != nil {
+		return Domain.TokenPair{}, err
+	}
+	if user.TokenRevoked(claims.Generation) {
+		return Domain.TokenPair{}, Domain.ErrInvalidRefresh
+	}
+	if err := u.checkCanSignIn(user); err != nil {
+		return Domain.TokenPair{}, err
+	}
+
+	// Revoking first, and only if nobody else has, makes sure a refresh
+	// token replayed concurrently yields one token pair, not several.
+	err = u.JWTService.RevokeTokenOnce(ctx, claims)
+	if errors.Is(err, Domain.ErrTokenRevoked) {
+		return Domain.TokenPair{}, Domain.ErrInvalidRefresh
+	}
+	if err != nil {
+		return Domain.TokenPair{}, errors.New("failed to revoke refresh token")
 	}
 
-	token, err := u.JWTService.GenerateToken(user)
+	return u.issueTokens(user)
+}
+
+// Logout revokes the caller's access token and the refresh token issued
+// alongside it. The refresh token is required: left alive, it would open
+// the session again long after the access token expired.
+func (u *UserUsecase) Logout(ctx context.Context, access *Domain.AuthClaims, refreshToken string) error {
+	claims, err := u.JWTService.ValidateRefreshToken(ctx, refreshToken)
+	if err != nil || claims.UserID != access.UserID {
+		return Domain.ErrInvalidRefresh
+	}
+	if err := u.JWTService.RevokeToken(ctx, claims); err != nil {
+		return errors.New("failed to revoke refresh token")
+	}
+
+	if err := u.JWTService.RevokeToken(ctx, access); err != nil {
+		return errors.New("failed to revoke token")
+	}
+	return nil
+}
+
+func (u *UserUsecase) PromoteUser(ctx context.Context, actor Domain.AuthClaims, id string) (Domain.User, error) {
+	// The admin role always holds every permission.
+	if !actor.CanGrant(Domain.Role{Name: Domain.RoleAdmin, Permissions: Domain.Permissions}) {
+		return Domain.User{}, Domain.ErrRoleNotGrantable
+	}
+	before, err := u.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	promoted, err := u.UserRepo.SetRole(ctx, id, Domain.RoleAdmin)
 	if err != nil {
-		return "", errors.New("failed to generate token")
+		return Domain.User{}, err
 	}
+	u.audit(ctx, actor.Email, Domain.AuditUserPromote, id, Domain.DiffUsers(before, promoted))
+	return promoted, nil
+}
+
+func (u *UserUsecase) ListUsers(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = Domain.DefaultUserPageSize
+	}
+	if filter.Limit > Domain.MaxUserPageSize {
+		filter.Limit = Domain.MaxUserPageSize
+	}
+	return u.UserRepo.List(ctx, filter)
+}
+
+// checkCanManage refuses to let the actor act on a user whose role grants
+// permissions the actor does not hold, so managing users does not reach
+// anyone more privileged, such as an admin.
+func (u *UserUsecase) checkCanManage(ctx context.Context, actor Domain.AuthClaims, target Domain.User) error {
+	role, err := findRole(ctx, u.RoleRepo, target.Role)
+	if err != nil {
+		return err
+	}
+	if !actor.CanGrant(role) {
+		return Domain.ErrRoleNotGrantable
+	}
+	return nil
+}
 
-	return token, nil
+// DemoteUser gives the user the role new users get.
+func (u *UserUsecase) DemoteUser(ctx context.Context, actor Domain.AuthClaims, id string) (Domain.User, error) {
+	before, err := u.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if err := u.checkCanManage(ctx, actor, before); err != nil {
+		return Domain.User{}, err
+	}
+	demoted, err := u.UserRepo.SetRole(ctx, id, Domain.DefaultRole)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditUserDemote, id, Domain.DiffUsers(before, demoted))
+	return demoted, nil
+}
+
+// SetUserDisabled disables or re-enables an account. A disabled user keeps
+// their data but cannot log in or use tokens issued earlier.
+func (u *UserUsecase) SetUserDisabled(ctx context.Context, actor Domain.AuthClaims, id string, disabled bool) (Domain.User, error) {
+	before, err := u.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if err := u.checkCanManage(ctx, actor, before); err != nil {
+		return Domain.User{}, err
+	}
+	updated, err := u.UserRepo.SetDisabled(ctx, id, disabled)
+	if err != nil {
+		return Domain.User{}, err
+	}
+
+	action := Domain.AuditUserEnable
+	if disabled {
+		action = Domain.AuditUserDisable
+	}
+	u.audit(ctx, actor.Email, action, id, Domain.DiffUsers(before, updated))
+	return updated, nil
+}
+
+// DeleteUser removes an account. Tasks the user owns are kept.
+func (u *UserUsecase) DeleteUser(ctx context.Context, actor Domain.AuthClaims, id string) error {
+	before, err := u.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.checkCanManage(ctx, actor, before); err != nil {
+		return err
+	}
+	if err := u.UserRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.audit(ctx, actor.Email, Domain.AuditUserDelete, id, Domain.DiffUsers(before, Domain.User{}))
+	return nil
 }
 
-func (u *UserUsecase) PromoteUser(id string) (Domain.User, error) {
-	return u.UserRepo.Promote(id)
+func (u *UserUsecase) audit(ctx context.Context, actor, action, id string, changes map[string]Domain.FieldChange) {
+	recordAudit(ctx, u.Audit, Domain.AuditEntry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   Domain.AuditEntityUser,
+		EntityID: id,
+		Changes:  changes,
+	})
 }
@@ -1,10 +1,14 @@
 package Usecases_test
 
 import (
+	"context"
 	"errors"
-	"testing"
+	"sync"
 	"task-manager/Domain"
+	"task-manager/Infrastructure"
+	"task-manager/Repositories"
 	"task-manager/Usecases"
+	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,21 +20,56 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (Domain.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (Domain.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Create(user Domain.User) (Domain.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, user Domain.User) (Domain.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Promote(id string) (Domain.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (Domain.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, id string, role string) (Domain.User, error) {
+	args := m.Called(id, role)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filter Domain.UserFilter) (Domain.UserPage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(Domain.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	args := m.Called(id, disabled)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetVerified(ctx context.Context, id string, verified bool) (Domain.User, error) {
+	args := m.Called(id, verified)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetPassword(ctx context.Context, id string, hash string) (Domain.User, error) {
+	args := m.Called(id, hash)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) NextTokenGeneration(ctx context.Context, id string) (Domain.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 type MockPasswordService struct {
 	mock.Mock
 }
@@ -45,6 +84,11 @@ func (m *MockPasswordService) Compare(plain, hashed string) bool {
 	return args.Bool(0)
 }
 
+func (m *MockPasswordService) NeedsRehash(hashed string) bool {
+	args := m.Called(hashed)
+	return args.Bool(0)
+}
+
 type MockJWTService struct {
 	mock.Mock
 }
@@ -54,11 +98,32 @@ func (m *MockJWTService) GenerateToken(user Domain.User) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockJWTService) ValidateToken(tokenString string) (*Domain.AuthClaims, error) {
+func (m *MockJWTService) ValidateToken(ctx context.Context, tokenString string) (*Domain.AuthClaims, error) {
 	args := m.Called(tokenString)
 	return args.Get(0).(*Domain.AuthClaims), args.Error(1)
 }
 
+func (m *MockJWTService) GenerateRefreshToken(user Domain.User) (string, error) {
+	args := m.Called(user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) ValidateRefreshToken(ctx context.Context, tokenString string) (*Domain.AuthClaims, error) {
+	args := m.Called(tokenString)
+	claims, _ := args.Get(0).(*Domain.AuthClaims)
+	return claims, args.Error(1)
+}
+
+func (m *MockJWTService) RevokeToken(ctx context.Context, claims *Domain.AuthClaims) error {
+	args := m.Called(claims)
+	return args.Error(0)
+}
+
+func (m *MockJWTService) RevokeTokenOnce(ctx context.Context, claims *Domain.AuthClaims) error {
+	args := m.Called(claims)
+	return args.Error(0)
+}
+
 // test
 
 func TestRegister_Success(t *testing.T) {
@@ -67,17 +132,17 @@ func TestRegister_Success(t *testing.T) {
 	mockJWT := new(MockJWTService)
 	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
 
-	user := Domain.User{Email: "anansi@test.com", Password: "password123"}
+	user := Domain.User{Email: "anansi@test.com", Password: "Correct-Horse-9"}
 	hashedPassword := "hashed_password"
 	expectedUser := Domain.User{ID: "1", Email: "anansi@test.com", Password: hashedPassword}
 
-	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, errors.New("not found"))
+	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, Domain.ErrUserNotFound)
 	mockHasher.On("Hash", user.Password).Return(hashedPassword, nil)
 	mockRepo.On("Create", mock.MatchedBy(func(u Domain.User) bool {
 		return u.Email == user.Email && u.Password == hashedPassword
 	})).Return(expectedUser, nil)
 
-	result, err := usecase.Register(user)
+	result, err := usecase.Register(context.Background(), user)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUser, result)
@@ -91,12 +156,12 @@ func TestRegister_EmailAlreadyExists(t *testing.T) {
 	mockJWT := new(MockJWTService)
 	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
 
-	user := Domain.User{Email: "existing@example.com", Password: "password123"}
+	user := Domain.User{Email: "existing@example.com", Password: "Correct-Horse-9"}
 	existingUser := Domain.User{ID: "1", Email: "existing@example.com"}
 
 	mockRepo.On("FindByEmail", user.Email).Return(existingUser, nil)
 
-	result, err := usecase.Register(user)
+	result, err := usecase.Register(context.Background(), user)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "email already registered")
@@ -110,12 +175,12 @@ func TestRegister_HashPasswordFails(t *testing.T) {
 	mockJWT := new(MockJWTService)
 	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
 
-	user := Domain.User{Email: "new@example.com", Password: "password123"}
+	user := Domain.User{Email: "new@example.com", Password: "Correct-Horse-9"}
 
-	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, errors.New("not found"))
+	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, Domain.ErrUserNotFound)
 	mockHasher.On("Hash", user.Password).Return("", errors.New("hash failure"))
 
-	result, err := usecase.Register(user)
+	result, err := usecase.Register(context.Background(), user)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "failed to hash password")
@@ -130,14 +195,14 @@ func TestRegister_CreateUserFails(t *testing.T) {
 	mockJWT := new(MockJWTService)
 	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
 
-	user := Domain.User{Email: "new@example.com", Password: "password123"}
+	user := Domain.User{Email: "new@example.com", Password: "Correct-Horse-9"}
 	hashedPassword := "hashed_password"
 
-	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, errors.New("not found"))
+	mockRepo.On("FindByEmail", user.Email).Return(Domain.User{}, Domain.ErrUserNotFound)
 	mockHasher.On("Hash", user.Password).Return(hashedPassword, nil)
 	mockRepo.On("Create", mock.Anything).Return(Domain.User{}, errors.New("db error"))
 
-	result, err := usecase.Register(user)
+	result, err := usecase.Register(context.Background(), user)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "db error")
@@ -160,12 +225,15 @@ func TestLogin_Success(t *testing.T) {
 
 	mockRepo.On("FindByEmail", email).Return(user, nil)
 	mockHasher.On("Compare", password, hashedPassword).Return(true)
+	mockHasher.On("NeedsRehash", hashedPassword).Return(false)
 	mockJWT.On("GenerateToken", user).Return(expectedToken, nil)
+	mockJWT.On("GenerateRefreshToken", user).Return("refresh_token", nil)
 
-	token, err := usecase.Login(email, password)
+	tokens, err := usecase.Login(context.Background(), email, password, "")
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedToken, token)
+	assert.Equal(t, expectedToken, tokens.AccessToken)
+	assert.Equal(t, "refresh_token", tokens.RefreshToken)
 	mockRepo.AssertExpectations(t)
 	mockHasher.AssertExpectations(t)
 	mockJWT.AssertExpectations(t)
@@ -185,11 +253,11 @@ func TestLogin_InvalidPassword(t *testing.T) {
 	mockRepo.On("FindByEmail", email).Return(user, nil)
 	mockHasher.On("Compare", password, hashedPassword).Return(false)
 
-	token, err := usecase.Login(email, password)
+	tokens, err := usecase.Login(context.Background(), email, password, "")
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid credentials")
-	assert.Empty(t, token)
+	assert.Empty(t, tokens)
 	mockRepo.AssertExpectations(t)
 	mockHasher.AssertExpectations(t)
 }
@@ -202,13 +270,30 @@ func TestLogin_UserNotFound(t *testing.T) {
 
 	email := "missing@example.com"
 
-	mockRepo.On("FindByEmail", email).Return(Domain.User{}, errors.New("not found"))
+	mockRepo.On("FindByEmail", email).Return(Domain.User{}, Domain.ErrUserNotFound)
 
-	token, err := usecase.Login(email, "any_password")
+	tokens, err := usecase.Login(context.Background(), email, "any_password", "")
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid credentials")
-	assert.Empty(t, token)
+	assert.Empty(t, tokens)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_RepositoryFailureIsNotInvalidCredentials(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	email := "user@example.com"
+
+	mockRepo.On("FindByEmail", email).Return(Domain.User{}, errors.New("connection refused"))
+
+	_, err := usecase.Login(context.Background(), email, "any_password", "")
+
+	assert.EqualError(t, err, "connection refused")
+	assert.NotErrorIs(t, err, Domain.ErrInvalidCredentials)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -225,18 +310,158 @@ func TestLogin_TokenGenerationFails(t *testing.T) {
 
 	mockRepo.On("FindByEmail", email).Return(user, nil)
 	mockHasher.On("Compare", password, hashedPassword).Return(true)
+	mockHasher.On("NeedsRehash", hashedPassword).Return(false)
 	mockJWT.On("GenerateToken", user).Return("", errors.New("token error"))
 
-	token, err := usecase.Login(email, password)
+	tokens, err := usecase.Login(context.Background(), email, password, "")
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "failed to generate token")
-	assert.Empty(t, token)
+	assert.Empty(t, tokens)
 	mockRepo.AssertExpectations(t)
 	mockHasher.AssertExpectations(t)
 	mockJWT.AssertExpectations(t)
 }
 
+func TestRefresh_RotatesRefreshToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	user := Domain.User{ID: "1", Email: "anansi@test.com", Role: "user"}
+	claims := &Domain.AuthClaims{ID: "old-jti", UserID: user.ID, Email: user.Email, Type: Domain.RefreshToken}
+
+	mockJWT.On("ValidateRefreshToken", "old-refresh").Return(claims, nil)
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockJWT.On("RevokeTokenOnce", claims).Return(nil)
+	mockJWT.On("GenerateToken", user).Return("new-access", nil)
+	mockJWT.On("GenerateRefreshToken", user).Return("new-refresh", nil)
+
+	tokens, err := usecase.Refresh(context.Background(), "old-refresh")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.TokenPair{AccessToken: "new-access", RefreshToken: "new-refresh"}, tokens)
+	mockRepo.AssertExpectations(t)
+	mockJWT.AssertExpectations(t)
+}
+
+func TestRefresh_InvalidToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	mockJWT.On("ValidateRefreshToken", "revoked").Return(nil, errors.New("invalid token: revoked"))
+
+	_, err := usecase.Refresh(context.Background(), "revoked")
+
+	assert.EqualError(t, err, "invalid refresh token")
+	mockJWT.AssertNotCalled(t, "GenerateToken", mock.Anything)
+}
+
+// barrierRevocationStore holds every IsRevoked call until all expected
+// callers have made one, so they all see the token as unrevoked and then
+// race to revoke it.
+type barrierRevocationStore struct {
+	Domain.ITokenRevocationStore
+	checked sync.WaitGroup
+}
+
+func (s *barrierRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	revoked, err := s.ITokenRevocationStore.IsRevoked(ctx, id)
+	s.checked.Done()
+	s.checked.Wait()
+	return revoked, err
+}
+
+func TestRefresh_ConcurrentUseSucceedsOnce(t *testing.T) {
+	users := Repositories.NewMemoryUserRepository()
+	user, err := users.Create(context.Background(), Domain.User{Email: "anansi@test.com", Verified: true})
+	assert.NoError(t, err)
+
+	keys, err := Infrastructure.NewKeySet(Infrastructure.NewHMACKey("default", []byte("test-secret-key-for-testing-1234")))
+	assert.NoError(t, err)
+	const callers = 8
+	store := &barrierRevocationStore{ITokenRevocationStore: Infrastructure.NewInMemoryRevocationStore()}
+	store.checked.Add(callers)
+	jwtService := Infrastructure.NewJWTServiceWithKeys(keys, store)
+	usecase := Usecases.NewUserUsecase(users, new(MockPasswordService), jwtService)
+
+	refresh, err := jwtService.GenerateRefreshToken(user)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = usecase.Refresh(context.Background(), refresh)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, Domain.ErrInvalidRefresh)
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestLogout_RevokesBothTokens(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	access := &Domain.AuthClaims{ID: "access-jti", UserID: "1", Email: "anansi@test.com", Type: Domain.AccessToken}
+	refresh := &Domain.AuthClaims{ID: "refresh-jti", UserID: "1", Email: "anansi@test.com", Type: Domain.RefreshToken}
+
+	mockJWT.On("ValidateRefreshToken", "refresh").Return(refresh, nil)
+	mockJWT.On("RevokeToken", refresh).Return(nil)
+	mockJWT.On("RevokeToken", access).Return(nil)
+
+	err := usecase.Logout(context.Background(), access, "refresh")
+
+	assert.NoError(t, err)
+	mockJWT.AssertExpectations(t)
+}
+
+func TestLogout_RequiresTheRefreshToken(t *testing.T) {
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(new(MockUserRepository), new(MockPasswordService), mockJWT)
+	access := &Domain.AuthClaims{ID: "access-jti", UserID: "1", Type: Domain.AccessToken}
+
+	mockJWT.On("ValidateRefreshToken", "").Return(nil, errors.New("invalid or expired token"))
+
+	err := usecase.Logout(context.Background(), access, "")
+
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefresh)
+	mockJWT.AssertNotCalled(t, "RevokeToken", mock.Anything)
+}
+
+func TestLogout_RejectsSomeoneElsesRefreshToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	access := &Domain.AuthClaims{ID: "access-jti", UserID: "1", Email: "anansi@test.com"}
+	refresh := &Domain.AuthClaims{ID: "refresh-jti", UserID: "2", Email: "other@test.com"}
+
+	mockJWT.On("ValidateRefreshToken", "refresh").Return(refresh, nil)
+
+	err := usecase.Logout(context.Background(), access, "refresh")
+
+	assert.EqualError(t, err, "invalid refresh token")
+	mockJWT.AssertNotCalled(t, "RevokeToken", mock.Anything)
+}
+
 func TestPromoteUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockHasher := new(MockPasswordService)
@@ -246,15 +471,27 @@ func TestPromoteUser_Success(t *testing.T) {
 	userID := "123"
 	promotedUser := Domain.User{ID: userID, Email: "promoted@example.com", Role: "admin"}
 
-	mockRepo.On("Promote", userID).Return(promotedUser, nil)
+	mockRepo.On("FindByID", userID).Return(Domain.User{ID: userID, Email: "promoted@example.com", Role: "user"}, nil)
+	mockRepo.On("SetRole", userID, Domain.RoleAdmin).Return(promotedUser, nil)
 
-	result, err := usecase.PromoteUser(userID)
+	result, err := usecase.PromoteUser(context.Background(), admin, userID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, promotedUser, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestPromoteUser_RequiresEveryAdminPermission(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, new(MockPasswordService), new(MockJWTService))
+	promoter := Domain.AuthClaims{UserID: "promoter-id", Permissions: []string{Domain.PermUsersPromote}}
+
+	_, err := usecase.PromoteUser(context.Background(), promoter, "123")
+
+	assert.ErrorIs(t, err, Domain.ErrRoleNotGrantable)
+	mockRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+}
+
 func TestPromoteUser_Error(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockHasher := new(MockPasswordService)
@@ -263,12 +500,228 @@ func TestPromoteUser_Error(t *testing.T) {
 
 	userID := "123"
 
-	mockRepo.On("Promote", userID).Return(Domain.User{}, errors.New("promotion failed"))
+	mockRepo.On("FindByID", userID).Return(Domain.User{ID: userID, Role: "user"}, nil)
+	mockRepo.On("SetRole", userID, Domain.RoleAdmin).Return(Domain.User{}, errors.New("promotion failed"))
 
-	result, err := usecase.PromoteUser(userID)
+	result, err := usecase.PromoteUser(context.Background(), admin, userID)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "promotion failed")
 	assert.Equal(t, Domain.User{}, result)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestPromoteUser_RecordsAudit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewUserUsecase(mockRepo, new(MockPasswordService), new(MockJWTService))
+	usecase.Audit = audit
+
+	mockRepo.On("FindByID", "123").Return(Domain.User{ID: "123", Email: "promoted@example.com", Password: "hash", Role: "user"}, nil)
+	mockRepo.On("SetRole", "123", Domain.RoleAdmin).Return(Domain.User{ID: "123", Email: "promoted@example.com", Password: "hash", Role: "admin"}, nil)
+
+	_, err := usecase.PromoteUser(context.Background(), admin, "123")
+	assert.NoError(t, err)
+
+	entries, err := audit.List(context.Background(), Domain.AuditFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, admin.Email, entries[0].Actor)
+	assert.Equal(t, Domain.AuditUserPromote, entries[0].Action)
+	assert.Equal(t, "123", entries[0].EntityID)
+	assert.Equal(t, map[string]Domain.FieldChange{"role": {Before: "user", After: "admin"}}, entries[0].Changes)
+}
+
+func TestPromoteUser_UnknownUserIsNotAudited(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewUserUsecase(mockRepo, new(MockPasswordService), new(MockJWTService))
+	usecase.Audit = audit
+
+	mockRepo.On("FindByID", "missing").Return(Domain.User{}, Domain.ErrUserNotFound)
+
+	_, err := usecase.PromoteUser(context.Background(), admin, "missing")
+
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+	mockRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+	entries, _ := audit.List(context.Background(), Domain.AuditFilter{Limit: 10})
+	assert.Empty(t, entries)
+}
+
+func TestManageUser_RejectsUsersBeyondTheActor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	roles := Repositories.NewMemoryRoleRepository()
+	_ = roles.Save(context.Background(), Domain.Role{Name: "support", Permissions: []string{Domain.PermTasksRead, Domain.PermUsersManage}})
+	usecase := Usecases.NewUserUsecase(mockRepo, new(MockPasswordService), new(MockJWTService))
+	usecase.RoleRepo = roles
+	support := Domain.AuthClaims{UserID: "support-id", Role: "support", Permissions: []string{Domain.PermTasksRead, Domain.PermUsersManage}}
+
+	mockRepo.On("FindByID", "admin-id").Return(Domain.User{ID: "admin-id", Role: Domain.RoleAdmin}, nil)
+	_, err := usecase.DemoteUser(context.Background(), support, "admin-id")
+	assert.ErrorIs(t, err, Domain.ErrRoleNotGrantable)
+	_, err = usecase.SetUserDisabled(context.Background(), support, "admin-id", true)
+	assert.ErrorIs(t, err, Domain.ErrRoleNotGrantable)
+	assert.ErrorIs(t, usecase.DeleteUser(context.Background(), support, "admin-id"), Domain.ErrRoleNotGrantable)
+	mockRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "SetDisabled", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+
+	// Users whose role grants nothing beyond the actor's are fair game.
+	mockRepo.On("FindByID", "viewer-id").Return(Domain.User{ID: "viewer-id", Role: Domain.RoleViewer}, nil)
+	mockRepo.On("SetDisabled", "viewer-id", true).Return(Domain.User{ID: "viewer-id", Role: Domain.RoleViewer, Disabled: true}, nil)
+	_, err = usecase.SetUserDisabled(context.Background(), support, "viewer-id", true)
+	assert.NoError(t, err)
+}
+
+func TestLogin_DisabledUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	user := Domain.User{ID: "1", Email: "gone@example.com", Password: "hash", Disabled: true}
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockHasher.On("Compare", "password", "hash").Return(true)
+
+	_, err := usecase.Login(context.Background(), user.Email, "password", "")
+
+	assert.ErrorIs(t, err, Domain.ErrUserDisabled)
+	mockJWT.AssertNotCalled(t, "GenerateToken", mock.Anything)
+}
+
+func TestLastAdminCannotBeRemoved(t *testing.T) {
+	users := Repositories.NewMemoryUserRepository()
+	usecase := Usecases.NewUserUsecase(users, new(MockPasswordService), new(MockJWTService))
+	roles := Usecases.NewRoleUsecase(Repositories.NewMemoryRoleRepository(), users)
+	ctx := context.Background()
+	assert.NoError(t, roles.SeedDefaults(ctx))
+
+	only, _ := users.Create(ctx, Domain.User{Email: "admin@example.com"})
+
+	_, err := usecase.DemoteUser(ctx, admin, only.ID)
+	assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+	_, err = usecase.SetUserDisabled(ctx, admin, only.ID, true)
+	assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+	assert.ErrorIs(t, usecase.DeleteUser(ctx, admin, only.ID), Domain.ErrLastAdmin)
+	_, err = roles.AssignRole(ctx, admin, only.ID, Domain.RoleManager)
+	assert.ErrorIs(t, err, Domain.ErrLastAdmin)
+
+	other, _ := users.Create(ctx, Domain.User{Email: "second@example.com"})
+	_, err = usecase.PromoteUser(ctx, admin, other.ID)
+	assert.NoError(t, err)
+
+	demoted, err := usecase.DemoteUser(ctx, admin, only.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.DefaultRole, demoted.Role)
+	assert.ErrorIs(t, usecase.DeleteUser(ctx, admin, other.ID), Domain.ErrLastAdmin)
+}
+
+func TestSetUserDisabled_RecordsAudit(t *testing.T) {
+	users := Repositories.NewMemoryUserRepository()
+	audit := Repositories.NewMemoryAuditRepository()
+	usecase := Usecases.NewUserUsecase(users, new(MockPasswordService), new(MockJWTService))
+	usecase.Audit = audit
+	ctx := context.Background()
+
+	_, _ = users.Create(ctx, Domain.User{Email: "admin@example.com"})
+	member, _ := users.Create(ctx, Domain.User{Email: "member@example.com"})
+
+	_, err := usecase.SetUserDisabled(ctx, admin, member.ID, true)
+	assert.NoError(t, err)
+	_, err = usecase.SetUserDisabled(ctx, admin, member.ID, false)
+	assert.NoError(t, err)
+
+	entries, _ := audit.List(ctx, Domain.AuditFilter{Limit: 10})
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, Domain.AuditUserEnable, entries[0].Action)
+		assert.Equal(t, Domain.AuditUserDisable, entries[1].Action)
+		assert.Equal(t, Domain.FieldChange{Before: "false", After: "true"}, entries[1].Changes["disabled"])
+	}
+}
+
+func TestRegister_ReportsEveryFieldProblem(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, new(MockPasswordService), new(MockJWTService))
+
+	_, err := usecase.Register(context.Background(), Domain.User{Email: "not an email", Password: "short"})
+
+	assert.ErrorIs(t, err, Domain.ErrValidationFailed)
+	var domainErr *Domain.Error
+	if assert.ErrorAs(t, err, &domainErr) {
+		codes := map[string][]string{}
+		for _, field := range domainErr.Fields {
+			codes[field.Field] = append(codes[field.Field], field.Code)
+		}
+		assert.Equal(t, []string{"invalid_email"}, codes["email"])
+		assert.Equal(t, []string{"too_short", "missing_uppercase", "missing_digit"}, codes["password"])
+	}
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+}
+
+func TestRegister_RejectsEmptyPassword(t *testing.T) {
+	usecase := Usecases.NewUserUsecase(new(MockUserRepository), new(MockPasswordService), new(MockJWTService))
+
+	_, err := usecase.Register(context.Background(), Domain.User{Email: "ada@example.com"})
+
+	var domainErr *Domain.Error
+	if assert.ErrorAs(t, err, &domainErr) && assert.Len(t, domainErr.Fields, 1) {
+		assert.Equal(t, Domain.FieldError{Field: "password", Code: "required", Message: "password is required"}, domainErr.Fields[0])
+	}
+}
+
+func TestRegister_NormalizesEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, new(MockJWTService))
+
+	mockRepo.On("FindByEmail", "ada@example.com").Return(Domain.User{}, Domain.ErrUserNotFound)
+	mockHasher.On("Hash", "Correct-Horse-9").Return("hash", nil)
+	mockRepo.On("Create", mock.MatchedBy(func(u Domain.User) bool {
+		return u.Email == "ada@example.com"
+	})).Return(Domain.User{ID: "1", Email: "ada@example.com"}, nil)
+
+	_, err := usecase.Register(context.Background(), Domain.User{Email: "  Ada@Example.COM ", Password: "Correct-Horse-9"})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_FallsBackToEmailAsTyped(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(mockRepo, mockHasher, mockJWT)
+
+	legacy := Domain.User{ID: "1", Email: "Ada@Example.com", Password: "hash"}
+	mockRepo.On("FindByEmail", "ada@example.com").Return(Domain.User{}, Domain.ErrUserNotFound)
+	mockRepo.On("FindByEmail", "Ada@Example.com").Return(legacy, nil)
+	mockHasher.On("Compare", "secret", "hash").Return(true)
+	mockHasher.On("NeedsRehash", "hash").Return(false)
+	mockJWT.On("GenerateToken", legacy).Return("access", nil)
+	mockJWT.On("GenerateRefreshToken", legacy).Return("refresh", nil)
+
+	tokens, err := usecase.Login(context.Background(), "Ada@Example.com", "secret", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "access", tokens.AccessToken)
+}
+
+func TestLogin_UpgradesOutdatedHash(t *testing.T) {
+	users := Repositories.NewMemoryUserRepository()
+	mockHasher := new(MockPasswordService)
+	mockJWT := new(MockJWTService)
+	usecase := Usecases.NewUserUsecase(users, mockHasher, mockJWT)
+	user, _ := users.Create(context.Background(), Domain.User{Email: "ada@example.com", Password: "old-hash", Verified: true})
+
+	mockHasher.On("Compare", "Correct-Horse-9", "old-hash").Return(true)
+	mockHasher.On("NeedsRehash", "old-hash").Return(true)
+	mockHasher.On("Hash", "Correct-Horse-9").Return("new-hash", nil)
+	mockJWT.On("GenerateToken", mock.Anything).Return("access", nil)
+	mockJWT.On("GenerateRefreshToken", mock.Anything).Return("refresh", nil)
+
+	_, err := usecase.Login(context.Background(), "ada@example.com", "Correct-Horse-9", "")
+
+	assert.NoError(t, err)
+	stored, _ := users.FindByID(context.Background(), user.ID)
+	assert.Equal(t, "new-hash", stored.Password)
+}